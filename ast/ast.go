@@ -4,13 +4,14 @@ import (
 	"let_lang_proj_michael_andrepont/token"
 	"errors"
 	"fmt"
-	"math"
+	"math/big"
 	"strings"
 )
 
 type Binding struct {
 	VarName string
-	Value   int
+	Value   ExpVal
+	Rec     *RecProc //Set instead of Value for letrec bindings, the closure is built on lookup.
 }
 type BindingList = []Binding
 
@@ -18,7 +19,11 @@ func GetEnvStr(bl *BindingList) string {
 	if bl != nil {
 		str := "[< "
 		for _, b := range *bl {
-			str += fmt.Sprintf("(%s %d) ", b.VarName, b.Value)
+			if b.Rec != nil {
+				str += fmt.Sprintf("(%s %s) ", b.VarName, b.Rec)
+			} else {
+				str += fmt.Sprintf("(%s %s) ", b.VarName, b.Value)
+			}
 		}
 		str += ">]"
 		return str
@@ -26,13 +31,20 @@ func GetEnvStr(bl *BindingList) string {
 	return "[< >]"
 }
 
-func findIdentifierInEnv(varName string, env BindingList) (int, error) {
-	for _, b := range env {
+func findIdentifierInEnv(varName string, env BindingList, rt *Runtime) (ExpVal, error) {
+	for i, b := range env {
 		if b.VarName == varName {
+			if b.Rec != nil {
+				return b.Rec.closure(env[i-b.Rec.Index:]), nil
+			}
+			//With implicit refs the binding holds the variable's location, not its value.
+			if ref, ok := b.Value.(RefVal); ok && rt.ImplicitRefs {
+				return rt.Store.Deref(ref)
+			}
 			return b.Value, nil
 		}
 	}
-	return -1, errors.New(fmt.Sprintf("Could not find variable name: %s in env of: %#v", varName, env))
+	return nil, errors.New(fmt.Sprintf("Could not find variable name: %s in env of: %#v", varName, env))
 }
 
 func indentStr(indentLevel int) string {
@@ -46,18 +58,33 @@ type Node interface {
 
 type Expression interface {
 	Node
-	Eval(env BindingList) (int, error)
+	Eval(env BindingList, rt *Runtime) (ExpVal, error)
 	GetEnv() *BindingList
 	SetEnv(*BindingList)
+	GetToken() token.Token
+	GetType() Type
+	SetType(Type)
 }
 
 type BaseExpression struct {
 	Token token.Token //IS_ZERO Token
 	env   *BindingList
+	typ   Type
 }
 
 func (be *BaseExpression) GetEnv() *BindingList    { return be.env }
 func (be *BaseExpression) SetEnv(env *BindingList) { be.env = env }
+func (be *BaseExpression) GetToken() token.Token   { return be.Token }
+func (be *BaseExpression) GetType() Type           { return be.typ }
+func (be *BaseExpression) SetType(t Type)          { be.typ = t }
+
+//details is what Print shows after a node's name, its env, and its type once one is set.
+func (be *BaseExpression) details() string {
+	if be.typ == nil {
+		return GetEnvStr(be.env)
+	}
+	return GetEnvStr(be.env) + " : " + be.typ.String()
+}
 
 type LetExpression struct {
 	BaseExpression
@@ -66,49 +93,176 @@ type LetExpression struct {
 	In    Expression
 }
 
-func (e *LetExpression) Eval(env BindingList) (int, error) {
+func (e *LetExpression) Eval(env BindingList, rt *Runtime) (ExpVal, error) {
 	e.SetEnv(&env)
 	e.Name.SetEnv(&env)
 	varName := e.Name.Value
-	value, err := e.Value.Eval(env)
+	value, err := e.Value.Eval(env, rt)
 	if err != nil {
-		return math.MaxInt32, err
+		return nil, err
 	}
-	newEnv := append(BindingList{{VarName: varName, Value: value}}, env...)
-	return e.In.Eval(newEnv)
+	newEnv := append(BindingList{rt.NewBinding(varName, value)}, env...)
+	return e.In.Eval(newEnv, rt)
 }
 
 func (e *LetExpression) Print(indentLevel int) {
-	fmt.Printf("%s%s %s\n", indentStr(indentLevel), "let", GetEnvStr(e.env))
+	fmt.Printf("%s%s %s\n", indentStr(indentLevel), "let", e.details())
 	e.Name.Print(indentLevel + 1)
 	e.Value.Print(indentLevel + 1)
 	e.In.Print(indentLevel + 1)
 }
 
+type LetBinding struct {
+	Name  *Identifier
+	Value Expression
+}
+
+//MultiLetExpression binds all of its names at once, every value is evaluated in the outer env.
+type MultiLetExpression struct {
+	BaseExpression
+	Bindings []*LetBinding
+	In       Expression
+}
+
+func (e *MultiLetExpression) Eval(env BindingList, rt *Runtime) (ExpVal, error) {
+	e.SetEnv(&env)
+	newEnv := make(BindingList, 0, len(e.Bindings)+len(env))
+	for _, binding := range e.Bindings {
+		binding.Name.SetEnv(&env)
+		value, err := binding.Value.Eval(env, rt)
+		if err != nil {
+			return nil, err
+		}
+		newEnv = append(newEnv, rt.NewBinding(binding.Name.Value, value))
+	}
+	newEnv = append(newEnv, env...)
+	return e.In.Eval(newEnv, rt)
+}
+
+func (e *MultiLetExpression) Print(indentLevel int) {
+	fmt.Printf("%s%s %s\n", indentStr(indentLevel), "let", e.details())
+	for _, binding := range e.Bindings {
+		binding.Name.Print(indentLevel + 1)
+		binding.Value.Print(indentLevel + 1)
+	}
+	e.In.Print(indentLevel + 1)
+}
+
+//LetStarExpression binds its names in order, each value can see the names bound before it.
+type LetStarExpression struct {
+	BaseExpression
+	Bindings []*LetBinding
+	In       Expression
+}
+
+func (e *LetStarExpression) Eval(env BindingList, rt *Runtime) (ExpVal, error) {
+	e.SetEnv(&env)
+	newEnv := env
+	for _, binding := range e.Bindings {
+		bindingEnv := newEnv
+		binding.Name.SetEnv(&bindingEnv)
+		value, err := binding.Value.Eval(bindingEnv, rt)
+		if err != nil {
+			return nil, err
+		}
+		newEnv = append(BindingList{rt.NewBinding(binding.Name.Value, value)}, bindingEnv...)
+	}
+	return e.In.Eval(newEnv, rt)
+}
+
+func (e *LetStarExpression) Print(indentLevel int) {
+	fmt.Printf("%s%s %s\n", indentStr(indentLevel), "let*", e.details())
+	for _, binding := range e.Bindings {
+		binding.Name.Print(indentLevel + 1)
+		binding.Value.Print(indentLevel + 1)
+	}
+	e.In.Print(indentLevel + 1)
+}
+
 type Identifier struct {
 	BaseExpression
 	Value string
 }
 
-func (e *Identifier) Eval(env BindingList) (int, error) {
+func (e *Identifier) Eval(env BindingList, rt *Runtime) (ExpVal, error) {
 	e.SetEnv(&env)
-	return findIdentifierInEnv(e.Value, env)
+	return findIdentifierInEnv(e.Value, env, rt)
 }
 func (e *Identifier) Print(indentLevel int) {
-	fmt.Printf("%s%s %s\n", indentStr(indentLevel), e.Value, GetEnvStr(e.env))
+	fmt.Printf("%s%s %s\n", indentStr(indentLevel), e.Value, e.details())
 }
 
 type IntLiteral struct {
 	BaseExpression
 	Value int
+	Big   *big.Int //Set instead of Value when the literal does not fit in an int.
+}
+
+//Num returns the literal as a value, the runtime's Number converts it for bignum mode.
+func (e *IntLiteral) Num() ExpVal {
+	if e.Big != nil {
+		return BigNumVal{Value: e.Big}
+	}
+	return NumVal{Value: e.Value}
 }
 
-func (e *IntLiteral) Eval(env BindingList) (int, error) {
+func (e *IntLiteral) Eval(env BindingList, rt *Runtime) (ExpVal, error) {
 	e.SetEnv(&env)
-	return e.Value, nil
+	return rt.Number(e.Num())
 }
 func (e *IntLiteral) Print(indentLevel int) {
-	fmt.Printf("%s%d %s\n", indentStr(indentLevel), e.Value, GetEnvStr(e.env))
+	fmt.Printf("%s%s %s\n", indentStr(indentLevel), e.Num(), e.details())
+}
+
+type FloatLiteral struct {
+	BaseExpression
+	Value float64
+}
+
+func (e *FloatLiteral) Eval(env BindingList, rt *Runtime) (ExpVal, error) {
+	e.SetEnv(&env)
+	return FloatVal{Value: e.Value}, nil
+}
+func (e *FloatLiteral) Print(indentLevel int) {
+	fmt.Printf("%s%s %s\n", indentStr(indentLevel), FloatVal{Value: e.Value}, e.details())
+}
+
+type StringLiteral struct {
+	BaseExpression
+	Value string
+}
+
+func (e *StringLiteral) Eval(env BindingList, rt *Runtime) (ExpVal, error) {
+	e.SetEnv(&env)
+	return StrVal{Value: e.Value}, nil
+}
+func (e *StringLiteral) Print(indentLevel int) {
+	fmt.Printf("%s%s %s\n", indentStr(indentLevel), StrVal{Value: e.Value}, e.details())
+}
+
+type BoolLiteral struct {
+	BaseExpression
+	Value bool
+}
+
+func (e *BoolLiteral) Eval(env BindingList, rt *Runtime) (ExpVal, error) {
+	e.SetEnv(&env)
+	return BoolVal{Value: e.Value}, nil
+}
+func (e *BoolLiteral) Print(indentLevel int) {
+	fmt.Printf("%s%t %s\n", indentStr(indentLevel), e.Value, e.details())
+}
+
+type EmptyListLiteral struct {
+	BaseExpression
+}
+
+func (e *EmptyListLiteral) Eval(env BindingList, rt *Runtime) (ExpVal, error) {
+	e.SetEnv(&env)
+	return EmptyListVal{}, nil
+}
+func (e *EmptyListLiteral) Print(indentLevel int) {
+	fmt.Printf("%s%s %s\n", indentStr(indentLevel), "emptylist", e.details())
 }
 
 type MinusExpression struct {
@@ -117,20 +271,20 @@ type MinusExpression struct {
 	Arg2 Expression
 }
 
-func (e *MinusExpression) Eval(env BindingList) (int, error) {
+func (e *MinusExpression) Eval(env BindingList, rt *Runtime) (ExpVal, error) {
 	e.SetEnv(&env)
-	arg1Val, err := e.Arg1.Eval(env)
+	arg1Val, err := e.Arg1.Eval(env, rt)
 	if err != nil {
-		return -1, err
+		return nil, err
 	}
-	arg2Val, err := e.Arg2.Eval(env)
+	arg2Val, err := e.Arg2.Eval(env, rt)
 	if err != nil {
-		return -1, err
+		return nil, err
 	}
-	return arg1Val - arg2Val, nil
+	return applyPrimitive(rt, "minus", []ExpVal{arg1Val, arg2Val})
 }
 func (e *MinusExpression) Print(indentLevel int) {
-	fmt.Printf("%s%s %s\n", indentStr(indentLevel), "minus", GetEnvStr(e.env))
+	fmt.Printf("%s%s %s\n", indentStr(indentLevel), "minus", e.details())
 	e.Arg1.Print(indentLevel + 1)
 	e.Arg2.Print(indentLevel + 1)
 }
@@ -140,19 +294,16 @@ type IsZeroExpression struct {
 	Arg1 Expression
 }
 
-func (e *IsZeroExpression) Eval(env BindingList) (int, error) {
+func (e *IsZeroExpression) Eval(env BindingList, rt *Runtime) (ExpVal, error) {
 	e.SetEnv(&env)
-	exprVal, err := e.Arg1.Eval(env)
+	exprVal, err := e.Arg1.Eval(env, rt)
 	if err != nil {
-		return -1, err
+		return nil, err
 	}
-	if exprVal == 0 {
-		return 1, nil
-	}
-	return 0, nil
+	return applyPrimitive(rt, "zero?", []ExpVal{exprVal})
 }
 func (e *IsZeroExpression) Print(indentLevel int) {
-	fmt.Printf("%s%s %s\n", indentStr(indentLevel), "iszero", GetEnvStr(e.env))
+	fmt.Printf("%s%s %s\n", indentStr(indentLevel), "iszero", e.details())
 	e.Arg1.Print(indentLevel + 1)
 }
 
@@ -163,20 +314,317 @@ type IfThenElseExpression struct {
 	FalseBranch Expression
 }
 
-func (e *IfThenElseExpression) Eval(env BindingList) (int, error) {
+func (e *IfThenElseExpression) Eval(env BindingList, rt *Runtime) (ExpVal, error) {
 	e.SetEnv(&env)
-	predicateVal, err := e.Value.Eval(env)
+	predicateVal, err := e.Value.Eval(env, rt)
+	if err != nil {
+		return nil, err
+	}
+	predicate, err := expvalToBool(predicateVal, "if")
 	if err != nil {
-		return -1, err
+		return nil, err
 	}
-	if predicateVal == 1 {
-		return e.TrueBranch.Eval(env)
+	if predicate {
+		return e.TrueBranch.Eval(env, rt)
 	}
-	return e.FalseBranch.Eval(env)
+	return e.FalseBranch.Eval(env, rt)
 }
 func (e *IfThenElseExpression) Print(indentLevel int) {
-	fmt.Printf("%s%s %s\n", indentStr(indentLevel), "if-then-else", GetEnvStr(e.env))
+	fmt.Printf("%s%s %s\n", indentStr(indentLevel), "if-then-else", e.details())
 	e.Value.Print(indentLevel + 1)
 	e.TrueBranch.Print(indentLevel + 1)
 	e.FalseBranch.Print(indentLevel + 1)
 }
+
+type ProcExpression struct {
+	BaseExpression
+	Params     []*Identifier
+	ParamTypes []Type //The annotation of each param, nil where there is none.
+	Body       Expression
+}
+
+func (e *ProcExpression) Eval(env BindingList, rt *Runtime) (ExpVal, error) {
+	e.SetEnv(&env)
+	params := make([]string, len(e.Params))
+	for i, param := range e.Params {
+		param.SetEnv(&env)
+		params[i] = param.Value
+	}
+	return &ProcVal{Params: params, Body: e.Body, Env: env}, nil
+}
+func (e *ProcExpression) Print(indentLevel int) {
+	fmt.Printf("%s%s %s\n", indentStr(indentLevel), "proc", e.details())
+	for _, param := range e.Params {
+		param.Print(indentLevel + 1)
+	}
+	e.Body.Print(indentLevel + 1)
+}
+
+type CallExpression struct {
+	BaseExpression
+	Operator Expression
+	Operands []Expression
+}
+
+func (e *CallExpression) Eval(env BindingList, rt *Runtime) (ExpVal, error) {
+	e.SetEnv(&env)
+	operatorVal, err := e.Operator.Eval(env, rt)
+	if err != nil {
+		return nil, err
+	}
+	proc, err := expvalToProc(operatorVal, "call")
+	if err != nil {
+		return nil, err
+	}
+	args := make([]ExpVal, len(e.Operands))
+	for i, operand := range e.Operands {
+		args[i], err = operand.Eval(env, rt)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return applyProcedure(rt, proc, args)
+}
+func (e *CallExpression) Print(indentLevel int) {
+	fmt.Printf("%s%s %s\n", indentStr(indentLevel), "call", e.details())
+	e.Operator.Print(indentLevel + 1)
+	for _, operand := range e.Operands {
+		operand.Print(indentLevel + 1)
+	}
+}
+
+type LetrecProc struct {
+	Name       *Identifier
+	Params     []*Identifier
+	ParamTypes []Type
+	ResultType Type
+	Body       Expression
+}
+
+func (p *LetrecProc) Print(indentLevel int) {
+	if p.Name.typ != nil {
+		fmt.Printf("%s%s : %s\n", indentStr(indentLevel), p.Name.Value, p.Name.typ)
+	} else {
+		fmt.Printf("%s%s\n", indentStr(indentLevel), p.Name.Value)
+	}
+	for _, param := range p.Params {
+		param.Print(indentLevel + 1)
+	}
+	p.Body.Print(indentLevel + 1)
+}
+
+type LetrecExpression struct {
+	BaseExpression
+	Procs []*LetrecProc
+	In    Expression
+}
+
+func (e *LetrecExpression) Eval(env BindingList, rt *Runtime) (ExpVal, error) {
+	e.SetEnv(&env)
+	for _, proc := range e.Procs {
+		proc.Name.SetEnv(&env)
+	}
+	return e.In.Eval(e.Extend(env), rt)
+}
+
+//Extend returns env with the letrec procs bound in front of it.
+func (e *LetrecExpression) Extend(env BindingList) BindingList {
+	newEnv := make(BindingList, 0, len(e.Procs)+len(env))
+	for i, proc := range e.Procs {
+		params := make([]string, len(proc.Params))
+		for j, param := range proc.Params {
+			params[j] = param.Value
+		}
+		newEnv = append(newEnv, Binding{
+			VarName: proc.Name.Value,
+			Rec:     &RecProc{Params: params, Body: proc.Body, Index: i},
+		})
+	}
+	return append(newEnv, env...)
+}
+func (e *LetrecExpression) Print(indentLevel int) {
+	fmt.Printf("%s%s %s\n", indentStr(indentLevel), "letrec", e.details())
+	for _, proc := range e.Procs {
+		proc.Print(indentLevel + 1)
+	}
+	e.In.Print(indentLevel + 1)
+}
+
+//PrimAppExpression applies a primitive from the primitive table, like plus(x, 1).
+type PrimAppExpression struct {
+	BaseExpression
+	Name string
+	Args []Expression
+}
+
+func (e *PrimAppExpression) Eval(env BindingList, rt *Runtime) (ExpVal, error) {
+	e.SetEnv(&env)
+	args := make([]ExpVal, len(e.Args))
+	for i, arg := range e.Args {
+		var err error
+		args[i], err = arg.Eval(env, rt)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return applyPrimitive(rt, e.Name, args)
+}
+func (e *PrimAppExpression) Print(indentLevel int) {
+	fmt.Printf("%s%s %s\n", indentStr(indentLevel), e.Name, e.details())
+	for _, arg := range e.Args {
+		arg.Print(indentLevel + 1)
+	}
+}
+
+//BeginExpression evaluates each expression in order and returns the value of the last one.
+type BeginExpression struct {
+	BaseExpression
+	Exprs []Expression
+}
+
+func (e *BeginExpression) Eval(env BindingList, rt *Runtime) (ExpVal, error) {
+	e.SetEnv(&env)
+	var result ExpVal
+	for _, expr := range e.Exprs {
+		var err error
+		result, err = expr.Eval(env, rt)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+func (e *BeginExpression) Print(indentLevel int) {
+	fmt.Printf("%s%s %s\n", indentStr(indentLevel), "begin", e.details())
+	for _, expr := range e.Exprs {
+		expr.Print(indentLevel + 1)
+	}
+}
+
+//SetExpression assigns to a variable, it is only allowed with implicit refs.
+type SetExpression struct {
+	BaseExpression
+	Name  *Identifier
+	Value Expression
+}
+
+func (e *SetExpression) Eval(env BindingList, rt *Runtime) (ExpVal, error) {
+	e.SetEnv(&env)
+	e.Name.SetEnv(&env)
+	if !rt.ImplicitRefs {
+		return nil, ErrSetWithoutImplicitRefs
+	}
+	value, err := e.Value.Eval(env, rt)
+	if err != nil {
+		return nil, err
+	}
+	return rt.SetVariable(e.Name.Value, value, env)
+}
+func (e *SetExpression) Print(indentLevel int) {
+	fmt.Printf("%s%s %s\n", indentStr(indentLevel), "set", e.details())
+	e.Name.Print(indentLevel + 1)
+	e.Value.Print(indentLevel + 1)
+}
+
+type RaiseExpression struct {
+	BaseExpression
+	Value Expression
+}
+
+func (e *RaiseExpression) Eval(env BindingList, rt *Runtime) (ExpVal, error) {
+	e.SetEnv(&env)
+	value, err := e.Value.Eval(env, rt)
+	if err != nil {
+		return nil, err
+	}
+	return nil, &RaisedException{Value: value}
+}
+func (e *RaiseExpression) Print(indentLevel int) {
+	fmt.Printf("%s%s %s\n", indentStr(indentLevel), "raise", e.details())
+	e.Value.Print(indentLevel + 1)
+}
+
+//TryExpression runs Handler with Var bound to the raised value when Body raises. Only values
+//raised by a raise expression are caught, interpreter errors still stop the program.
+type TryExpression struct {
+	BaseExpression
+	Body    Expression
+	Var     *Identifier
+	Handler Expression
+}
+
+func (e *TryExpression) Eval(env BindingList, rt *Runtime) (ExpVal, error) {
+	e.SetEnv(&env)
+	e.Var.SetEnv(&env)
+	result, err := e.Body.Eval(env, rt)
+	exception, ok := err.(*RaisedException)
+	if !ok {
+		return result, err
+	}
+	handlerEnv := append(BindingList{rt.NewBinding(e.Var.Value, exception.Value)}, env...)
+	return e.Handler.Eval(handlerEnv, rt)
+}
+func (e *TryExpression) Print(indentLevel int) {
+	fmt.Printf("%s%s %s\n", indentStr(indentLevel), "try-catch", e.details())
+	e.Body.Print(indentLevel + 1)
+	e.Var.Print(indentLevel + 1)
+	e.Handler.Print(indentLevel + 1)
+}
+
+//LetccExpression binds Name to the continuation of the letcc expression while Body runs. The
+//Eval methods only support escaping with it, throwing to it after Body has returned needs the
+//CPS evaluator.
+type LetccExpression struct {
+	BaseExpression
+	Name *Identifier
+	Body Expression
+}
+
+func (e *LetccExpression) Eval(env BindingList, rt *Runtime) (ExpVal, error) {
+	e.SetEnv(&env)
+	e.Name.SetEnv(&env)
+	k := &ContVal{Name: e.Name.Value, active: true}
+	result, err := e.Body.Eval(append(BindingList{rt.NewBinding(e.Name.Value, k)}, env...), rt)
+	k.active = false
+	if thrown, ok := err.(*thrownValue); ok && thrown.to == k {
+		return thrown.value, nil
+	}
+	return result, err
+}
+func (e *LetccExpression) Print(indentLevel int) {
+	fmt.Printf("%s%s %s\n", indentStr(indentLevel), "letcc", e.details())
+	e.Name.Print(indentLevel + 1)
+	e.Body.Print(indentLevel + 1)
+}
+
+type ThrowExpression struct {
+	BaseExpression
+	Value Expression
+	Cont  Expression
+}
+
+func (e *ThrowExpression) Eval(env BindingList, rt *Runtime) (ExpVal, error) {
+	e.SetEnv(&env)
+	value, err := e.Value.Eval(env, rt)
+	if err != nil {
+		return nil, err
+	}
+	contVal, err := e.Cont.Eval(env, rt)
+	if err != nil {
+		return nil, err
+	}
+	k, err := expvalToCont(contVal, "throw")
+	if err != nil {
+		return nil, err
+	}
+	if !k.active {
+		return nil, errors.New(fmt.Sprintf("Cannot throw to %s after its letcc has returned, "+
+			"the direct evaluator only supports escaping continuations, run with --backend cps", k))
+	}
+	return nil, &thrownValue{to: k, value: value}
+}
+func (e *ThrowExpression) Print(indentLevel int) {
+	fmt.Printf("%s%s %s\n", indentStr(indentLevel), "throw", e.details())
+	e.Value.Print(indentLevel + 1)
+	e.Cont.Print(indentLevel + 1)
+}
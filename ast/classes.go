@@ -0,0 +1,372 @@
+package ast
+
+import (
+	"let_lang_proj_michael_andrepont/token"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+//The CLASSES language is built on IMPLICIT-REFS, each field of an object is a location in the
+//Store, and a method body sees the fields of its class as variables it can set.
+
+//ClassDecl is class c extends s field ... method ..., declared before the program's expression.
+type ClassDecl struct {
+	Token   token.Token //CLASS Token
+	Name    *Identifier
+	Super   *Identifier
+	Fields  []*Identifier
+	Methods []*MethodDecl
+}
+
+func (c *ClassDecl) Print(indentLevel int) {
+	fmt.Printf("%s%s %s extends %s\n", indentStr(indentLevel), "class", c.Name.Value, c.Super.Value)
+	for _, field := range c.Fields {
+		fmt.Printf("%s%s %s\n", indentStr(indentLevel+1), "field", field.Value)
+	}
+	for _, method := range c.Methods {
+		method.Print(indentLevel + 1)
+	}
+}
+
+type MethodDecl struct {
+	Token      token.Token //METHOD Token
+	Name       *Identifier
+	Params     []*Identifier
+	ParamTypes []Type //The annotation of each param, nil where there is none.
+	Body       Expression
+}
+
+func (m *MethodDecl) Print(indentLevel int) {
+	params := make([]string, len(m.Params))
+	for i, param := range m.Params {
+		params[i] = param.Value
+	}
+	fmt.Printf("%s%s %s (%s)\n", indentStr(indentLevel), "method", m.Name.Value, strings.Join(params, ", "))
+	m.Body.Print(indentLevel + 1)
+}
+
+//Class is a declared class. Fields holds the inherited fields first, so the fields of a class
+//are a prefix of the fields of each of its subclasses.
+type Class struct {
+	Name    string
+	Super   *Class
+	Fields  []string
+	Methods map[string]*MethodDecl
+}
+
+//A Class is only an ExpVal so a method's env can hold its host's superclass, it is never the
+//value of a Let expression.
+func (c *Class) String() string   { return fmt.Sprintf("<class %s>", c.Name) }
+func (c *Class) TypeName() string { return "class" }
+
+//findMethod looks for name in c and then its superclasses, returning the class declaring it.
+func (c *Class) findMethod(name string) (*MethodDecl, *Class) {
+	for class := c; class != nil; class = class.Super {
+		if method, ok := class.Methods[name]; ok {
+			return method, class
+		}
+	}
+	return nil, nil
+}
+
+//ObjectVal is an instance of Class, Fields are the locations of its fields in the Store.
+type ObjectVal struct {
+	Class  *Class
+	Fields []RefVal
+}
+
+func (v *ObjectVal) String() string   { return fmt.Sprintf("<object %s>", v.Class.Name) }
+func (v *ObjectVal) TypeName() string { return "object" }
+
+//The names a method's env binds self and its host's superclass to, they can't be written in
+//a program since % is not part of an identifier.
+const selfVar = "%self"
+const superVar = "%super"
+
+var objectClass = &Class{Name: "object"}
+
+//DefineClass adds a class declaration, its superclass must already be defined.
+func (rt *Runtime) DefineClass(decl *ClassDecl) error {
+	if !rt.ImplicitRefs {
+		return ErrClassesWithoutImplicitRefs
+	}
+	if _, ok := rt.lookupClass(decl.Name.Value); ok {
+		return errors.New(fmt.Sprintf("Class %s is already defined", decl.Name.Value))
+	}
+	super, ok := rt.lookupClass(decl.Super.Value)
+	if !ok {
+		return errors.New(fmt.Sprintf("Class %s extends unknown class %s", decl.Name.Value, decl.Super.Value))
+	}
+	class := &Class{Name: decl.Name.Value, Super: super, Methods: map[string]*MethodDecl{}}
+	class.Fields = append(class.Fields, super.Fields...)
+	for _, field := range decl.Fields {
+		class.Fields = append(class.Fields, field.Value)
+	}
+	for _, method := range decl.Methods {
+		class.Methods[method.Name.Value] = method
+	}
+	if rt.classes == nil {
+		rt.classes = map[string]*Class{}
+	}
+	rt.classes[class.Name] = class
+	return nil
+}
+
+func (rt *Runtime) lookupClass(name string) (*Class, bool) {
+	if name == objectClass.Name {
+		return objectClass, true
+	}
+	class, ok := rt.classes[name]
+	return class, ok
+}
+
+//NewObject allocates an object of the class, its fields start as 0 until initialize sets them.
+func (rt *Runtime) NewObject(className string) (*ObjectVal, error) {
+	class, ok := rt.lookupClass(className)
+	if !ok {
+		return nil, errors.New(fmt.Sprintf("Unknown class: %s", className))
+	}
+	obj := &ObjectVal{Class: class, Fields: make([]RefVal, len(class.Fields))}
+	for i := range obj.Fields {
+		obj.Fields[i] = rt.Store.NewRef(NumVal{Value: 0})
+	}
+	return obj, nil
+}
+
+//Initializer returns the initialize method of obj, or nil if its class has none and new was
+//given no arguments.
+func (rt *Runtime) Initializer(obj *ObjectVal, argCount int) (*ProcVal, error) {
+	method, host := obj.Class.findMethod("initialize")
+	if method == nil {
+		if argCount > 0 {
+			return nil, errors.New(fmt.Sprintf("Class %s has no initialize method, but new was given %d argument(s)",
+				obj.Class.Name, argCount))
+		}
+		return nil, nil
+	}
+	return methodProc(obj, method, host), nil
+}
+
+//MethodProc finds the method name for send, starting at the class of obj.
+func (rt *Runtime) MethodProc(obj ExpVal, name string) (*ProcVal, error) {
+	object, ok := obj.(*ObjectVal)
+	if !ok {
+		return nil, &TypeError{Operation: "send", Expected: "an object", Got: obj}
+	}
+	return findMethodProc(object, object.Class, name)
+}
+
+//SuperMethodProc finds the method name for super, starting at the superclass of the class
+//that declares the method env belongs to.
+func (rt *Runtime) SuperMethodProc(env BindingList, name string) (*ProcVal, error) {
+	self, err := rt.Self(env)
+	if err != nil {
+		return nil, err
+	}
+	super, err := findIdentifierInEnv(superVar, env, rt)
+	if err != nil {
+		return nil, err
+	}
+	superClass, ok := super.(*Class)
+	if !ok {
+		return nil, &TypeError{Operation: "super", Expected: "a class", Got: super}
+	}
+	return findMethodProc(self, superClass, name)
+}
+
+//Self returns the object the method env belongs to was sent to.
+func (rt *Runtime) Self(env BindingList) (*ObjectVal, error) {
+	for _, b := range env {
+		if b.VarName != selfVar {
+			continue
+		}
+		val := b.Value
+		if ref, ok := val.(RefVal); ok && rt.ImplicitRefs {
+			var err error
+			if val, err = rt.Store.Deref(ref); err != nil {
+				return nil, err
+			}
+		}
+		self, ok := val.(*ObjectVal)
+		if !ok {
+			return nil, &TypeError{Operation: "self", Expected: "an object", Got: val}
+		}
+		return self, nil
+	}
+	return nil, errors.New("self and super can only be used inside a method")
+}
+
+func findMethodProc(obj *ObjectVal, class *Class, name string) (*ProcVal, error) {
+	method, host := class.findMethod(name)
+	if method == nil {
+		return nil, errors.New(fmt.Sprintf("Method %s not found in class %s", name, class.Name))
+	}
+	return methodProc(obj, method, host), nil
+}
+
+//methodProc makes a procedure of method whose env binds self, the host's superclass and the
+//host's fields. Later fields come first so a subclass's field shadows one of the same name.
+func methodProc(obj *ObjectVal, method *MethodDecl, host *Class) *ProcVal {
+	env := BindingList{{VarName: selfVar, Value: obj}, {VarName: superVar, Value: host.Super}}
+	for i := len(host.Fields) - 1; i >= 0; i-- {
+		env = append(env, Binding{VarName: host.Fields[i], Value: obj.Fields[i]})
+	}
+	params := make([]string, len(method.Params))
+	for i, param := range method.Params {
+		params[i] = param.Value
+	}
+	return &ProcVal{Params: params, Body: method.Body, Env: env}
+}
+
+//Show is val's String, except that objects also show the values of their fields.
+func (rt *Runtime) Show(val ExpVal) string {
+	switch v := val.(type) {
+	case *ObjectVal:
+		var str strings.Builder
+		str.WriteString("<object " + v.Class.Name)
+		for i, ref := range v.Fields {
+			fieldVal, err := rt.Store.Deref(ref)
+			if err != nil {
+				return v.String()
+			}
+			//Fields are shown with String, an object can hold itself.
+			fmt.Fprintf(&str, " (%s %s)", v.Class.Fields[i], fieldVal)
+		}
+		return str.String() + ">"
+	case *PairVal:
+		str := "(" + rt.Show(v.Car)
+		rest := v.Cdr
+		for {
+			switch r := rest.(type) {
+			case *PairVal:
+				str += " " + rt.Show(r.Car)
+				rest = r.Cdr
+				continue
+			case EmptyListVal:
+				return str + ")"
+			default:
+				return str + " . " + rt.Show(r) + ")"
+			}
+		}
+	}
+	return val.String()
+}
+
+//NewObjectExpression is new c(args), it makes an object and calls its initialize method.
+type NewObjectExpression struct {
+	BaseExpression
+	Class *Identifier
+	Args  []Expression
+}
+
+func (e *NewObjectExpression) Eval(env BindingList, rt *Runtime) (ExpVal, error) {
+	e.SetEnv(&env)
+	args, err := evalArgs(e.Args, env, rt)
+	if err != nil {
+		return nil, err
+	}
+	obj, err := rt.NewObject(e.Class.Value)
+	if err != nil {
+		return nil, err
+	}
+	initialize, err := rt.Initializer(obj, len(args))
+	if err != nil || initialize == nil {
+		return obj, err
+	}
+	if _, err := applyProcedure(rt, initialize, args); err != nil {
+		return nil, err
+	}
+	return obj, nil
+}
+func (e *NewObjectExpression) Print(indentLevel int) {
+	fmt.Printf("%s%s %s %s\n", indentStr(indentLevel), "new", e.Class.Value, e.details())
+	for _, arg := range e.Args {
+		arg.Print(indentLevel + 1)
+	}
+}
+
+//MethodCallExpression is send obj m(args).
+type MethodCallExpression struct {
+	BaseExpression
+	Object Expression
+	Method *Identifier
+	Args   []Expression
+}
+
+func (e *MethodCallExpression) Eval(env BindingList, rt *Runtime) (ExpVal, error) {
+	e.SetEnv(&env)
+	obj, err := e.Object.Eval(env, rt)
+	if err != nil {
+		return nil, err
+	}
+	args, err := evalArgs(e.Args, env, rt)
+	if err != nil {
+		return nil, err
+	}
+	proc, err := rt.MethodProc(obj, e.Method.Value)
+	if err != nil {
+		return nil, err
+	}
+	return applyProcedure(rt, proc, args)
+}
+func (e *MethodCallExpression) Print(indentLevel int) {
+	fmt.Printf("%s%s %s %s\n", indentStr(indentLevel), "send", e.Method.Value, e.details())
+	e.Object.Print(indentLevel + 1)
+	for _, arg := range e.Args {
+		arg.Print(indentLevel + 1)
+	}
+}
+
+//SuperCallExpression is super m(args), it sends m to self starting at the host's superclass.
+type SuperCallExpression struct {
+	BaseExpression
+	Method *Identifier
+	Args   []Expression
+}
+
+func (e *SuperCallExpression) Eval(env BindingList, rt *Runtime) (ExpVal, error) {
+	e.SetEnv(&env)
+	args, err := evalArgs(e.Args, env, rt)
+	if err != nil {
+		return nil, err
+	}
+	proc, err := rt.SuperMethodProc(env, e.Method.Value)
+	if err != nil {
+		return nil, err
+	}
+	return applyProcedure(rt, proc, args)
+}
+func (e *SuperCallExpression) Print(indentLevel int) {
+	fmt.Printf("%s%s %s %s\n", indentStr(indentLevel), "super", e.Method.Value, e.details())
+	for _, arg := range e.Args {
+		arg.Print(indentLevel + 1)
+	}
+}
+
+type SelfExpression struct {
+	BaseExpression
+}
+
+func (e *SelfExpression) Eval(env BindingList, rt *Runtime) (ExpVal, error) {
+	e.SetEnv(&env)
+	self, err := rt.Self(env)
+	if err != nil {
+		return nil, err
+	}
+	return self, nil
+}
+func (e *SelfExpression) Print(indentLevel int) {
+	fmt.Printf("%s%s %s\n", indentStr(indentLevel), "self", e.details())
+}
+
+func evalArgs(exprs []Expression, env BindingList, rt *Runtime) ([]ExpVal, error) {
+	args := make([]ExpVal, len(exprs))
+	for i, expr := range exprs {
+		var err error
+		if args[i], err = expr.Eval(env, rt); err != nil {
+			return nil, err
+		}
+	}
+	return args, nil
+}
@@ -0,0 +1,112 @@
+package ast
+
+import (
+	"let_lang_proj_michael_andrepont/token"
+	"errors"
+	"fmt"
+)
+
+//Program is a whole Let program, the module and class declarations followed by the expression
+//to run.
+type Program struct {
+	Modules []*ModuleDefinition
+	Classes []*ClassDecl
+	Body    Expression
+}
+
+func (p *Program) Print(indentLevel int) {
+	for _, class := range p.Classes {
+		class.Print(indentLevel)
+	}
+	for _, module := range p.Modules {
+		module.Print(indentLevel)
+	}
+	p.Body.Print(indentLevel)
+}
+
+//InterfaceDecl declares a name a module exports and its type, like a : int.
+type InterfaceDecl struct {
+	Name *Identifier
+	Type Type
+}
+
+//ModuleDefinition is module m interface [...] body [...]. The body definitions are evaluated
+//in order like let*, only the names declared in the interface can be taken from the module.
+type ModuleDefinition struct {
+	Token     token.Token //MODULE Token
+	Name      *Identifier
+	Interface []*InterfaceDecl
+	Body      []*LetBinding
+}
+
+func (m *ModuleDefinition) Print(indentLevel int) {
+	fmt.Printf("%s%s %s\n", indentStr(indentLevel), "module", m.Name.Value)
+	fmt.Printf("%s%s\n", indentStr(indentLevel+1), "interface")
+	for _, decl := range m.Interface {
+		fmt.Printf("%s%s : %s\n", indentStr(indentLevel+2), decl.Name.Value, decl.Type)
+	}
+	fmt.Printf("%s%s\n", indentStr(indentLevel+1), "body")
+	for _, binding := range m.Body {
+		binding.Name.Print(indentLevel + 2)
+		binding.Value.Print(indentLevel + 2)
+	}
+}
+
+//Exports picks the bindings named in the interface out of env, the env the body was evaluated in.
+func (m *ModuleDefinition) Exports(env BindingList) (BindingList, error) {
+	exports := BindingList{}
+	for _, decl := range m.Interface {
+		found := false
+		for _, b := range env {
+			if b.VarName == decl.Name.Value {
+				exports = append(exports, b)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("Module %s does not define %s declared in its interface", m.Name.Value, decl.Name.Value)
+		}
+	}
+	return exports, nil
+}
+
+//QualifiedVarExpression is from m take x.
+type QualifiedVarExpression struct {
+	BaseExpression
+	Module *Identifier
+	Var    *Identifier
+}
+
+func (e *QualifiedVarExpression) Eval(env BindingList, rt *Runtime) (ExpVal, error) {
+	e.SetEnv(&env)
+	return rt.LookupQualified(e.Module.Value, e.Var.Value)
+}
+func (e *QualifiedVarExpression) Print(indentLevel int) {
+	fmt.Printf("%s%s %s.%s %s\n", indentStr(indentLevel), "from-take", e.Module.Value, e.Var.Value, e.details())
+}
+
+//DefineModule makes the bindings a module exports available to from m take x.
+func (rt *Runtime) DefineModule(name string, exports BindingList) error {
+	if _, ok := rt.modules[name]; ok {
+		return errors.New(fmt.Sprintf("Module %s is already defined", name))
+	}
+	if rt.modules == nil {
+		rt.modules = map[string]BindingList{}
+	}
+	rt.modules[name] = exports
+	return nil
+}
+
+func (rt *Runtime) LookupQualified(moduleName string, varName string) (ExpVal, error) {
+	exports, ok := rt.modules[moduleName]
+	if !ok {
+		return nil, errors.New(fmt.Sprintf("Unknown module: %s", moduleName))
+	}
+	for _, b := range exports {
+		if b.VarName == varName {
+			return findIdentifierInEnv(varName, exports, rt)
+		}
+	}
+	return nil, errors.New(fmt.Sprintf("Module %s does not export %s", moduleName, varName))
+}
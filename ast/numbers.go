@@ -0,0 +1,178 @@
+package ast
+
+import (
+	"math"
+	"math/big"
+)
+
+//Numbers form a tower, an int can be promoted to a rational and a rational to a float. A
+//numeric primitive promotes its arguments to the kind of the most general one.
+type numberKind int
+
+const (
+	intKind numberKind = iota
+	ratKind
+	floatKind
+)
+
+func numberKindOf(val ExpVal) (numberKind, bool) {
+	switch val.(type) {
+	case NumVal, BigNumVal:
+		return intKind, true
+	case RatVal:
+		return ratKind, true
+	case FloatVal:
+		return floatKind, true
+	}
+	return 0, false
+}
+
+//numericOp is a primitive of two numbers for each kind of number, Big is used for ints in
+//bignum mode.
+type numericOp struct {
+	Int   func(a, b int) ExpVal
+	Big   func(a, b *big.Int) ExpVal
+	Rat   func(a, b *big.Rat) ExpVal
+	Float func(a, b float64) ExpVal
+	//Divides makes a zero second argument a DivisionByZeroError.
+	Divides bool
+	//Exact promotes ints to rationals in rationals mode, so the result is not truncated.
+	Exact bool
+}
+
+//overflows reports if the numeric primitive of the same name overflows for a and b.
+var overflows = map[string]func(a, b int) bool{
+	"minus": func(a, b int) bool { return (b < 0 && a > math.MaxInt+b) || (b > 0 && a < math.MinInt+b) },
+	"plus":  func(a, b int) bool { return (b > 0 && a > math.MaxInt-b) || (b < 0 && a < math.MinInt-b) },
+	"times": func(a, b int) bool {
+		if a == 0 || b == 0 {
+			return false
+		}
+		return (a == -1 && b == math.MinInt) || (b == -1 && a == math.MinInt) || (a*b)/b != a
+	},
+	"quotient": func(a, b int) bool { return a == math.MinInt && b == -1 },
+}
+
+func addNumericPrimitive(name string, op numericOp) {
+	addPrimitive(name, 2, func(rt *Runtime, args []ExpVal) (ExpVal, error) {
+		kind := intKind
+		for _, arg := range args {
+			argKind, ok := numberKindOf(arg)
+			if !ok {
+				return nil, &TypeError{Operation: name, Expected: "numbers", Got: arg}
+			}
+			if argKind > kind {
+				kind = argKind
+			}
+		}
+		if op.Divides && isZero(args[1]) {
+			return nil, &DivisionByZeroError{Operation: name, Dividend: args[0]}
+		}
+		if kind == intKind && op.Exact && rt.Rationals {
+			kind = ratKind
+		}
+		switch {
+		case kind == floatKind:
+			return op.Float(toFloat(args[0]), toFloat(args[1])), nil
+		case kind == ratKind:
+			return rt.canonical(name, args, op.Rat(toRat(args[0]), toRat(args[1])))
+		case rt.Bignums:
+			return op.Big(toBig(args[0]), toBig(args[1])), nil
+		}
+		num1, num2 := args[0].(NumVal).Value, args[1].(NumVal).Value
+		if check, ok := overflows[name]; ok && rt.CheckOverflow && check(num1, num2) {
+			return nil, &OverflowError{Operation: name, Operands: []ExpVal{args[0], args[1]}}
+		}
+		return op.Int(num1, num2), nil
+	})
+}
+
+//addComparisonPrimitive adds a primitive that compares two numbers, test is given the sign of
+//the first minus the second.
+func addComparisonPrimitive(name string, test func(cmp int) bool) {
+	addNumericPrimitive(name, numericOp{
+		Int: func(a, b int) ExpVal {
+			cmp := 0
+			if a < b {
+				cmp = -1
+			} else if a > b {
+				cmp = 1
+			}
+			return BoolVal{Value: test(cmp)}
+		},
+		Big: func(a, b *big.Int) ExpVal { return BoolVal{Value: test(a.Cmp(b))} },
+		Rat: func(a, b *big.Rat) ExpVal { return BoolVal{Value: test(a.Cmp(b))} },
+		Float: func(a, b float64) ExpVal {
+			//NaN is not equal to, greater or less than anything.
+			if math.IsNaN(a) || math.IsNaN(b) {
+				return BoolVal{Value: false}
+			}
+			return BoolVal{Value: test(big.NewFloat(a).Cmp(big.NewFloat(b)))}
+		},
+	})
+}
+
+//canonical turns a rational that is a whole number into an int. Without bignum mode a number
+//too big for an int overflows like the primitive name would for machine ints.
+func (rt *Runtime) canonical(name string, args []ExpVal, val ExpVal) (ExpVal, error) {
+	rat, ok := val.(RatVal)
+	if !ok || !rat.Value.IsInt() {
+		return val, nil
+	}
+	num := rat.Value.Num()
+	switch {
+	case rt.Bignums:
+		return BigNumVal{Value: num}, nil
+	case num.IsInt64():
+		return NumVal{Value: int(num.Int64())}, nil
+	case rt.CheckOverflow:
+		return nil, &OverflowError{Operation: name, Operands: []ExpVal{args[0], args[1]}}
+	}
+	//And keeps the low 64 bits of the two's complement, which is how an int wraps around.
+	low := new(big.Int).And(num, new(big.Int).SetUint64(math.MaxUint64))
+	return NumVal{Value: int(int64(low.Uint64()))}, nil
+}
+
+func isZero(val ExpVal) bool {
+	switch num := val.(type) {
+	case NumVal:
+		return num.Value == 0
+	case BigNumVal:
+		return num.Value.Sign() == 0
+	case RatVal:
+		return num.Value.Sign() == 0
+	case FloatVal:
+		return num.Value == 0
+	}
+	return false
+}
+
+//toBig, toRat and toFloat convert a number to a kind at least as general as its own.
+
+func toBig(val ExpVal) *big.Int {
+	if num, ok := val.(BigNumVal); ok {
+		return num.Value
+	}
+	return big.NewInt(int64(val.(NumVal).Value))
+}
+
+func toRat(val ExpVal) *big.Rat {
+	if num, ok := val.(RatVal); ok {
+		return num.Value
+	}
+	return new(big.Rat).SetInt(toBig(val))
+}
+
+func toFloat(val ExpVal) float64 {
+	switch num := val.(type) {
+	case FloatVal:
+		return num.Value
+	case RatVal:
+		f, _ := num.Value.Float64()
+		return f
+	case BigNumVal:
+		f, _ := new(big.Float).SetInt(num.Value).Float64()
+		return f
+	}
+	return float64(val.(NumVal).Value)
+}
@@ -0,0 +1,272 @@
+package ast
+
+import (
+	"let_lang_proj_michael_andrepont/token"
+	"fmt"
+	"math"
+	"math/big"
+	"strings"
+	"unicode/utf8"
+)
+
+//VariadicArity marks a primitive that takes any number of arguments.
+const VariadicArity = -1
+
+//Primitive is a built in operation, Arity is the exact number of arguments it takes.
+type Primitive struct {
+	Name  string
+	Arity int
+	Apply func(rt *Runtime, args []ExpVal) (ExpVal, error)
+}
+
+//DivisionByZeroError is returned by quotient and remainder when the divisor is zero.
+type DivisionByZeroError struct {
+	Operation string
+	Dividend  ExpVal
+}
+
+func (e *DivisionByZeroError) Error() string {
+	return fmt.Sprintf("%s: division by zero (dividend was %s)", e.Operation, e.Dividend)
+}
+
+//OverflowError is returned in checked mode when the result of an operation does not fit in an int.
+type OverflowError struct {
+	Operation string
+	Operands  []ExpVal
+}
+
+func (e *OverflowError) Error() string {
+	operands := make([]string, len(e.Operands))
+	for i, operand := range e.Operands {
+		operands[i] = operand.String()
+	}
+	return fmt.Sprintf("%s: integer overflow (operands were %s)", e.Operation, strings.Join(operands, " and "))
+}
+
+var primitives = map[string]*Primitive{}
+
+func init() {
+	addNumericPrimitive("minus", numericOp{
+		Int:   func(a, b int) ExpVal { return NumVal{Value: a - b} },
+		Big:   func(a, b *big.Int) ExpVal { return BigNumVal{Value: new(big.Int).Sub(a, b)} },
+		Rat:   func(a, b *big.Rat) ExpVal { return RatVal{Value: new(big.Rat).Sub(a, b)} },
+		Float: func(a, b float64) ExpVal { return FloatVal{Value: a - b} },
+	})
+	addNumericPrimitive("plus", numericOp{
+		Int:   func(a, b int) ExpVal { return NumVal{Value: a + b} },
+		Big:   func(a, b *big.Int) ExpVal { return BigNumVal{Value: new(big.Int).Add(a, b)} },
+		Rat:   func(a, b *big.Rat) ExpVal { return RatVal{Value: new(big.Rat).Add(a, b)} },
+		Float: func(a, b float64) ExpVal { return FloatVal{Value: a + b} },
+	})
+	addNumericPrimitive("times", numericOp{
+		Int:   func(a, b int) ExpVal { return NumVal{Value: a * b} },
+		Big:   func(a, b *big.Int) ExpVal { return BigNumVal{Value: new(big.Int).Mul(a, b)} },
+		Rat:   func(a, b *big.Rat) ExpVal { return RatVal{Value: new(big.Rat).Mul(a, b)} },
+		Float: func(a, b float64) ExpVal { return FloatVal{Value: a * b} },
+	})
+	addNumericPrimitive("quotient", numericOp{
+		Int:     func(a, b int) ExpVal { return NumVal{Value: a / b} },
+		Big:     func(a, b *big.Int) ExpVal { return BigNumVal{Value: new(big.Int).Quo(a, b)} },
+		Rat:     func(a, b *big.Rat) ExpVal { return RatVal{Value: new(big.Rat).Quo(a, b)} },
+		Float:   func(a, b float64) ExpVal { return FloatVal{Value: a / b} },
+		Divides: true,
+		Exact:   true,
+	})
+	addNumericPrimitive("remainder", numericOp{
+		Int: func(a, b int) ExpVal { return NumVal{Value: a % b} },
+		Big: func(a, b *big.Int) ExpVal { return BigNumVal{Value: new(big.Int).Rem(a, b)} },
+		Rat: func(a, b *big.Rat) ExpVal {
+			//a minus b times the quotient truncated toward zero, like the remainder of ints.
+			q := new(big.Rat).Quo(a, b)
+			truncated := new(big.Rat).SetInt(new(big.Int).Quo(q.Num(), q.Denom()))
+			return RatVal{Value: new(big.Rat).Sub(a, truncated.Mul(truncated, b))}
+		},
+		Float:   func(a, b float64) ExpVal { return FloatVal{Value: math.Mod(a, b)} },
+		Divides: true,
+	})
+	addComparisonPrimitive("equal?", func(cmp int) bool { return cmp == 0 })
+	addComparisonPrimitive("greater?", func(cmp int) bool { return cmp > 0 })
+	addComparisonPrimitive("less?", func(cmp int) bool { return cmp < 0 })
+	addPrimitive("zero?", 1, func(rt *Runtime, args []ExpVal) (ExpVal, error) {
+		if _, ok := numberKindOf(args[0]); !ok {
+			return nil, &TypeError{Operation: "zero?", Expected: "a number", Got: args[0]}
+		}
+		return BoolVal{Value: isZero(args[0])}, nil
+	})
+	addPrimitive("cons", 2, func(rt *Runtime, args []ExpVal) (ExpVal, error) {
+		return &PairVal{Car: args[0], Cdr: args[1]}, nil
+	})
+	addPrimitive("car", 1, func(rt *Runtime, args []ExpVal) (ExpVal, error) {
+		pair, err := expvalToPair(args[0], "car")
+		if err != nil {
+			return nil, err
+		}
+		return pair.Car, nil
+	})
+	addPrimitive("cdr", 1, func(rt *Runtime, args []ExpVal) (ExpVal, error) {
+		pair, err := expvalToPair(args[0], "cdr")
+		if err != nil {
+			return nil, err
+		}
+		return pair.Cdr, nil
+	})
+	addPrimitive("null?", 1, func(rt *Runtime, args []ExpVal) (ExpVal, error) {
+		_, isEmpty := args[0].(EmptyListVal)
+		return BoolVal{Value: isEmpty}, nil
+	})
+	addPrimitive("newref", 1, func(rt *Runtime, args []ExpVal) (ExpVal, error) {
+		return rt.Store.NewRef(args[0]), nil
+	})
+	addPrimitive("deref", 1, func(rt *Runtime, args []ExpVal) (ExpVal, error) {
+		ref, err := expvalToRef(args[0], "deref")
+		if err != nil {
+			return nil, err
+		}
+		return rt.Store.Deref(ref)
+	})
+	addPrimitive("setref", 2, func(rt *Runtime, args []ExpVal) (ExpVal, error) {
+		ref, err := expvalToRef(args[0], "setref")
+		if err != nil {
+			return nil, err
+		}
+		if err := rt.Store.SetRef(ref, args[1]); err != nil {
+			return nil, err
+		}
+		//Like EOPL, setref returns an arbitrary value since it is only run for its effect.
+		return NumVal{Value: 23}, nil
+	})
+	//The thread primitives return the same arbitrary values EOPL does.
+	addPrimitive("spawn", 1, func(rt *Runtime, args []ExpVal) (ExpVal, error) {
+		proc, err := expvalToProc(args[0], "spawn")
+		if err != nil {
+			return nil, err
+		}
+		//EOPL threads are procedures of one ignored argument, zero argument procs work too.
+		var procArgs []ExpVal
+		if len(proc.Params) == 1 {
+			procArgs = []ExpVal{NumVal{Value: 28}}
+		}
+		rt.Scheduler.spawn(func() error {
+			_, err := rt.apply(proc, procArgs)
+			return err
+		})
+		return NumVal{Value: 73}, nil
+	})
+	addPrimitive("yield", 0, func(rt *Runtime, args []ExpVal) (ExpVal, error) {
+		if err := rt.Scheduler.yield(); err != nil {
+			return nil, err
+		}
+		return NumVal{Value: 99}, nil
+	})
+	addPrimitive("mutex", 0, func(rt *Runtime, args []ExpVal) (ExpVal, error) {
+		return &MutexVal{}, nil
+	})
+	addPrimitive("wait", 1, func(rt *Runtime, args []ExpVal) (ExpVal, error) {
+		mutex, err := expvalToMutex(args[0], "wait")
+		if err != nil {
+			return nil, err
+		}
+		if err := rt.Scheduler.wait(mutex); err != nil {
+			return nil, err
+		}
+		return NumVal{Value: 52}, nil
+	})
+	addPrimitive("signal", 1, func(rt *Runtime, args []ExpVal) (ExpVal, error) {
+		mutex, err := expvalToMutex(args[0], "signal")
+		if err != nil {
+			return nil, err
+		}
+		rt.Scheduler.signal(mutex)
+		return NumVal{Value: 53}, nil
+	})
+	addPrimitive("list", VariadicArity, func(rt *Runtime, args []ExpVal) (ExpVal, error) {
+		var list ExpVal = EmptyListVal{}
+		for i := len(args) - 1; i >= 0; i-- {
+			list = &PairVal{Car: args[i], Cdr: list}
+		}
+		return list, nil
+	})
+	//Strings are indexed by character, not byte.
+	addPrimitive("string-length", 1, func(rt *Runtime, args []ExpVal) (ExpVal, error) {
+		str, err := expvalToString(args[0], "string-length")
+		if err != nil {
+			return nil, err
+		}
+		return rt.Number(NumVal{Value: utf8.RuneCountInString(str)})
+	})
+	addPrimitive("string-append", VariadicArity, func(rt *Runtime, args []ExpVal) (ExpVal, error) {
+		var appended strings.Builder
+		for _, arg := range args {
+			str, err := expvalToString(arg, "string-append")
+			if err != nil {
+				return nil, err
+			}
+			appended.WriteString(str)
+		}
+		return StrVal{Value: appended.String()}, nil
+	})
+	addPrimitive("substring", 3, func(rt *Runtime, args []ExpVal) (ExpVal, error) {
+		str, err := expvalToString(args[0], "substring")
+		if err != nil {
+			return nil, err
+		}
+		start, err := expvalToIndex(args[1], "substring")
+		if err != nil {
+			return nil, err
+		}
+		end, err := expvalToIndex(args[2], "substring")
+		if err != nil {
+			return nil, err
+		}
+		chars := []rune(str)
+		if start < 0 || end < start || end > len(chars) {
+			return nil, fmt.Errorf("substring: indices %d and %d are out of range for a string of length %d",
+				start, end, len(chars))
+		}
+		return StrVal{Value: string(chars[start:end])}, nil
+	})
+	addPrimitive("string=?", 2, func(rt *Runtime, args []ExpVal) (ExpVal, error) {
+		str1, err := expvalToString(args[0], "string=?")
+		if err != nil {
+			return nil, err
+		}
+		str2, err := expvalToString(args[1], "string=?")
+		if err != nil {
+			return nil, err
+		}
+		return BoolVal{Value: str1 == str2}, nil
+	})
+	addPrimitive("number->string", 1, func(rt *Runtime, args []ExpVal) (ExpVal, error) {
+		if _, ok := numberKindOf(args[0]); !ok {
+			return nil, &TypeError{Operation: "number->string", Expected: "a number", Got: args[0]}
+		}
+		return StrVal{Value: args[0].String()}, nil
+	})
+}
+
+func addPrimitive(name string, arity int, apply func(rt *Runtime, args []ExpVal) (ExpVal, error)) {
+	//The lexer only reads a name with symbols as one identifier if the token package lists it.
+	if strings.ContainsAny(name, "->=") && !token.IsSymbolicName(name) {
+		panic(fmt.Sprintf("primitive %s has symbols in its name but is not a token.IsSymbolicName", name))
+	}
+	primitives[name] = &Primitive{Name: name, Arity: arity, Apply: apply}
+}
+
+func LookupPrimitive(name string) (*Primitive, bool) {
+	prim, ok := primitives[name]
+	return prim, ok
+}
+
+func applyPrimitive(rt *Runtime, name string, args []ExpVal) (ExpVal, error) {
+	prim, ok := primitives[name]
+	if !ok {
+		return nil, fmt.Errorf("Unknown primitive: %s", name)
+	}
+	if prim.Arity != VariadicArity && len(args) != prim.Arity {
+		return nil, fmt.Errorf("%s expects %d argument(s), got %d", name, prim.Arity, len(args))
+	}
+	if err := rt.Scheduler.step(); err != nil {
+		return nil, err
+	}
+	return prim.Apply(rt, args)
+}
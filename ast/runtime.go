@@ -0,0 +1,193 @@
+package ast
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+//Runtime holds the state of a single program run that is shared by every Eval call.
+type Runtime struct {
+	Store *Store
+	//ImplicitRefs selects the IMPLICIT-REFS semantics, every variable is bound to a
+	//location in the Store and can be assigned with set.
+	ImplicitRefs bool
+	//Bignums makes every number a BigNumVal, so literals of any length can be used and
+	//arithmetic never overflows.
+	Bignums bool
+	//CheckOverflow makes arithmetic that does not fit in an int fail with an OverflowError
+	//instead of wrapping around.
+	CheckOverflow bool
+	//Rationals makes quotient of ints an exact rational instead of truncating it.
+	Rationals bool
+	Scheduler *Scheduler
+	//Apply is used to run spawned threads when set, so an evaluator other than the Eval
+	//methods can run them too.
+	Apply func(proc *ProcVal, args []ExpVal) (ExpVal, error)
+	//modules holds the exported bindings of each module by name.
+	modules map[string]BindingList
+	//classes holds the declared classes by name.
+	classes map[string]*Class
+}
+
+var ErrSetWithoutImplicitRefs = errors.New("set requires implicit references, run with --implicit-refs")
+
+//ErrClassesWithoutImplicitRefs is returned for a class declaration without implicit refs, the
+//CLASSES language is built on IMPLICIT-REFS and fields are assigned with set.
+var ErrClassesWithoutImplicitRefs = errors.New("classes require implicit references, run with --implicit-refs")
+
+func NewRuntime() *Runtime {
+	return &Runtime{Store: NewStore(), Scheduler: NewScheduler(DefaultTimeSlice)}
+}
+
+//NewBinding binds name to val, allocating a location for it when using implicit refs.
+func (rt *Runtime) NewBinding(name string, val ExpVal) Binding {
+	if rt.ImplicitRefs {
+		return Binding{VarName: name, Value: rt.Store.NewRef(val)}
+	}
+	return Binding{VarName: name, Value: val}
+}
+
+//The methods below are what an evaluator needs from the runtime besides the values, the Eval
+//methods use them and so does any other evaluator of the AST.
+
+func (rt *Runtime) Lookup(varName string, env BindingList) (ExpVal, error) {
+	return findIdentifierInEnv(varName, env, rt)
+}
+
+//LookupAddress returns the value of the binding depth places from the front of env, the
+//lexical address the nameless translator gives a variable.
+func (rt *Runtime) LookupAddress(depth int, env BindingList) (ExpVal, error) {
+	if depth < 0 || depth >= len(env) {
+		return nil, fmt.Errorf("Invalid lexical address: (%d), the env has %d binding(s)", depth, len(env))
+	}
+	b := env[depth]
+	if b.Rec != nil {
+		return b.Rec.closure(env[depth-b.Rec.Index:]), nil
+	}
+	if ref, ok := b.Value.(RefVal); ok && rt.ImplicitRefs {
+		return rt.Store.Deref(ref)
+	}
+	return b.Value, nil
+}
+
+//SetAddress is SetVariable for the binding at a lexical address.
+func (rt *Runtime) SetAddress(depth int, val ExpVal, env BindingList) (ExpVal, error) {
+	if depth < 0 || depth >= len(env) {
+		return nil, fmt.Errorf("Invalid lexical address: (%d), the env has %d binding(s)", depth, len(env))
+	}
+	return rt.assign(env[depth], val)
+}
+
+//Number returns num the way the runtime represents numbers, literals too big for an int
+//can only be used in bignum mode.
+func (rt *Runtime) Number(num ExpVal) (ExpVal, error) {
+	switch num := num.(type) {
+	case NumVal:
+		if rt.Bignums {
+			return BigNumVal{Value: big.NewInt(int64(num.Value))}, nil
+		}
+	case BigNumVal:
+		if !rt.Bignums {
+			return nil, fmt.Errorf("%s does not fit in a machine int, run with --bignum", num)
+		}
+	}
+	return num, nil
+}
+
+func (rt *Runtime) ApplyPrimitive(name string, args []ExpVal) (ExpVal, error) {
+	return applyPrimitive(rt, name, args)
+}
+
+//BindArgs counts a step for the scheduler and returns the env proc's body is evaluated in.
+func (rt *Runtime) BindArgs(proc *ProcVal, args []ExpVal) (BindingList, error) {
+	if err := rt.Scheduler.step(); err != nil {
+		return nil, err
+	}
+	if len(args) != len(proc.Params) {
+		return nil, errors.New(fmt.Sprintf("Procedure %s expects %d argument(s), got %d",
+			proc, len(proc.Params), len(args)))
+	}
+	newEnv := make(BindingList, 0, len(args)+len(proc.Env))
+	for i, param := range proc.Params {
+		newEnv = append(newEnv, rt.NewBinding(param, args[i]))
+	}
+	return append(newEnv, proc.Env...), nil
+}
+
+//SetVariable assigns val to the location varName is bound to and returns the value of the set.
+func (rt *Runtime) SetVariable(varName string, val ExpVal, env BindingList) (ExpVal, error) {
+	for _, b := range env {
+		if b.VarName == varName {
+			return rt.assign(b, val)
+		}
+	}
+	return nil, errors.New(fmt.Sprintf("Could not find variable name: %s in env of: %#v", varName, env))
+}
+
+//Like EOPL, set returns an arbitrary value since it is only run for its effect.
+var setResult = NumVal{Value: 27}
+
+//assign stores val in the location b is bound to.
+func (rt *Runtime) assign(b Binding, val ExpVal) (ExpVal, error) {
+	ref, ok := b.Value.(RefVal)
+	if b.Rec != nil || !ok {
+		return nil, errors.New(fmt.Sprintf("Cannot set %s, it is not an assignable variable", b.VarName))
+	}
+	if err := rt.Store.SetRef(ref, val); err != nil {
+		return nil, err
+	}
+	return setResult, nil
+}
+
+func (rt *Runtime) apply(proc *ProcVal, args []ExpVal) (ExpVal, error) {
+	if rt.Apply != nil {
+		return rt.Apply(proc, args)
+	}
+	return applyProcedure(rt, proc, args)
+}
+
+//Store is the EXPLICIT-REFS store, a RefVal is an index into locations.
+type Store struct {
+	locations []ExpVal
+}
+
+func NewStore() *Store {
+	return &Store{}
+}
+
+func (s *Store) NewRef(val ExpVal) RefVal {
+	s.locations = append(s.locations, val)
+	return RefVal{Location: len(s.locations) - 1}
+}
+
+func (s *Store) Deref(ref RefVal) (ExpVal, error) {
+	if ref.Location < 0 || ref.Location >= len(s.locations) {
+		return nil, fmt.Errorf("Invalid reference: %d, the store has %d location(s)", ref.Location, len(s.locations))
+	}
+	return s.locations[ref.Location], nil
+}
+
+func (s *Store) SetRef(ref RefVal, val ExpVal) error {
+	if ref.Location < 0 || ref.Location >= len(s.locations) {
+		return fmt.Errorf("Invalid reference: %d, the store has %d location(s)", ref.Location, len(s.locations))
+	}
+	s.locations[ref.Location] = val
+	return nil
+}
+
+func (s *Store) Len() int { return len(s.locations) }
+
+func (s *Store) String() string {
+	var str strings.Builder
+	str.WriteString("[")
+	for i, val := range s.locations {
+		if i > 0 {
+			str.WriteString(", ")
+		}
+		fmt.Fprintf(&str, "%d: %s", i, val)
+	}
+	str.WriteString("]")
+	return str.String()
+}
@@ -0,0 +1,180 @@
+package ast
+
+import (
+	"errors"
+	"fmt"
+)
+
+//DefaultTimeSlice is how many steps a thread runs before it is preempted, a step is one
+//procedure or primitive application.
+const DefaultTimeSlice = 10
+
+var errThreadKilled = errors.New("thread killed")
+var errDeadlock = errors.New("deadlock: every thread is waiting on a mutex")
+
+type thread struct {
+	wake chan struct{}
+}
+
+func newThread() *thread {
+	return &thread{wake: make(chan struct{}, 1)}
+}
+
+//Scheduler is a deterministic round robin scheduler for the THREADS primitives. Each Let
+//thread runs on its own goroutine, but only the current thread ever runs, the rest are parked
+//on their wake channel until the scheduler hands control to them.
+type Scheduler struct {
+	TimeSlice int
+	remaining int
+	main      *thread
+	current   *thread
+	ready     []*thread
+	live      map[*thread]bool
+	err       error
+	killed    bool
+}
+
+func NewScheduler(timeSlice int) *Scheduler {
+	main := newThread()
+	return &Scheduler{
+		TimeSlice: timeSlice,
+		remaining: timeSlice,
+		main:      main,
+		current:   main,
+		live:      map[*thread]bool{main: true},
+	}
+}
+
+//step counts one step against the current thread, and preempts it when its slice is used up.
+func (s *Scheduler) step() error {
+	s.remaining--
+	if s.remaining > 0 {
+		return nil
+	}
+	if len(s.ready) == 0 {
+		s.remaining = s.TimeSlice
+		return nil
+	}
+	return s.yield()
+}
+
+func (s *Scheduler) yield() error {
+	s.ready = append(s.ready, s.current)
+	return s.park()
+}
+
+//park hands control to the next ready thread and blocks the current one until it is woken.
+func (s *Scheduler) park() error {
+	me := s.current
+	if len(s.ready) == 0 {
+		if me == s.main {
+			return errDeadlock
+		}
+		s.fail(errDeadlock)
+	} else {
+		s.runNext()
+	}
+	<-me.wake
+	if s.killed {
+		return errThreadKilled
+	}
+	if me == s.main && s.err != nil {
+		return s.err
+	}
+	return nil
+}
+
+func (s *Scheduler) runNext() {
+	next := s.ready[0]
+	s.ready = s.ready[1:]
+	s.current = next
+	s.remaining = s.TimeSlice
+	next.wake <- struct{}{}
+}
+
+//fail stops every other thread and hands the error to the main thread.
+func (s *Scheduler) fail(err error) {
+	s.err = err
+	s.ready = nil
+	s.current = s.main
+	s.main.wake <- struct{}{}
+}
+
+func (s *Scheduler) spawn(run func() error) {
+	t := newThread()
+	s.live[t] = true
+	s.ready = append(s.ready, t)
+	go func() {
+		<-t.wake
+		if s.killed {
+			return
+		}
+		err := run()
+		if s.killed {
+			return
+		}
+		delete(s.live, t)
+		if err != nil {
+			s.fail(err)
+		} else if len(s.ready) == 0 {
+			//The main thread is always ready or waiting on a mutex, so nothing can run.
+			s.fail(errDeadlock)
+		} else {
+			s.runNext()
+		}
+	}()
+}
+
+//RunRemaining is called by the main thread once it has its result, it keeps running the other
+//threads until none of them are ready, then stops any that are still waiting on a mutex.
+func (s *Scheduler) RunRemaining() error {
+	for s.err == nil && len(s.ready) > 0 {
+		s.ready = append(s.ready, s.main)
+		s.runNext()
+		<-s.main.wake
+	}
+	s.Shutdown()
+	return s.err
+}
+
+//Shutdown unblocks every parked thread so its goroutine can exit.
+func (s *Scheduler) Shutdown() {
+	s.killed = true
+	for t := range s.live {
+		if t != s.current {
+			t.wake <- struct{}{}
+		}
+	}
+	s.live = map[*thread]bool{}
+	s.ready = nil
+}
+
+//MutexVal is a binary semaphore, a thread that waits on a locked mutex is parked until it is
+//handed the mutex by signal.
+type MutexVal struct {
+	locked  bool
+	waiters []*thread
+}
+
+func (v *MutexVal) String() string {
+	return fmt.Sprintf("<mutex locked=%t waiting=%d>", v.locked, len(v.waiters))
+}
+func (v *MutexVal) TypeName() string { return "mutex" }
+
+func (s *Scheduler) wait(m *MutexVal) error {
+	if !m.locked {
+		m.locked = true
+		return nil
+	}
+	m.waiters = append(m.waiters, s.current)
+	return s.park()
+}
+
+func (s *Scheduler) signal(m *MutexVal) {
+	if len(m.waiters) == 0 {
+		m.locked = false
+		return
+	}
+	s.ready = append(s.ready, m.waiters[0])
+	m.waiters = m.waiters[1:]
+}
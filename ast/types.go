@@ -0,0 +1,44 @@
+package ast
+
+import (
+	"strings"
+)
+
+//Type is a type annotation like int or (int -> bool), checked by the typecheck package.
+type Type interface {
+	String() string
+}
+
+type IntType struct{}
+
+func (t IntType) String() string { return "int" }
+
+type BoolType struct{}
+
+func (t BoolType) String() string { return "bool" }
+
+type StringType struct{}
+
+func (t StringType) String() string { return "string" }
+
+//UnknownType is the ? annotation, the type checker infers the type in its place.
+type UnknownType struct{}
+
+func (t UnknownType) String() string { return "?" }
+
+//ProcType is the type of a procedure, written (int * bool -> int).
+type ProcType struct {
+	Params []Type
+	Result Type
+}
+
+func (t *ProcType) String() string {
+	params := make([]string, len(t.Params))
+	for i, param := range t.Params {
+		params[i] = param.String()
+	}
+	if len(params) == 0 {
+		return "(-> " + t.Result.String() + ")"
+	}
+	return "(" + strings.Join(params, " * ") + " -> " + t.Result.String() + ")"
+}
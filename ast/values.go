@@ -0,0 +1,247 @@
+package ast
+
+import (
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+//ExpVal is any value a Let expression can evaluate to.
+type ExpVal interface {
+	String() string
+	TypeName() string
+}
+
+type NumVal struct {
+	Value int
+}
+
+func (v NumVal) String() string   { return fmt.Sprintf("%d", v.Value) }
+func (v NumVal) TypeName() string { return "number" }
+
+//BigNumVal is a number in bignum mode, it never overflows.
+type BigNumVal struct {
+	Value *big.Int
+}
+
+func (v BigNumVal) String() string   { return v.Value.String() }
+func (v BigNumVal) TypeName() string { return "number" }
+
+//RatVal is an exact fraction, a rational that is a whole number is always an int instead.
+type RatVal struct {
+	Value *big.Rat
+}
+
+func (v RatVal) String() string   { return v.Value.RatString() }
+func (v RatVal) TypeName() string { return "number" }
+
+type FloatVal struct {
+	Value float64
+}
+
+//String always shows a fraction or exponent, so a float is not mistaken for an int.
+func (v FloatVal) String() string {
+	str := strconv.FormatFloat(v.Value, 'g', -1, 64)
+	if !strings.ContainsAny(str, ".eIN") {
+		str += ".0"
+	}
+	return str
+}
+func (v FloatVal) TypeName() string { return "number" }
+
+type BoolVal struct {
+	Value bool
+}
+
+func (v BoolVal) String() string   { return fmt.Sprintf("%t", v.Value) }
+func (v BoolVal) TypeName() string { return "bool" }
+
+type StrVal struct {
+	Value string
+}
+
+//String shows the string quoted with its escape sequences, so it can be told apart from other values.
+func (v StrVal) String() string   { return strconv.Quote(v.Value) }
+func (v StrVal) TypeName() string { return "string" }
+
+type EmptyListVal struct{}
+
+func (v EmptyListVal) String() string   { return "()" }
+func (v EmptyListVal) TypeName() string { return "emptylist" }
+
+//PairVal is a cons cell, a list is a chain of pairs ending in EmptyListVal.
+type PairVal struct {
+	Car ExpVal
+	Cdr ExpVal
+}
+
+func (v *PairVal) String() string {
+	str := "(" + v.Car.String()
+	rest := v.Cdr
+	for {
+		switch r := rest.(type) {
+		case *PairVal:
+			str += " " + r.Car.String()
+			rest = r.Cdr
+			continue
+		case EmptyListVal:
+			return str + ")"
+		default:
+			return str + " . " + r.String() + ")"
+		}
+	}
+}
+func (v *PairVal) TypeName() string { return "pair" }
+
+//RefVal is a location in the Store.
+type RefVal struct {
+	Location int
+}
+
+func (v RefVal) String() string   { return fmt.Sprintf("<ref %d>", v.Location) }
+func (v RefVal) TypeName() string { return "ref" }
+
+//ProcVal is a closure, Env is the BindingList in effect when the proc was evaluated.
+type ProcVal struct {
+	Params []string
+	Body   Expression
+	Env    BindingList
+}
+
+func (v *ProcVal) String() string {
+	return fmt.Sprintf("<proc (%s)>", strings.Join(v.Params, ", "))
+}
+func (v *ProcVal) TypeName() string { return "proc" }
+
+//RecProc is a procedure bound by letrec. Index is its position within the letrec group, so
+//the group (and everything it closes over) starts Index bindings before it in the env.
+type RecProc struct {
+	Params []string
+	Body   Expression
+	Index  int
+}
+
+func (r *RecProc) String() string {
+	return fmt.Sprintf("<rec proc (%s)>", strings.Join(r.Params, ", "))
+}
+
+func (r *RecProc) closure(groupEnv BindingList) *ProcVal {
+	return &ProcVal{Params: r.Params, Body: r.Body, Env: groupEnv}
+}
+
+//ContVal is a continuation captured by letcc. Frame is the continuation itself for evaluators
+//that represent it as data, the Eval methods leave it nil and unwind the Go stack to the letcc.
+type ContVal struct {
+	Name   string
+	Frame  interface{}
+	active bool
+}
+
+func (v *ContVal) String() string   { return fmt.Sprintf("<continuation %s>", v.Name) }
+func (v *ContVal) TypeName() string { return "continuation" }
+
+//TypeError is returned when an operation is applied to a value of the wrong kind.
+type TypeError struct {
+	Operation string
+	Expected  string
+	Got       ExpVal
+}
+
+func (e *TypeError) Error() string {
+	got := "nothing"
+	if e.Got != nil {
+		got = e.Got.TypeName()
+	}
+	return fmt.Sprintf("%s expects %s, got %s", e.Operation, e.Expected, got)
+}
+
+//RaisedException carries a value raised by a Let program. Try expressions catch it, if it
+//reaches EvalProgram the exception was uncaught.
+type RaisedException struct {
+	Value ExpVal
+}
+
+func (e *RaisedException) Error() string {
+	return fmt.Sprintf("uncaught exception: %s", e.Value)
+}
+
+//thrownValue unwinds the Eval methods from a throw to the letcc that captured its continuation.
+type thrownValue struct {
+	to    *ContVal
+	value ExpVal
+}
+
+func (e *thrownValue) Error() string {
+	return fmt.Sprintf("throw to %s escaped the thread that captured it", e.to)
+}
+
+func expvalToBool(val ExpVal, operation string) (bool, error) {
+	if b, ok := val.(BoolVal); ok {
+		return b.Value, nil
+	}
+	return false, &TypeError{Operation: operation, Expected: "a bool", Got: val}
+}
+
+func expvalToPair(val ExpVal, operation string) (*PairVal, error) {
+	if pair, ok := val.(*PairVal); ok {
+		return pair, nil
+	}
+	return nil, &TypeError{Operation: operation, Expected: "a non-empty list", Got: val}
+}
+
+func expvalToString(val ExpVal, operation string) (string, error) {
+	if str, ok := val.(StrVal); ok {
+		return str.Value, nil
+	}
+	return "", &TypeError{Operation: operation, Expected: "a string", Got: val}
+}
+
+//expvalToIndex returns an int argument used to index a string.
+func expvalToIndex(val ExpVal, operation string) (int, error) {
+	switch num := val.(type) {
+	case NumVal:
+		return num.Value, nil
+	case BigNumVal:
+		if num.Value.IsInt64() {
+			return int(num.Value.Int64()), nil
+		}
+	}
+	return 0, &TypeError{Operation: operation, Expected: "an int", Got: val}
+}
+
+func expvalToRef(val ExpVal, operation string) (RefVal, error) {
+	if ref, ok := val.(RefVal); ok {
+		return ref, nil
+	}
+	return RefVal{}, &TypeError{Operation: operation, Expected: "a reference", Got: val}
+}
+
+func expvalToMutex(val ExpVal, operation string) (*MutexVal, error) {
+	if mutex, ok := val.(*MutexVal); ok {
+		return mutex, nil
+	}
+	return nil, &TypeError{Operation: operation, Expected: "a mutex", Got: val}
+}
+
+func expvalToCont(val ExpVal, operation string) (*ContVal, error) {
+	if k, ok := val.(*ContVal); ok {
+		return k, nil
+	}
+	return nil, &TypeError{Operation: operation, Expected: "a continuation", Got: val}
+}
+
+func expvalToProc(val ExpVal, operation string) (*ProcVal, error) {
+	if proc, ok := val.(*ProcVal); ok {
+		return proc, nil
+	}
+	return nil, &TypeError{Operation: operation, Expected: "a procedure", Got: val}
+}
+
+func applyProcedure(rt *Runtime, proc *ProcVal, args []ExpVal) (ExpVal, error) {
+	newEnv, err := rt.BindArgs(proc, args)
+	if err != nil {
+		return nil, err
+	}
+	return proc.Body.Eval(newEnv, rt)
+}
@@ -0,0 +1,514 @@
+package evaluator
+
+import (
+	"let_lang_proj_michael_andrepont/ast"
+	"fmt"
+)
+
+//The CPS evaluator runs the same AST as the Eval methods, but the rest of the computation is
+//kept in continuation objects on the heap instead of on the Go stack. Each step of the
+//machine either evaluates an expression or hands a value to a continuation, and run loops
+//over those steps, so the Go stack does not grow however deep the Let program recurses.
+
+//cont is a continuation, a frame of the computation waiting for a value. A nil cont is the end
+//of the program. Frames are never modified once built, so one can be applied more than once.
+type cont interface {
+	apply(m *machine, val ast.ExpVal) error
+	outer() cont
+}
+
+type machine struct {
+	rt *ast.Runtime
+	//When returning is set the machine hands val to k, otherwise it evaluates expr in env.
+	returning bool
+	expr      ast.Expression
+	env       ast.BindingList
+	val       ast.ExpVal
+	k         cont
+}
+
+func newMachine(rt *ast.Runtime) *machine {
+	return &machine{rt: rt}
+}
+
+func (m *machine) eval(expr ast.Expression, env ast.BindingList, k cont) {
+	m.returning = false
+	m.expr, m.env, m.k = expr, env, k
+}
+
+func (m *machine) ret(val ast.ExpVal, k cont) {
+	m.returning = true
+	m.val, m.k = val, k
+}
+
+func (m *machine) run() (ast.ExpVal, error) {
+	for {
+		if !m.returning {
+			if err := m.step(); err != nil {
+				return nil, err
+			}
+		} else if m.k == nil {
+			return m.val, nil
+		} else if err := m.k.apply(m, m.val); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (m *machine) step() error {
+	env := m.env
+	k := m.k
+	m.expr.SetEnv(&env)
+	switch e := m.expr.(type) {
+	case *ast.IntLiteral:
+		val, err := m.rt.Number(e.Num())
+		if err != nil {
+			return err
+		}
+		m.ret(val, k)
+	case *ast.FloatLiteral:
+		m.ret(ast.FloatVal{Value: e.Value}, k)
+	case *ast.StringLiteral:
+		m.ret(ast.StrVal{Value: e.Value}, k)
+	case *ast.BoolLiteral:
+		m.ret(ast.BoolVal{Value: e.Value}, k)
+	case *ast.EmptyListLiteral:
+		m.ret(ast.EmptyListVal{}, k)
+	case *ast.Identifier:
+		val, err := m.rt.Lookup(e.Value, env)
+		if err != nil {
+			return err
+		}
+		m.ret(val, k)
+	case *ast.QualifiedVarExpression:
+		val, err := m.rt.LookupQualified(e.Module.Value, e.Var.Value)
+		if err != nil {
+			return err
+		}
+		m.ret(val, k)
+	case *ast.LetExpression:
+		e.Name.SetEnv(&env)
+		m.eval(e.Value, env, &letCont{e: e, env: env, next: k})
+	case *ast.MultiLetExpression:
+		for _, binding := range e.Bindings {
+			binding.Name.SetEnv(&env)
+		}
+		m.multiLet(e, env, nil, k)
+	case *ast.LetStarExpression:
+		m.letStar(e, 0, env, k)
+	case *ast.MinusExpression:
+		return m.primApp("minus", []ast.Expression{e.Arg1, e.Arg2}, env, nil, k)
+	case *ast.IsZeroExpression:
+		return m.primApp("zero?", []ast.Expression{e.Arg1}, env, nil, k)
+	case *ast.PrimAppExpression:
+		return m.primApp(e.Name, e.Args, env, nil, k)
+	case *ast.IfThenElseExpression:
+		m.eval(e.Value, env, &ifCont{e: e, env: env, next: k})
+	case *ast.ProcExpression:
+		params := make([]string, len(e.Params))
+		for i, param := range e.Params {
+			param.SetEnv(&env)
+			params[i] = param.Value
+		}
+		m.ret(&ast.ProcVal{Params: params, Body: e.Body, Env: env}, k)
+	case *ast.CallExpression:
+		m.eval(e.Operator, env, &operatorCont{e: e, env: env, next: k})
+	case *ast.LetrecExpression:
+		for _, proc := range e.Procs {
+			proc.Name.SetEnv(&env)
+		}
+		m.eval(e.In, e.Extend(env), k)
+	case *ast.BeginExpression:
+		if len(e.Exprs) == 0 {
+			m.ret(nil, k)
+			return nil
+		}
+		m.begin(e, 0, env, k)
+	case *ast.SetExpression:
+		e.Name.SetEnv(&env)
+		if !m.rt.ImplicitRefs {
+			return ast.ErrSetWithoutImplicitRefs
+		}
+		m.eval(e.Value, env, &setCont{e: e, env: env, next: k})
+	case *ast.RaiseExpression:
+		m.eval(e.Value, env, &raiseCont{next: k})
+	case *ast.TryExpression:
+		e.Var.SetEnv(&env)
+		m.eval(e.Body, env, &tryCont{e: e, env: env, next: k})
+	case *ast.NewObjectExpression:
+		return m.objectArgs(e, nil, e.Args, env, nil, k)
+	case *ast.MethodCallExpression:
+		m.eval(e.Object, env, &sendObjectCont{e: e, env: env, next: k})
+	case *ast.SuperCallExpression:
+		return m.objectArgs(e, nil, e.Args, env, nil, k)
+	case *ast.SelfExpression:
+		self, err := m.rt.Self(env)
+		if err != nil {
+			return err
+		}
+		m.ret(self, k)
+	case *ast.LetccExpression:
+		e.Name.SetEnv(&env)
+		contVal := &ast.ContVal{Name: e.Name.Value, Frame: captured{k: k}}
+		m.eval(e.Body, append(ast.BindingList{m.rt.NewBinding(e.Name.Value, contVal)}, env...), k)
+	case *ast.ThrowExpression:
+		m.eval(e.Value, env, &throwValueCont{e: e, env: env, next: k})
+	default:
+		return fmt.Errorf("Could not evaluate %T with the CPS evaluator", m.expr)
+	}
+	return nil
+}
+
+//multiLet evaluates the next binding of e, bound holds the bindings made so far.
+func (m *machine) multiLet(e *ast.MultiLetExpression, env ast.BindingList, bound ast.BindingList, k cont) {
+	if len(bound) == len(e.Bindings) {
+		m.eval(e.In, append(bound, env...), k)
+		return
+	}
+	m.eval(e.Bindings[len(bound)].Value, env, &multiLetCont{e: e, env: env, bound: bound, next: k})
+}
+
+func (m *machine) letStar(e *ast.LetStarExpression, i int, env ast.BindingList, k cont) {
+	if i == len(e.Bindings) {
+		m.eval(e.In, env, k)
+		return
+	}
+	bindingEnv := env
+	e.Bindings[i].Name.SetEnv(&bindingEnv)
+	m.eval(e.Bindings[i].Value, env, &letStarCont{e: e, i: i, env: env, next: k})
+}
+
+func (m *machine) primApp(name string, args []ast.Expression, env ast.BindingList, vals []ast.ExpVal, k cont) error {
+	if len(vals) == len(args) {
+		val, err := m.rt.ApplyPrimitive(name, vals)
+		if err != nil {
+			return err
+		}
+		m.ret(val, k)
+		return nil
+	}
+	m.eval(args[len(vals)], env, &primArgCont{name: name, args: args, env: env, vals: vals, next: k})
+	return nil
+}
+
+func (m *machine) callArgs(e *ast.CallExpression, proc *ast.ProcVal, env ast.BindingList, vals []ast.ExpVal, k cont) error {
+	if len(vals) == len(e.Operands) {
+		return m.applyProc(proc, vals, k)
+	}
+	m.eval(e.Operands[len(vals)], env, &callArgCont{e: e, proc: proc, env: env, vals: vals, next: k})
+	return nil
+}
+
+func (m *machine) applyProc(proc *ast.ProcVal, args []ast.ExpVal, k cont) error {
+	newEnv, err := m.rt.BindArgs(proc, args)
+	if err != nil {
+		return err
+	}
+	m.eval(proc.Body, newEnv, k)
+	return nil
+}
+
+//objectArgs evaluates the arguments of new, send or super, and then applies the method. obj is
+//the object a send goes to.
+func (m *machine) objectArgs(e ast.Expression, obj ast.ExpVal, args []ast.Expression, env ast.BindingList, vals []ast.ExpVal, k cont) error {
+	if len(vals) < len(args) {
+		m.eval(args[len(vals)], env, &objectArgCont{e: e, obj: obj, args: args, env: env, vals: vals, next: k})
+		return nil
+	}
+	switch e := e.(type) {
+	case *ast.NewObjectExpression:
+		newObj, err := m.rt.NewObject(e.Class.Value)
+		if err != nil {
+			return err
+		}
+		initialize, err := m.rt.Initializer(newObj, len(vals))
+		if err != nil {
+			return err
+		}
+		if initialize == nil {
+			m.ret(newObj, k)
+			return nil
+		}
+		return m.applyProc(initialize, vals, &newObjectCont{obj: newObj, next: k})
+	case *ast.MethodCallExpression:
+		proc, err := m.rt.MethodProc(obj, e.Method.Value)
+		if err != nil {
+			return err
+		}
+		return m.applyProc(proc, vals, k)
+	case *ast.SuperCallExpression:
+		proc, err := m.rt.SuperMethodProc(env, e.Method.Value)
+		if err != nil {
+			return err
+		}
+		return m.applyProc(proc, vals, k)
+	}
+	return fmt.Errorf("Could not evaluate %T with the CPS evaluator", e)
+}
+
+func (m *machine) begin(e *ast.BeginExpression, i int, env ast.BindingList, k cont) {
+	if i == len(e.Exprs)-1 {
+		m.eval(e.Exprs[i], env, k)
+		return
+	}
+	m.eval(e.Exprs[i], env, &beginCont{e: e, i: i + 1, env: env, next: k})
+}
+
+//raise hands val to the handler of the closest try frame in k.
+func (m *machine) raise(val ast.ExpVal, k cont) error {
+	for ; k != nil; k = k.outer() {
+		if try, ok := k.(*tryCont); ok {
+			handlerEnv := append(ast.BindingList{m.rt.NewBinding(try.e.Var.Value, val)}, try.env...)
+			m.eval(try.e.Handler, handlerEnv, try.next)
+			return nil
+		}
+	}
+	return &ast.RaisedException{Value: val}
+}
+
+//extend copies vals with val on the end, so frames sharing vals are left alone.
+func extend(vals []ast.ExpVal, val ast.ExpVal) []ast.ExpVal {
+	return append(append(make([]ast.ExpVal, 0, len(vals)+1), vals...), val)
+}
+
+type letCont struct {
+	e    *ast.LetExpression
+	env  ast.BindingList
+	next cont
+}
+
+func (c *letCont) apply(m *machine, val ast.ExpVal) error {
+	m.eval(c.e.In, append(ast.BindingList{m.rt.NewBinding(c.e.Name.Value, val)}, c.env...), c.next)
+	return nil
+}
+func (c *letCont) outer() cont { return c.next }
+
+type multiLetCont struct {
+	e     *ast.MultiLetExpression
+	env   ast.BindingList
+	bound ast.BindingList
+	next  cont
+}
+
+func (c *multiLetCont) apply(m *machine, val ast.ExpVal) error {
+	bound := make(ast.BindingList, 0, len(c.bound)+1+len(c.env))
+	bound = append(bound, c.bound...)
+	bound = append(bound, m.rt.NewBinding(c.e.Bindings[len(c.bound)].Name.Value, val))
+	m.multiLet(c.e, c.env, bound, c.next)
+	return nil
+}
+func (c *multiLetCont) outer() cont { return c.next }
+
+type letStarCont struct {
+	e    *ast.LetStarExpression
+	i    int
+	env  ast.BindingList
+	next cont
+}
+
+func (c *letStarCont) apply(m *machine, val ast.ExpVal) error {
+	newEnv := append(ast.BindingList{m.rt.NewBinding(c.e.Bindings[c.i].Name.Value, val)}, c.env...)
+	m.letStar(c.e, c.i+1, newEnv, c.next)
+	return nil
+}
+func (c *letStarCont) outer() cont { return c.next }
+
+type primArgCont struct {
+	name string
+	args []ast.Expression
+	env  ast.BindingList
+	vals []ast.ExpVal
+	next cont
+}
+
+func (c *primArgCont) apply(m *machine, val ast.ExpVal) error {
+	return m.primApp(c.name, c.args, c.env, extend(c.vals, val), c.next)
+}
+func (c *primArgCont) outer() cont { return c.next }
+
+type ifCont struct {
+	e    *ast.IfThenElseExpression
+	env  ast.BindingList
+	next cont
+}
+
+func (c *ifCont) apply(m *machine, val ast.ExpVal) error {
+	predicate, ok := val.(ast.BoolVal)
+	if !ok {
+		return &ast.TypeError{Operation: "if", Expected: "a bool", Got: val}
+	}
+	if predicate.Value {
+		m.eval(c.e.TrueBranch, c.env, c.next)
+	} else {
+		m.eval(c.e.FalseBranch, c.env, c.next)
+	}
+	return nil
+}
+func (c *ifCont) outer() cont { return c.next }
+
+type operatorCont struct {
+	e    *ast.CallExpression
+	env  ast.BindingList
+	next cont
+}
+
+func (c *operatorCont) apply(m *machine, val ast.ExpVal) error {
+	proc, ok := val.(*ast.ProcVal)
+	if !ok {
+		return &ast.TypeError{Operation: "call", Expected: "a procedure", Got: val}
+	}
+	return m.callArgs(c.e, proc, c.env, nil, c.next)
+}
+func (c *operatorCont) outer() cont { return c.next }
+
+type callArgCont struct {
+	e    *ast.CallExpression
+	proc *ast.ProcVal
+	env  ast.BindingList
+	vals []ast.ExpVal
+	next cont
+}
+
+func (c *callArgCont) apply(m *machine, val ast.ExpVal) error {
+	return m.callArgs(c.e, c.proc, c.env, extend(c.vals, val), c.next)
+}
+func (c *callArgCont) outer() cont { return c.next }
+
+type sendObjectCont struct {
+	e    *ast.MethodCallExpression
+	env  ast.BindingList
+	next cont
+}
+
+func (c *sendObjectCont) apply(m *machine, val ast.ExpVal) error {
+	return m.objectArgs(c.e, val, c.e.Args, c.env, nil, c.next)
+}
+func (c *sendObjectCont) outer() cont { return c.next }
+
+type objectArgCont struct {
+	e    ast.Expression
+	obj  ast.ExpVal
+	args []ast.Expression
+	env  ast.BindingList
+	vals []ast.ExpVal
+	next cont
+}
+
+func (c *objectArgCont) apply(m *machine, val ast.ExpVal) error {
+	return m.objectArgs(c.e, c.obj, c.args, c.env, extend(c.vals, val), c.next)
+}
+func (c *objectArgCont) outer() cont { return c.next }
+
+//newObjectCont makes the object the value of new once initialize returns.
+type newObjectCont struct {
+	obj  *ast.ObjectVal
+	next cont
+}
+
+func (c *newObjectCont) apply(m *machine, val ast.ExpVal) error {
+	m.ret(c.obj, c.next)
+	return nil
+}
+func (c *newObjectCont) outer() cont { return c.next }
+
+type beginCont struct {
+	e    *ast.BeginExpression
+	i    int
+	env  ast.BindingList
+	next cont
+}
+
+func (c *beginCont) apply(m *machine, val ast.ExpVal) error {
+	m.begin(c.e, c.i, c.env, c.next)
+	return nil
+}
+func (c *beginCont) outer() cont { return c.next }
+
+type setCont struct {
+	e    *ast.SetExpression
+	env  ast.BindingList
+	next cont
+}
+
+func (c *setCont) apply(m *machine, val ast.ExpVal) error {
+	result, err := m.rt.SetVariable(c.e.Name.Value, val, c.env)
+	if err != nil {
+		return err
+	}
+	m.ret(result, c.next)
+	return nil
+}
+func (c *setCont) outer() cont { return c.next }
+
+type raiseCont struct {
+	next cont
+}
+
+func (c *raiseCont) apply(m *machine, val ast.ExpVal) error {
+	return m.raise(val, c.next)
+}
+func (c *raiseCont) outer() cont { return c.next }
+
+//tryCont passes the body's value straight through, raise looks for it to find the handler.
+type tryCont struct {
+	e    *ast.TryExpression
+	env  ast.BindingList
+	next cont
+}
+
+func (c *tryCont) apply(m *machine, val ast.ExpVal) error {
+	m.ret(val, c.next)
+	return nil
+}
+func (c *tryCont) outer() cont { return c.next }
+
+//captured is the Frame of a ContVal made by the CPS evaluator, k may be nil for the end of the
+//program so it is wrapped.
+type captured struct {
+	k cont
+}
+
+type throwValueCont struct {
+	e    *ast.ThrowExpression
+	env  ast.BindingList
+	next cont
+}
+
+func (c *throwValueCont) apply(m *machine, val ast.ExpVal) error {
+	m.eval(c.e.Cont, c.env, &throwCont{value: val, next: c.next})
+	return nil
+}
+func (c *throwValueCont) outer() cont { return c.next }
+
+type throwCont struct {
+	value ast.ExpVal
+	next  cont
+}
+
+//apply abandons next and hands the thrown value to the continuation that was captured.
+func (c *throwCont) apply(m *machine, val ast.ExpVal) error {
+	contVal, ok := val.(*ast.ContVal)
+	if !ok {
+		return &ast.TypeError{Operation: "throw", Expected: "a continuation", Got: val}
+	}
+	target, ok := contVal.Frame.(captured)
+	if !ok {
+		return fmt.Errorf("Cannot throw to %s, it was not captured by the CPS evaluator", contVal)
+	}
+	m.ret(c.value, target.k)
+	return nil
+}
+func (c *throwCont) outer() cont { return c.next }
+
+func evalCPS(expr ast.Expression, env ast.BindingList, rt *ast.Runtime) (ast.ExpVal, error) {
+	rt.Apply = func(proc *ast.ProcVal, args []ast.ExpVal) (ast.ExpVal, error) {
+		m := newMachine(rt)
+		if err := m.applyProc(proc, args, nil); err != nil {
+			return nil, err
+		}
+		return m.run()
+	}
+	m := newMachine(rt)
+	m.eval(expr, env, nil)
+	return m.run()
+}
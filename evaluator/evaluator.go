@@ -6,13 +6,69 @@ import (
 	"fmt"
 )
 
-func EvalProgram(rootNode ast.Node) (int, error) {
+type evalFunc func(expr ast.Expression, env ast.BindingList, rt *ast.Runtime) (ast.ExpVal, error)
+
+//EvalProgram runs a program with the Eval methods of the AST.
+func EvalProgram(rootNode ast.Node, rt *ast.Runtime) (ast.ExpVal, error) {
+	return runProgram(rootNode, rt, evalDirect)
+}
+
+//EvalProgramCPS runs a program with the continuation passing evaluator, which gives the same
+//results as EvalProgram without using the Go stack for the program's recursion.
+func EvalProgramCPS(rootNode ast.Node, rt *ast.Runtime) (ast.ExpVal, error) {
+	return runProgram(rootNode, rt, evalCPS)
+}
+
+func runProgram(rootNode ast.Node, rt *ast.Runtime, eval evalFunc) (ast.ExpVal, error) {
+	if program, ok := rootNode.(*ast.Program); ok {
+		for _, class := range program.Classes {
+			if err := rt.DefineClass(class); err != nil {
+				return nil, err
+			}
+		}
+		for _, module := range program.Modules {
+			if err := defineModule(module, rt, eval); err != nil {
+				rt.Scheduler.Shutdown()
+				return nil, err
+			}
+		}
+		rootNode = program.Body
+	}
 	if node, ok := rootNode.(ast.Expression); ok {
-		return evalExpression(node, []ast.Binding{})
+		result, err := eval(node, []ast.Binding{}, rt)
+		if err != nil {
+			rt.Scheduler.Shutdown()
+			return nil, err
+		}
+		if err := rt.Scheduler.RunRemaining(); err != nil {
+			return nil, err
+		}
+		return result, nil
 	} else {
-		return -1, errors.New(fmt.Sprintf("Could not evaluate %T, No eval function exist for that node.", rootNode))
+		return nil, errors.New(fmt.Sprintf("Could not evaluate %T, No eval function exist for that node.", rootNode))
 	}
 }
-func evalExpression(expressionRoot ast.Expression, e []ast.Binding) (int, error) {
-	return expressionRoot.Eval(e)
+
+//defineModule evaluates the body of a module like let*, and exports the names in its interface.
+func defineModule(module *ast.ModuleDefinition, rt *ast.Runtime, eval evalFunc) error {
+	env := ast.BindingList{}
+	for _, binding := range module.Body {
+		bindingEnv := env
+		binding.Name.SetEnv(&bindingEnv)
+		val, err := eval(binding.Value, bindingEnv, rt)
+		if err != nil {
+			return err
+		}
+		env = append(ast.BindingList{rt.NewBinding(binding.Name.Value, val)}, bindingEnv...)
+	}
+
+	exports, err := module.Exports(env)
+	if err != nil {
+		return err
+	}
+	return rt.DefineModule(module.Name.Value, exports)
+}
+
+func evalDirect(expr ast.Expression, env ast.BindingList, rt *ast.Runtime) (ast.ExpVal, error) {
+	return expr.Eval(env, rt)
 }
@@ -2,22 +2,53 @@ package evaluator
 
 import (
 	"let_lang_proj_michael_andrepont/ast"
+	"let_lang_proj_michael_andrepont/lexer"
+	"let_lang_proj_michael_andrepont/parser"
+	"let_lang_proj_michael_andrepont/token"
+	"let_lang_proj_michael_andrepont/vm"
 	"fmt"
 	"math"
+	"runtime/debug"
 	"strings"
 	"testing"
 )
 
+//evalExpression runs the expression with every evaluator, and reports an error if they disagree.
+func evalExpression(expressionRoot ast.Expression, e []ast.Binding) (ast.ExpVal, error) {
+	cpsResult, cpsErr := evalCPS(expressionRoot, e, ast.NewRuntime())
+	vmResult, vmErr := vm.Eval(expressionRoot, e, ast.NewRuntime())
+	result, err := evalDirect(expressionRoot, e, ast.NewRuntime())
+	if fmt.Sprint(result, err) != fmt.Sprint(cpsResult, cpsErr) {
+		return nil, fmt.Errorf("Evaluators disagree, Eval gave %v (error %v) but CPS gave %v (error %v)",
+			result, err, cpsResult, cpsErr)
+	}
+	if fmt.Sprint(result, err) != fmt.Sprint(vmResult, vmErr) {
+		return nil, fmt.Errorf("Evaluators disagree, Eval gave %v (error %v) but the VM gave %v (error %v)",
+			result, err, vmResult, vmErr)
+	}
+	return result, err
+}
+
 func makeInt(val int) *ast.IntLiteral      { return &ast.IntLiteral{Value: val} }
 func makeIdent(val string) *ast.Identifier { return &ast.Identifier{Value: val} }
+func makeBool(val bool) *ast.BoolLiteral   { return &ast.BoolLiteral{Value: val} }
 
 func checkEvalResult(t *testing.T, expression ast.Expression, env ast.BindingList, expected int) {
 	result, err := evalExpression(expression, env)
 	if err != nil {
 		t.Fatal(err.Error())
 	}
-	if result != expected {
-		t.Errorf("Expected result to be %d but was %d", expected, result)
+	if result != (ast.NumVal{Value: expected}) {
+		t.Errorf("Expected result to be %d but was %s", expected, result)
+	}
+}
+func checkBoolResult(t *testing.T, expression ast.Expression, env ast.BindingList, expected bool) {
+	result, err := evalExpression(expression, env)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if result != (ast.BoolVal{Value: expected}) {
+		t.Errorf("Expected result to be %t but was %s", expected, result)
 	}
 }
 func checkErrorResult(t *testing.T, err error, expectedSubStr string) {
@@ -40,12 +71,12 @@ func TestLetEval(t *testing.T) {
 
 func TestIsZeroEvalTrue(t *testing.T) {
 	expression := ast.IsZeroExpression{Arg1: makeInt(0)}
-	checkEvalResult(t, &expression, ast.BindingList{}, 1)
+	checkBoolResult(t, &expression, ast.BindingList{}, true)
 }
 
 func TestIsZeroEvalFalse(t *testing.T) {
 	expression := ast.IsZeroExpression{Arg1: makeInt(10)}
-	checkEvalResult(t, &expression, ast.BindingList{}, 0)
+	checkBoolResult(t, &expression, ast.BindingList{}, false)
 }
 
 func TestMinusEval(t *testing.T) {
@@ -70,16 +101,16 @@ func TestMinusEval(t *testing.T) {
 
 func TestIdentBasic(t *testing.T) {
 	e := ast.BindingList{
-		{VarName: "x", Value: 33},
-		{VarName: "test", Value: 22},
+		{VarName: "x", Value: ast.NumVal{Value: 33}},
+		{VarName: "test", Value: ast.NumVal{Value: 22}},
 	}
 	checkEvalResult(t, makeIdent("test"), e, 22)
 }
 
 func TestIdentShadowed(t *testing.T) {
 	e := ast.BindingList{
-		{VarName: "test", Value: 33},
-		{VarName: "test", Value: 22},
+		{VarName: "test", Value: ast.NumVal{Value: 33}},
+		{VarName: "test", Value: ast.NumVal{Value: 22}},
 	}
 	checkEvalResult(t, makeIdent("test"), e, 33)
 }
@@ -92,7 +123,7 @@ func TestIntLit(t *testing.T) {
 
 func TestIfThenElseEvalTrue(t *testing.T) {
 	expression := ast.IfThenElseExpression{
-		Value:       makeInt(1),
+		Value:       makeBool(true),
 		TrueBranch:  makeInt(22),
 		FalseBranch: makeInt(33),
 	}
@@ -100,16 +131,30 @@ func TestIfThenElseEvalTrue(t *testing.T) {
 }
 
 func TestIfThenElseEvalFalse(t *testing.T) {
-	//Everything other than one should be false.
 	expression := ast.IfThenElseExpression{
-		Value:       makeInt(0),
+		Value:       makeBool(false),
 		TrueBranch:  makeInt(22),
 		FalseBranch: makeInt(33),
 	}
 	checkEvalResult(t, &expression, ast.BindingList{}, 33)
-	expression.Value = makeInt(2)
-	checkEvalResult(t, &expression, ast.BindingList{}, 33)
-	expression.Value = makeInt(-1)
+}
+
+func TestIfThenElseNonBoolPredicate(t *testing.T) {
+	//Numbers are no longer truthy, every non bool predicate is an error.
+	for _, predicate := range []int{0, 1, 2, -1} {
+		expression := ast.IfThenElseExpression{
+			Value:       makeInt(predicate),
+			TrueBranch:  makeInt(22),
+			FalseBranch: makeInt(33),
+		}
+		_, err := evalExpression(&expression, ast.BindingList{})
+		checkErrorResult(t, err, "if expects a bool, got number")
+	}
+}
+
+func TestBoolLit(t *testing.T) {
+	checkBoolResult(t, makeBool(true), ast.BindingList{}, true)
+	checkBoolResult(t, makeBool(false), ast.BindingList{}, false)
 }
 
 func TestIdentNotFoundEmptyEnv(t *testing.T) {
@@ -119,8 +164,8 @@ func TestIdentNotFoundEmptyEnv(t *testing.T) {
 
 func TestIdentNotFoundNotEmptyEnv(t *testing.T) {
 	e := ast.BindingList{
-		{VarName: "x", Value: 33},
-		{VarName: "test", Value: 22},
+		{VarName: "x", Value: ast.NumVal{Value: 33}},
+		{VarName: "test", Value: ast.NumVal{Value: 22}},
 	}
 	_, err := evalExpression(makeIdent("y"), e)
 	checkErrorResult(t, err, "Could not find variable name: y in env of")
@@ -157,7 +202,7 @@ func TestMinusInvalidArg2(t *testing.T) {
 		Arg1: makeIdent("x"),
 		Arg2: makeIdent("y"),
 	}
-	_, err := evalExpression(&expression, ast.BindingList{{VarName: "x", Value: 8}})
+	_, err := evalExpression(&expression, ast.BindingList{{VarName: "x", Value: ast.NumVal{Value: 8}}})
 	checkErrorResult(t, err, "Could not find variable name: y in env of")
 }
 
@@ -199,13 +244,13 @@ func TestAssignmentExample(t *testing.T) {
 			},
 		},
 	}
-	result, err := EvalProgram(&root)
+	result, err := EvalProgram(&root, ast.NewRuntime())
 	expected := -5
 	if err != nil {
 		t.Fatal(err.Error())
 	}
-	if result != expected {
-		t.Fatalf("Expected result to be %d but was %d", expected, result)
+	if result != (ast.NumVal{Value: expected}) {
+		t.Fatalf("Expected result to be %d but was %s", expected, result)
 	}
 }
 
@@ -238,3 +283,1416 @@ func TestAssignmentExampleTwo(t *testing.T) {
 	root.Value = makeInt(10)
 	checkEvalResult(t, &root, ast.BindingList{}, 16)
 }
+
+func TestProcEval(t *testing.T) {
+	expression := ast.ProcExpression{
+		Params: []*ast.Identifier{makeIdent("x")},
+		Body:   makeIdent("x"),
+	}
+	result, err := evalExpression(&expression, ast.BindingList{})
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if _, ok := result.(*ast.ProcVal); !ok {
+		t.Fatalf("Expected result to be %T but was %T", &ast.ProcVal{}, result)
+	}
+}
+
+func TestCallEval(t *testing.T) {
+	expression := ast.CallExpression{
+		Operator: &ast.ProcExpression{
+			Params: []*ast.Identifier{makeIdent("x")},
+			Body: &ast.MinusExpression{
+				Arg1: makeIdent("x"),
+				Arg2: makeInt(11),
+			},
+		},
+		Operands: []ast.Expression{makeInt(7)},
+	}
+	checkEvalResult(t, &expression, ast.BindingList{}, -4)
+}
+
+func TestCallMultipleArgs(t *testing.T) {
+	expression := ast.CallExpression{
+		Operator: &ast.ProcExpression{
+			Params: []*ast.Identifier{makeIdent("x"), makeIdent("y")},
+			Body: &ast.MinusExpression{
+				Arg1: makeIdent("x"),
+				Arg2: makeIdent("y"),
+			},
+		},
+		Operands: []ast.Expression{makeInt(10), makeInt(3)},
+	}
+	checkEvalResult(t, &expression, ast.BindingList{}, 7)
+}
+
+func TestClosureCapturesEnv(t *testing.T) {
+	//let x = 200 in let f = proc (z) minus(z, x) in let x = 100 in (f 1)
+	root := ast.LetExpression{
+		Name:  makeIdent("x"),
+		Value: makeInt(200),
+		In: &ast.LetExpression{
+			Name: makeIdent("f"),
+			Value: &ast.ProcExpression{
+				Params: []*ast.Identifier{makeIdent("z")},
+				Body: &ast.MinusExpression{
+					Arg1: makeIdent("z"),
+					Arg2: makeIdent("x"),
+				},
+			},
+			In: &ast.LetExpression{
+				Name:  makeIdent("x"),
+				Value: makeInt(100),
+				In: &ast.CallExpression{
+					Operator: makeIdent("f"),
+					Operands: []ast.Expression{makeInt(1)},
+				},
+			},
+		},
+	}
+	checkEvalResult(t, &root, ast.BindingList{}, -199)
+}
+
+func TestCurriedCall(t *testing.T) {
+	//((proc (x) proc (y) minus(x, y) 10) 4)
+	expression := ast.CallExpression{
+		Operator: &ast.CallExpression{
+			Operator: &ast.ProcExpression{
+				Params: []*ast.Identifier{makeIdent("x")},
+				Body: &ast.ProcExpression{
+					Params: []*ast.Identifier{makeIdent("y")},
+					Body: &ast.MinusExpression{
+						Arg1: makeIdent("x"),
+						Arg2: makeIdent("y"),
+					},
+				},
+			},
+			Operands: []ast.Expression{makeInt(10)},
+		},
+		Operands: []ast.Expression{makeInt(4)},
+	}
+	checkEvalResult(t, &expression, ast.BindingList{}, 6)
+}
+
+func TestCallNotAProcedure(t *testing.T) {
+	expression := ast.CallExpression{
+		Operator: makeInt(3),
+		Operands: []ast.Expression{makeInt(4)},
+	}
+	_, err := evalExpression(&expression, ast.BindingList{})
+	checkErrorResult(t, err, "call expects a procedure, got number")
+}
+
+func TestCallWrongArgCount(t *testing.T) {
+	expression := ast.CallExpression{
+		Operator: &ast.ProcExpression{
+			Params: []*ast.Identifier{makeIdent("x")},
+			Body:   makeIdent("x"),
+		},
+		Operands: []ast.Expression{makeInt(4), makeInt(5)},
+	}
+	_, err := evalExpression(&expression, ast.BindingList{})
+	checkErrorResult(t, err, "expects 1 argument(s), got 2")
+}
+
+func TestMinusTypeError(t *testing.T) {
+	expression := ast.MinusExpression{
+		Arg1: makeInt(3),
+		Arg2: &ast.ProcExpression{Params: []*ast.Identifier{makeIdent("x")}, Body: makeIdent("x")},
+	}
+	_, err := evalExpression(&expression, ast.BindingList{})
+	checkErrorResult(t, err, "minus expects numbers, got proc")
+	if _, ok := err.(*ast.TypeError); !ok {
+		t.Fatalf("Expected error to be %T but was %T", &ast.TypeError{}, err)
+	}
+}
+
+func TestIsZeroTypeError(t *testing.T) {
+	expression := ast.IsZeroExpression{Arg1: makeIdent("b")}
+	_, err := evalExpression(&expression, ast.BindingList{{VarName: "b", Value: ast.BoolVal{Value: true}}})
+	checkErrorResult(t, err, "zero? expects a number, got bool")
+}
+
+func TestIdentNonNumberValue(t *testing.T) {
+	e := ast.BindingList{
+		{VarName: "b", Value: ast.BoolVal{Value: false}},
+	}
+	result, err := evalExpression(makeIdent("b"), e)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if result != (ast.BoolVal{Value: false}) {
+		t.Fatalf("Expected result to be false but was %s", result)
+	}
+}
+
+func makeOddEven() *ast.LetrecExpression {
+	//letrec even(x) = if iszero(x) then true else (odd minus(x, 1))
+	//       odd(x) = if iszero(x) then false else (even minus(x, 1))
+	//in ...
+	return &ast.LetrecExpression{
+		Procs: []*ast.LetrecProc{
+			{
+				Name:   makeIdent("even"),
+				Params: []*ast.Identifier{makeIdent("x")},
+				Body: &ast.IfThenElseExpression{
+					Value:      &ast.IsZeroExpression{Arg1: makeIdent("x")},
+					TrueBranch: makeBool(true),
+					FalseBranch: &ast.CallExpression{
+						Operator: makeIdent("odd"),
+						Operands: []ast.Expression{&ast.MinusExpression{Arg1: makeIdent("x"), Arg2: makeInt(1)}},
+					},
+				},
+			},
+			{
+				Name:   makeIdent("odd"),
+				Params: []*ast.Identifier{makeIdent("x")},
+				Body: &ast.IfThenElseExpression{
+					Value:      &ast.IsZeroExpression{Arg1: makeIdent("x")},
+					TrueBranch: makeBool(false),
+					FalseBranch: &ast.CallExpression{
+						Operator: makeIdent("even"),
+						Operands: []ast.Expression{&ast.MinusExpression{Arg1: makeIdent("x"), Arg2: makeInt(1)}},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestLetrecRecursive(t *testing.T) {
+	//letrec double(x) = if iszero(x) then 0 else minus((double minus(x, 1)), minus(0, 2)) in (double 6)
+	root := ast.LetrecExpression{
+		Procs: []*ast.LetrecProc{
+			{
+				Name:   makeIdent("double"),
+				Params: []*ast.Identifier{makeIdent("x")},
+				Body: &ast.IfThenElseExpression{
+					Value:      &ast.IsZeroExpression{Arg1: makeIdent("x")},
+					TrueBranch: makeInt(0),
+					FalseBranch: &ast.MinusExpression{
+						Arg1: &ast.CallExpression{
+							Operator: makeIdent("double"),
+							Operands: []ast.Expression{&ast.MinusExpression{Arg1: makeIdent("x"), Arg2: makeInt(1)}},
+						},
+						Arg2: &ast.MinusExpression{Arg1: makeInt(0), Arg2: makeInt(2)},
+					},
+				},
+			},
+		},
+		In: &ast.CallExpression{
+			Operator: makeIdent("double"),
+			Operands: []ast.Expression{makeInt(6)},
+		},
+	}
+	checkEvalResult(t, &root, ast.BindingList{}, 12)
+}
+
+func TestLetrecMutuallyRecursive(t *testing.T) {
+	root := makeOddEven()
+	root.In = &ast.CallExpression{Operator: makeIdent("odd"), Operands: []ast.Expression{makeInt(13)}}
+	checkBoolResult(t, root, ast.BindingList{}, true)
+	root.In = &ast.CallExpression{Operator: makeIdent("even"), Operands: []ast.Expression{makeInt(13)}}
+	checkBoolResult(t, root, ast.BindingList{}, false)
+}
+
+func TestLetrecSeesOuterEnv(t *testing.T) {
+	//let y = 5 in letrec f(x) = minus(x, y) in let y = 100 in (f 10)
+	root := ast.LetExpression{
+		Name:  makeIdent("y"),
+		Value: makeInt(5),
+		In: &ast.LetrecExpression{
+			Procs: []*ast.LetrecProc{
+				{
+					Name:   makeIdent("f"),
+					Params: []*ast.Identifier{makeIdent("x")},
+					Body:   &ast.MinusExpression{Arg1: makeIdent("x"), Arg2: makeIdent("y")},
+				},
+			},
+			In: &ast.LetExpression{
+				Name:  makeIdent("y"),
+				Value: makeInt(100),
+				In:    &ast.CallExpression{Operator: makeIdent("f"), Operands: []ast.Expression{makeInt(10)}},
+			},
+		},
+	}
+	checkEvalResult(t, &root, ast.BindingList{}, 5)
+}
+
+func TestLetrecEnvStr(t *testing.T) {
+	root := makeOddEven()
+	root.In = &ast.CallExpression{Operator: makeIdent("even"), Operands: []ast.Expression{makeInt(2)}}
+	checkBoolResult(t, root, ast.BindingList{}, true)
+	envStr := ast.GetEnvStr(root.In.GetEnv())
+	expected := "[< (even <rec proc (x)>) (odd <rec proc (x)>) >]"
+	if envStr != expected {
+		t.Fatalf("Expected env to be %s but was %s", expected, envStr)
+	}
+}
+
+func TestMultiLetSimultaneous(t *testing.T) {
+	//let x = 30 in let x = minus(x, 1) y = minus(x, 2) in minus(x, y)
+	root := ast.LetExpression{
+		Name:  makeIdent("x"),
+		Value: makeInt(30),
+		In: &ast.MultiLetExpression{
+			Bindings: []*ast.LetBinding{
+				{Name: makeIdent("x"), Value: &ast.MinusExpression{Arg1: makeIdent("x"), Arg2: makeInt(1)}},
+				{Name: makeIdent("y"), Value: &ast.MinusExpression{Arg1: makeIdent("x"), Arg2: makeInt(2)}},
+			},
+			In: &ast.MinusExpression{Arg1: makeIdent("x"), Arg2: makeIdent("y")},
+		},
+	}
+	checkEvalResult(t, &root, ast.BindingList{}, 1)
+}
+
+func TestLetStarSequential(t *testing.T) {
+	//let x = 30 in let* x = minus(x, 1) y = minus(x, 2) in minus(x, y)
+	root := ast.LetExpression{
+		Name:  makeIdent("x"),
+		Value: makeInt(30),
+		In: &ast.LetStarExpression{
+			Bindings: []*ast.LetBinding{
+				{Name: makeIdent("x"), Value: &ast.MinusExpression{Arg1: makeIdent("x"), Arg2: makeInt(1)}},
+				{Name: makeIdent("y"), Value: &ast.MinusExpression{Arg1: makeIdent("x"), Arg2: makeInt(2)}},
+			},
+			In: &ast.MinusExpression{Arg1: makeIdent("x"), Arg2: makeIdent("y")},
+		},
+	}
+	checkEvalResult(t, &root, ast.BindingList{}, 2)
+}
+
+func TestLetStarEnvs(t *testing.T) {
+	root := ast.LetStarExpression{
+		Bindings: []*ast.LetBinding{
+			{Name: makeIdent("x"), Value: makeInt(1)},
+			{Name: makeIdent("y"), Value: makeIdent("x")},
+		},
+		In: makeIdent("y"),
+	}
+	checkEvalResult(t, &root, ast.BindingList{}, 1)
+	expectedEnvs := []string{"[< >]", "[< (x 1) >]", "[< (y 1) (x 1) >]"}
+	actualEnvs := []string{
+		ast.GetEnvStr(root.Bindings[0].Name.GetEnv()),
+		ast.GetEnvStr(root.Bindings[1].Name.GetEnv()),
+		ast.GetEnvStr(root.In.GetEnv()),
+	}
+	for i, expected := range expectedEnvs {
+		if actualEnvs[i] != expected {
+			t.Errorf("Expected env %d to be %s but was %s", i, expected, actualEnvs[i])
+		}
+	}
+}
+
+func TestMultiLetInvalidValue(t *testing.T) {
+	root := ast.MultiLetExpression{
+		Bindings: []*ast.LetBinding{
+			{Name: makeIdent("x"), Value: makeInt(1)},
+			{Name: makeIdent("y"), Value: makeIdent("x")},
+		},
+		In: makeIdent("y"),
+	}
+	_, err := evalExpression(&root, ast.BindingList{})
+	checkErrorResult(t, err, "Could not find variable name: x in env of")
+}
+
+func makePrimApp(name string, args ...ast.Expression) *ast.PrimAppExpression {
+	return &ast.PrimAppExpression{Name: name, Args: args}
+}
+
+func TestArithmeticPrimitives(t *testing.T) {
+	x := []struct {
+		name   string
+		arg1   int
+		arg2   int
+		result int
+	}{
+		{"plus", 3, 4, 7},
+		{"plus", -3, 3, 0},
+		{"times", 6, 7, 42},
+		{"times", -2, 5, -10},
+		{"quotient", 17, 5, 3},
+		{"quotient", -17, 5, -3},
+		{"remainder", 17, 5, 2},
+		{"remainder", -17, 5, -2},
+		{"minus", 4, 10, -6},
+	}
+	for _, tc := range x {
+		t.Run(fmt.Sprintf("%s(%d,%d)=%d", tc.name, tc.arg1, tc.arg2, tc.result), func(t *testing.T) {
+			expression := makePrimApp(tc.name, makeInt(tc.arg1), makeInt(tc.arg2))
+			checkEvalResult(t, expression, ast.BindingList{}, tc.result)
+		})
+	}
+}
+
+func TestComparisonPrimitives(t *testing.T) {
+	x := []struct {
+		name   string
+		arg1   int
+		arg2   int
+		result bool
+	}{
+		{"equal?", 3, 3, true},
+		{"equal?", 3, 4, false},
+		{"greater?", 4, 3, true},
+		{"greater?", 3, 3, false},
+		{"less?", 3, 4, true},
+		{"less?", 4, 3, false},
+		{"zero?", 0, 0, true},
+	}
+	for _, tc := range x {
+		t.Run(fmt.Sprintf("%s(%d,%d)=%t", tc.name, tc.arg1, tc.arg2, tc.result), func(t *testing.T) {
+			expression := makePrimApp(tc.name, makeInt(tc.arg1), makeInt(tc.arg2))
+			if tc.name == "zero?" {
+				expression.Args = expression.Args[:1]
+			}
+			checkBoolResult(t, expression, ast.BindingList{}, tc.result)
+		})
+	}
+}
+
+func TestDivisionByZero(t *testing.T) {
+	for _, name := range []string{"quotient", "remainder"} {
+		_, err := evalExpression(makePrimApp(name, makeInt(7), makeInt(0)), ast.BindingList{})
+		checkErrorResult(t, err, name+": division by zero")
+		if _, ok := err.(*ast.DivisionByZeroError); !ok {
+			t.Fatalf("Expected error to be %T but was %T", &ast.DivisionByZeroError{}, err)
+		}
+	}
+}
+
+func TestPrimitiveTypeError(t *testing.T) {
+	_, err := evalExpression(makePrimApp("plus", makeInt(7), makeBool(true)), ast.BindingList{})
+	checkErrorResult(t, err, "plus expects numbers, got bool")
+}
+
+func TestPrimitiveWrongArgCount(t *testing.T) {
+	_, err := evalExpression(makePrimApp("plus", makeInt(7)), ast.BindingList{})
+	checkErrorResult(t, err, "plus expects 2 argument(s), got 1")
+}
+
+func TestFactorial(t *testing.T) {
+	//letrec fact(n) = if zero?(n) then 1 else times(n, (fact minus(n, 1))) in (fact 10)
+	root := ast.LetrecExpression{
+		Procs: []*ast.LetrecProc{
+			{
+				Name:   makeIdent("fact"),
+				Params: []*ast.Identifier{makeIdent("n")},
+				Body: &ast.IfThenElseExpression{
+					Value:      makePrimApp("zero?", makeIdent("n")),
+					TrueBranch: makeInt(1),
+					FalseBranch: makePrimApp("times", makeIdent("n"), &ast.CallExpression{
+						Operator: makeIdent("fact"),
+						Operands: []ast.Expression{&ast.MinusExpression{Arg1: makeIdent("n"), Arg2: makeInt(1)}},
+					}),
+				},
+			},
+		},
+		In: &ast.CallExpression{Operator: makeIdent("fact"), Operands: []ast.Expression{makeInt(10)}},
+	}
+	checkEvalResult(t, &root, ast.BindingList{}, 3628800)
+}
+
+func checkStrResult(t *testing.T, expression ast.Expression, env ast.BindingList, expected string) {
+	result, err := evalExpression(expression, env)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if result.String() != expected {
+		t.Errorf("Expected result to be %s but was %s", expected, result)
+	}
+}
+
+func TestListPrimitives(t *testing.T) {
+	emptyList := &ast.EmptyListLiteral{}
+	x := []struct {
+		expression ast.Expression
+		result     string
+	}{
+		{emptyList, "()"},
+		{makePrimApp("list"), "()"},
+		{makePrimApp("list", makeInt(1), makeInt(2), makeInt(3)), "(1 2 3)"},
+		{makePrimApp("cons", makeInt(1), emptyList), "(1)"},
+		{makePrimApp("cons", makeInt(1), makeInt(2)), "(1 . 2)"},
+		{makePrimApp("cons", makeInt(1), makePrimApp("cons", makeBool(true), makeInt(2))), "(1 true . 2)"},
+		{makePrimApp("list", makeInt(1), makePrimApp("list", makeInt(2)), emptyList), "(1 (2) ())"},
+		{makePrimApp("car", makePrimApp("list", makeInt(4), makeInt(5))), "4"},
+		{makePrimApp("cdr", makePrimApp("list", makeInt(4), makeInt(5))), "(5)"},
+		{makePrimApp("null?", emptyList), "true"},
+		{makePrimApp("null?", makePrimApp("list", makeInt(4))), "false"},
+		{makePrimApp("null?", makeInt(4)), "false"},
+	}
+	for _, tc := range x {
+		checkStrResult(t, tc.expression, ast.BindingList{}, tc.result)
+	}
+}
+
+func TestCarCdrErrors(t *testing.T) {
+	for _, name := range []string{"car", "cdr"} {
+		_, err := evalExpression(makePrimApp(name, &ast.EmptyListLiteral{}), ast.BindingList{})
+		checkErrorResult(t, err, name+" expects a non-empty list, got emptylist")
+		_, err = evalExpression(makePrimApp(name, makeInt(3)), ast.BindingList{})
+		checkErrorResult(t, err, name+" expects a non-empty list, got number")
+	}
+}
+
+func TestListSum(t *testing.T) {
+	//letrec sum(l) = if null?(l) then 0 else plus(car(l), (sum cdr(l))) in (sum list(1, 2, 3, 4))
+	root := ast.LetrecExpression{
+		Procs: []*ast.LetrecProc{
+			{
+				Name:   makeIdent("sum"),
+				Params: []*ast.Identifier{makeIdent("l")},
+				Body: &ast.IfThenElseExpression{
+					Value:      makePrimApp("null?", makeIdent("l")),
+					TrueBranch: makeInt(0),
+					FalseBranch: makePrimApp("plus", makePrimApp("car", makeIdent("l")), &ast.CallExpression{
+						Operator: makeIdent("sum"),
+						Operands: []ast.Expression{makePrimApp("cdr", makeIdent("l"))},
+					}),
+				},
+			},
+		},
+		In: &ast.CallExpression{
+			Operator: makeIdent("sum"),
+			Operands: []ast.Expression{makePrimApp("list", makeInt(1), makeInt(2), makeInt(3), makeInt(4))},
+		},
+	}
+	checkEvalResult(t, &root, ast.BindingList{}, 10)
+}
+
+func TestExplicitRefs(t *testing.T) {
+	//let x = newref(22) in let f = proc (z) let zz = newref(minus(z, deref(x))) in deref(zz)
+	//in minus((f 66), (f 55))
+	root := ast.LetExpression{
+		Name:  makeIdent("x"),
+		Value: makePrimApp("newref", makeInt(22)),
+		In: &ast.LetExpression{
+			Name: makeIdent("f"),
+			Value: &ast.ProcExpression{
+				Params: []*ast.Identifier{makeIdent("z")},
+				Body: &ast.LetExpression{
+					Name:  makeIdent("zz"),
+					Value: makePrimApp("newref", &ast.MinusExpression{Arg1: makeIdent("z"), Arg2: makePrimApp("deref", makeIdent("x"))}),
+					In:    makePrimApp("deref", makeIdent("zz")),
+				},
+			},
+			In: &ast.MinusExpression{
+				Arg1: &ast.CallExpression{Operator: makeIdent("f"), Operands: []ast.Expression{makeInt(66)}},
+				Arg2: &ast.CallExpression{Operator: makeIdent("f"), Operands: []ast.Expression{makeInt(55)}},
+			},
+		},
+	}
+	checkEvalResult(t, &root, ast.BindingList{}, 11)
+}
+
+func TestSetrefAndBegin(t *testing.T) {
+	//let counter = newref(0) in let inc = proc () setref(counter, plus(deref(counter), 1))
+	//in begin (inc); (inc); (inc); deref(counter) end
+	inc := &ast.CallExpression{Operator: makeIdent("inc")}
+	root := ast.LetExpression{
+		Name:  makeIdent("counter"),
+		Value: makePrimApp("newref", makeInt(0)),
+		In: &ast.LetExpression{
+			Name: makeIdent("inc"),
+			Value: &ast.ProcExpression{
+				Body: makePrimApp("setref", makeIdent("counter"),
+					makePrimApp("plus", makePrimApp("deref", makeIdent("counter")), makeInt(1))),
+			},
+			In: &ast.BeginExpression{
+				Exprs: []ast.Expression{inc, inc, inc, makePrimApp("deref", makeIdent("counter"))},
+			},
+		},
+	}
+	rt := ast.NewRuntime()
+	result, err := EvalProgram(&root, rt)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if result != (ast.NumVal{Value: 3}) {
+		t.Fatalf("Expected result to be 3 but was %s", result)
+	}
+	if rt.Store.String() != "[0: 3]" {
+		t.Fatalf("Expected store to be [0: 3] but was %s", rt.Store)
+	}
+}
+
+func TestRefErrors(t *testing.T) {
+	_, err := evalExpression(makePrimApp("deref", makeInt(0)), ast.BindingList{})
+	checkErrorResult(t, err, "deref expects a reference, got number")
+	_, err = evalExpression(makePrimApp("setref", makeBool(true), makeInt(0)), ast.BindingList{})
+	checkErrorResult(t, err, "setref expects a reference, got bool")
+	_, err = evalExpression(makePrimApp("deref", makeIdent("r")), ast.BindingList{{VarName: "r", Value: ast.RefVal{Location: 4}}})
+	checkErrorResult(t, err, "Invalid reference: 4")
+}
+
+func TestBeginStopsOnError(t *testing.T) {
+	root := ast.BeginExpression{
+		Exprs: []ast.Expression{makeInt(1), makeIdent("x"), makeInt(2)},
+	}
+	_, err := evalExpression(&root, ast.BindingList{})
+	checkErrorResult(t, err, "Could not find variable name: x in env of")
+}
+
+func evalImplicit(expression ast.Expression) (ast.ExpVal, error) {
+	rt := ast.NewRuntime()
+	rt.ImplicitRefs = true
+	cpsRt := ast.NewRuntime()
+	cpsRt.ImplicitRefs = true
+	return bothEvaluators(expression, rt, cpsRt)
+}
+
+//bothEvaluators runs a program with EvalProgram, EvalProgramCPS and the VM, and reports an error
+//if they disagree. The VM runs with the same settings as cpsRt.
+func bothEvaluators(expression ast.Node, rt *ast.Runtime, cpsRt *ast.Runtime) (ast.ExpVal, error) {
+	vmRt := ast.NewRuntime()
+	vmRt.ImplicitRefs = cpsRt.ImplicitRefs
+	vmRt.Bignums = cpsRt.Bignums
+	vmRt.CheckOverflow = cpsRt.CheckOverflow
+	vmRt.Rationals = cpsRt.Rationals
+	vmRt.Scheduler = ast.NewScheduler(cpsRt.Scheduler.TimeSlice)
+	cpsResult, cpsErr := EvalProgramCPS(expression, cpsRt)
+	vmResult, vmErr := vm.EvalProgram(expression, vmRt)
+	result, err := EvalProgram(expression, rt)
+	if fmt.Sprint(result, err) != fmt.Sprint(cpsResult, cpsErr) {
+		return nil, fmt.Errorf("Evaluators disagree, Eval gave %v (error %v) but CPS gave %v (error %v)",
+			result, err, cpsResult, cpsErr)
+	}
+	if fmt.Sprint(result, err) != fmt.Sprint(vmResult, vmErr) {
+		return nil, fmt.Errorf("Evaluators disagree, Eval gave %v (error %v) but the VM gave %v (error %v)",
+			result, err, vmResult, vmErr)
+	}
+	if rt.Store.String() != cpsRt.Store.String() {
+		return nil, fmt.Errorf("Evaluators disagree on the store, Eval left %s but CPS left %s", rt.Store, cpsRt.Store)
+	}
+	if rt.Store.String() != vmRt.Store.String() {
+		return nil, fmt.Errorf("Evaluators disagree on the store, Eval left %s but the VM left %s", rt.Store, vmRt.Store)
+	}
+	return result, err
+}
+
+func TestImplicitRefsPureProgramUnchanged(t *testing.T) {
+	root := makeOddEven()
+	root.In = &ast.LetStarExpression{
+		Bindings: []*ast.LetBinding{
+			{Name: makeIdent("x"), Value: makeInt(7)},
+			{Name: makeIdent("y"), Value: &ast.MinusExpression{Arg1: makeIdent("x"), Arg2: makeInt(2)}},
+		},
+		In: &ast.CallExpression{Operator: makeIdent("odd"), Operands: []ast.Expression{makeIdent("y")}},
+	}
+	result, err := evalImplicit(root)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if result != (ast.BoolVal{Value: true}) {
+		t.Fatalf("Expected result to be true but was %s", result)
+	}
+}
+
+func TestImplicitRefsSet(t *testing.T) {
+	//let x = 0 in letrec even(d) = if zero?(x) then 1 else begin set x = minus(x, 1); (odd 888) end
+	//                     odd(d) = if zero?(x) then 0 else begin set x = minus(x, 1); (even 888) end
+	//in begin set x = 13; (odd 888) end
+	makeBranch := func(result int, other string) ast.Expression {
+		return &ast.IfThenElseExpression{
+			Value:      makePrimApp("zero?", makeIdent("x")),
+			TrueBranch: makeInt(result),
+			FalseBranch: &ast.BeginExpression{Exprs: []ast.Expression{
+				&ast.SetExpression{Name: makeIdent("x"), Value: &ast.MinusExpression{Arg1: makeIdent("x"), Arg2: makeInt(1)}},
+				&ast.CallExpression{Operator: makeIdent(other), Operands: []ast.Expression{makeInt(888)}},
+			}},
+		}
+	}
+	root := ast.LetExpression{
+		Name:  makeIdent("x"),
+		Value: makeInt(0),
+		In: &ast.LetrecExpression{
+			Procs: []*ast.LetrecProc{
+				{Name: makeIdent("even"), Params: []*ast.Identifier{makeIdent("d")}, Body: makeBranch(1, "odd")},
+				{Name: makeIdent("odd"), Params: []*ast.Identifier{makeIdent("d")}, Body: makeBranch(0, "even")},
+			},
+			In: &ast.BeginExpression{Exprs: []ast.Expression{
+				&ast.SetExpression{Name: makeIdent("x"), Value: makeInt(13)},
+				&ast.CallExpression{Operator: makeIdent("odd"), Operands: []ast.Expression{makeInt(888)}},
+			}},
+		},
+	}
+	result, err := evalImplicit(&root)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if result != (ast.NumVal{Value: 1}) {
+		t.Fatalf("Expected result to be 1 but was %s", result)
+	}
+}
+
+func TestImplicitRefsClosureSharesLocation(t *testing.T) {
+	//let x = 1 in let f = proc () x in begin set x = 5; (f) end
+	root := ast.LetExpression{
+		Name:  makeIdent("x"),
+		Value: makeInt(1),
+		In: &ast.LetExpression{
+			Name:  makeIdent("f"),
+			Value: &ast.ProcExpression{Body: makeIdent("x")},
+			In: &ast.BeginExpression{Exprs: []ast.Expression{
+				&ast.SetExpression{Name: makeIdent("x"), Value: makeInt(5)},
+				&ast.CallExpression{Operator: makeIdent("f")},
+			}},
+		},
+	}
+	result, err := evalImplicit(&root)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if result != (ast.NumVal{Value: 5}) {
+		t.Fatalf("Expected result to be 5 but was %s", result)
+	}
+}
+
+func TestSetRequiresImplicitRefs(t *testing.T) {
+	root := ast.LetExpression{
+		Name:  makeIdent("x"),
+		Value: makeInt(1),
+		In:    &ast.SetExpression{Name: makeIdent("x"), Value: makeInt(5)},
+	}
+	_, err := evalExpression(&root, ast.BindingList{})
+	checkErrorResult(t, err, "set requires implicit references")
+}
+
+func TestSetLetrecName(t *testing.T) {
+	root := makeOddEven()
+	root.In = &ast.SetExpression{Name: makeIdent("odd"), Value: makeInt(5)}
+	_, err := evalImplicit(root)
+	checkErrorResult(t, err, "Cannot set odd, it is not an assignable variable")
+}
+
+func TestTryCatchesRaise(t *testing.T) {
+	//try minus(1, raise 99) catch (e) minus(e, 1)
+	root := ast.TryExpression{
+		Body:    &ast.MinusExpression{Arg1: makeInt(1), Arg2: &ast.RaiseExpression{Value: makeInt(99)}},
+		Var:     makeIdent("e"),
+		Handler: &ast.MinusExpression{Arg1: makeIdent("e"), Arg2: makeInt(1)},
+	}
+	checkEvalResult(t, &root, ast.BindingList{}, 98)
+}
+
+func TestTryWithoutRaise(t *testing.T) {
+	root := ast.TryExpression{
+		Body:    makeInt(5),
+		Var:     makeIdent("e"),
+		Handler: makeInt(0),
+	}
+	checkEvalResult(t, &root, ast.BindingList{}, 5)
+}
+
+func TestRaiseThroughProcedure(t *testing.T) {
+	//let f = proc (x) if zero?(x) then raise list(x) else x in try (f 0) catch (e) car(e)
+	root := ast.LetExpression{
+		Name: makeIdent("f"),
+		Value: &ast.ProcExpression{
+			Params: []*ast.Identifier{makeIdent("x")},
+			Body: &ast.IfThenElseExpression{
+				Value:       makePrimApp("zero?", makeIdent("x")),
+				TrueBranch:  &ast.RaiseExpression{Value: makePrimApp("list", makeIdent("x"))},
+				FalseBranch: makeIdent("x"),
+			},
+		},
+		In: &ast.TryExpression{
+			Body:    &ast.CallExpression{Operator: makeIdent("f"), Operands: []ast.Expression{makeInt(0)}},
+			Var:     makeIdent("e"),
+			Handler: makePrimApp("car", makeIdent("e")),
+		},
+	}
+	checkEvalResult(t, &root, ast.BindingList{}, 0)
+}
+
+func TestNestedTryReraise(t *testing.T) {
+	//try try raise 1 catch (e) raise minus(e, 10) catch (e) e
+	root := ast.TryExpression{
+		Body: &ast.TryExpression{
+			Body:    &ast.RaiseExpression{Value: makeInt(1)},
+			Var:     makeIdent("e"),
+			Handler: &ast.RaiseExpression{Value: &ast.MinusExpression{Arg1: makeIdent("e"), Arg2: makeInt(10)}},
+		},
+		Var:     makeIdent("e"),
+		Handler: makeIdent("e"),
+	}
+	checkEvalResult(t, &root, ast.BindingList{}, -9)
+}
+
+func TestUncaughtRaise(t *testing.T) {
+	root := ast.RaiseExpression{Value: makeBool(true)}
+	_, err := EvalProgram(&root, ast.NewRuntime())
+	checkErrorResult(t, err, "uncaught exception: true")
+	exception, ok := err.(*ast.RaisedException)
+	if !ok {
+		t.Fatalf("Expected error to be %T but was %T", &ast.RaisedException{}, err)
+	}
+	if exception.Value != (ast.BoolVal{Value: true}) {
+		t.Fatalf("Expected raised value to be true but was %s", exception.Value)
+	}
+}
+
+func TestTryDoesNotCatchRuntimeErrors(t *testing.T) {
+	root := ast.TryExpression{
+		Body:    makeIdent("x"),
+		Var:     makeIdent("e"),
+		Handler: makeInt(0),
+	}
+	_, err := evalExpression(&root, ast.BindingList{})
+	checkErrorResult(t, err, "Could not find variable name: x in env of")
+}
+
+func parseSource(t *testing.T, input string) ast.Expression {
+	lxr := lexer.New(input)
+	tokens := []token.Token{}
+	for tok := lxr.NextToken(); tok.Type != token.EOF; tok = lxr.NextToken() {
+		tokens = append(tokens, tok)
+	}
+	tokens = append(tokens, token.Token{Type: token.EOF, Literal: ""})
+	prs := parser.New(tokens)
+	expr := prs.ParseExpression()
+	if len(prs.Errors()) != 0 {
+		t.Fatalf("Could not parse %q: %s", input, strings.Join(prs.Errors(), "; "))
+	}
+	return expr
+}
+
+func evalThreads(t *testing.T, input string, timeSlice int) (ast.ExpVal, error) {
+	rt := ast.NewRuntime()
+	rt.Scheduler = ast.NewScheduler(timeSlice)
+	cpsRt := ast.NewRuntime()
+	cpsRt.Scheduler = ast.NewScheduler(timeSlice)
+	return bothEvaluators(parseSource(t, input), rt, cpsRt)
+}
+
+//Each thread conses its tag onto a shared list three times under a lock, the main thread reads
+//the list after a mutex handoff from the last worker.
+const interleaveProgram = `
+let log = newref(emptylist)
+in let lock = mutex()
+in let done = mutex()
+in let worker = proc (tag) letrec loop(n) = if zero?(n) then 0
+                                          else begin
+                                                 wait(lock); setref(log, cons(tag, deref(log))); signal(lock);
+                                                 (loop minus(n, 1))
+                                               end
+                           in (loop 3)
+in begin
+     wait(done);
+     spawn(proc (d) (worker 1));
+     spawn(proc (d) begin (worker 2); signal(done) end);
+     (worker 0);
+     wait(done);
+     deref(log)
+   end`
+
+func TestThreadsInterleaving(t *testing.T) {
+	result, err := evalThreads(t, interleaveProgram, 1000)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if result.String() != "(2 2 2 1 1 1 0 0 0)" {
+		t.Fatalf("Expected threads to run one after another but got %s", result)
+	}
+	result, err = evalThreads(t, interleaveProgram, 3)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if result.String() != "(2 0 1 2 0 1 2 0 1)" {
+		t.Fatalf("Expected threads to interleave but got %s", result)
+	}
+	//The same time slice always gives the same interleaving.
+	again, _ := evalThreads(t, interleaveProgram, 3)
+	if again.String() != result.String() {
+		t.Fatalf("Expected %s on a second run but got %s", result, again)
+	}
+}
+
+func TestThreadsYield(t *testing.T) {
+	input := `
+let log = newref(emptylist)
+in let note = proc (x) setref(log, cons(x, deref(log)))
+in begin
+     spawn(proc (d) begin (note 1); yield(); (note 3) end);
+     yield();
+     (note 2);
+     yield();
+     deref(log)
+   end`
+	result, err := evalThreads(t, input, 1000)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if result.String() != "(3 2 1)" {
+		t.Fatalf("Expected (3 2 1) but got %s", result)
+	}
+}
+
+func TestThreadsRunAfterMainFinishes(t *testing.T) {
+	input := `
+let cell = newref(0)
+in begin spawn(proc (d) setref(cell, 1)); cell end`
+	rt := ast.NewRuntime()
+	result, err := EvalProgram(parseSource(t, input), rt)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	ref := result.(ast.RefVal)
+	if val, _ := rt.Store.Deref(ref); val != (ast.NumVal{Value: 1}) {
+		t.Fatalf("Expected spawned thread to set the cell to 1 but got %s", val)
+	}
+}
+
+func TestThreadsDeadlock(t *testing.T) {
+	input := `let m = mutex() in begin wait(m); spawn(proc (d) wait(m)); wait(m) end`
+	_, err := evalThreads(t, input, 10)
+	checkErrorResult(t, err, "deadlock")
+}
+
+func TestThreadErrorStopsProgram(t *testing.T) {
+	input := `
+letrec spin(n) = (spin n)
+in begin spawn(proc (d) car(emptylist)); (spin 0) end`
+	_, err := evalThreads(t, input, 5)
+	checkErrorResult(t, err, "car expects a non-empty list")
+}
+
+func TestThreadPrimitiveTypeErrors(t *testing.T) {
+	_, err := evalThreads(t, "spawn(1)", 10)
+	checkErrorResult(t, err, "spawn expects a procedure")
+	_, err = evalThreads(t, "wait(1)", 10)
+	checkErrorResult(t, err, "wait expects a mutex")
+}
+
+func TestCPSDeepRecursion(t *testing.T) {
+	//Not a tail call, every level waits on the next one, so Eval would need Go stack frames
+	//for each level. With a 1MB stack limit Eval overflows, the CPS evaluator must not.
+	input := `letrec sum(n) = if zero?(n) then 0 else plus(n, (sum minus(n, 1))) in (sum 100000)`
+	expr := parseSource(t, input)
+	defer debug.SetMaxStack(debug.SetMaxStack(1 << 20))
+	result, err := EvalProgramCPS(expr, ast.NewRuntime())
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if result != (ast.NumVal{Value: 5000050000}) {
+		t.Fatalf("Expected result to be 5000050000 but was %s", result)
+	}
+}
+
+func TestCPSDeepNesting(t *testing.T) {
+	//minus(minus(...minus(0, x)..., x), x) nested 100000 deep
+	var expr ast.Expression = makeInt(0)
+	for i := 0; i < 100000; i++ {
+		expr = &ast.MinusExpression{Arg1: expr, Arg2: makeIdent("x")}
+	}
+	expr = &ast.LetExpression{Name: makeIdent("x"), Value: makeInt(1), In: expr}
+	result, err := EvalProgramCPS(expr, ast.NewRuntime())
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if result != (ast.NumVal{Value: -100000}) {
+		t.Fatalf("Expected result to be -100000 but was %s", result)
+	}
+}
+
+func TestCPSRaiseUnwindsContinuations(t *testing.T) {
+	input := `
+letrec find(n) = if zero?(n) then raise 42 else plus(1, (find minus(n, 1)))
+in try (find 10000) catch (e) minus(e, 2)`
+	result, err := bothEvaluators(parseSource(t, input), ast.NewRuntime(), ast.NewRuntime())
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if result != (ast.NumVal{Value: 40}) {
+		t.Fatalf("Expected result to be 40 but was %s", result)
+	}
+}
+
+func TestLetccEscape(t *testing.T) {
+	//let x = 5 in let y = letcc k in minus(x, throw 3 to k) in minus(x, y)
+	root := ast.LetExpression{
+		Name:  makeIdent("x"),
+		Value: makeInt(5),
+		In: &ast.LetExpression{
+			Name: makeIdent("y"),
+			Value: &ast.LetccExpression{
+				Name: makeIdent("k"),
+				Body: &ast.MinusExpression{
+					Arg1: makeIdent("x"),
+					Arg2: &ast.ThrowExpression{Value: makeInt(3), Cont: makeIdent("k")},
+				},
+			},
+			In: &ast.MinusExpression{Arg1: makeIdent("x"), Arg2: makeIdent("y")},
+		},
+	}
+	checkEvalResult(t, &root, ast.BindingList{}, 2)
+}
+
+func TestLetccWithoutThrow(t *testing.T) {
+	//let x = 5 in let y = letcc k in minus(x, 1) in minus(x, y)
+	root := ast.LetExpression{
+		Name:  makeIdent("x"),
+		Value: makeInt(5),
+		In: &ast.LetExpression{
+			Name: makeIdent("y"),
+			Value: &ast.LetccExpression{
+				Name: makeIdent("k"),
+				Body: &ast.MinusExpression{Arg1: makeIdent("x"), Arg2: makeInt(1)},
+			},
+			In: &ast.MinusExpression{Arg1: makeIdent("x"), Arg2: makeIdent("y")},
+		},
+	}
+	checkEvalResult(t, &root, ast.BindingList{}, 1)
+}
+
+func TestLetccEscapesNestedLets(t *testing.T) {
+	//letcc outer in let x = 1 in let y = letcc inner in throw x to outer in 100
+	root := ast.LetccExpression{
+		Name: makeIdent("outer"),
+		Body: &ast.LetExpression{
+			Name:  makeIdent("x"),
+			Value: makeInt(1),
+			In: &ast.LetExpression{
+				Name: makeIdent("y"),
+				Value: &ast.LetccExpression{
+					Name: makeIdent("inner"),
+					Body: &ast.ThrowExpression{Value: makeIdent("x"), Cont: makeIdent("outer")},
+				},
+				In: makeInt(100),
+			},
+		},
+	}
+	checkEvalResult(t, &root, ast.BindingList{}, 1)
+}
+
+func TestLetccEarlyExitFromRecursion(t *testing.T) {
+	//Multiply a list, jumping straight out with 0 when a 0 is found.
+	input := `
+letcc done
+in letrec product(l) = if null?(l) then 1
+                       else if zero?(car(l)) then throw 0 to done
+                       else times(car(l), (product cdr(l)))
+   in (product list(1, 2, 0, 4))`
+	checkEvalResult(t, parseSource(t, input), ast.BindingList{}, 0)
+}
+
+func TestLetccThrowThroughTry(t *testing.T) {
+	input := `let x = letcc k in try throw 7 to k catch (e) 0 in minus(x, 1)`
+	checkEvalResult(t, parseSource(t, input), ast.BindingList{}, 6)
+}
+
+func TestContinuationString(t *testing.T) {
+	checkStrResult(t, parseSource(t, `letcc k in k`), ast.BindingList{}, "<continuation k>")
+}
+
+func TestThrowErrors(t *testing.T) {
+	_, err := evalExpression(parseSource(t, `throw 1 to 2`), ast.BindingList{})
+	checkErrorResult(t, err, "throw expects a continuation, got number")
+}
+
+//Throwing to k after its letcc returned runs the rest of the program again with a new x.
+const reenterProgram = `
+let saved = newref(0)
+in let count = newref(0)
+in let x = letcc k in begin setref(saved, k); 0 end
+in begin
+     setref(count, plus(deref(count), 1));
+     if less?(x, 3) then throw plus(x, 1) to deref(saved) else list(x, deref(count))
+   end`
+
+func TestLetccReenter(t *testing.T) {
+	result, err := EvalProgramCPS(parseSource(t, reenterProgram), ast.NewRuntime())
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if result.String() != "(3 4)" {
+		t.Fatalf("Expected result to be (3 4) but was %s", result)
+	}
+}
+
+func TestLetccReenterNeedsCPS(t *testing.T) {
+	_, err := EvalProgram(parseSource(t, reenterProgram), ast.NewRuntime())
+	checkErrorResult(t, err, "run with --backend cps")
+}
+
+func TestLetccGenerator(t *testing.T) {
+	//The generator hands back each element by throwing to the caller's continuation, and keeps
+	//its own continuation so the next call resumes where it left off.
+	input := `
+let resume = newref(0)
+in let return = newref(0)
+in let gen = proc (l) letrec walk(l) = if null?(l) then throw emptylist to deref(return)
+                                       else begin
+                                              letcc k in begin setref(resume, k); throw car(l) to deref(return) end;
+                                              (walk cdr(l))
+                                            end
+                      in (walk l)
+in let next = proc (d) letcc k in begin setref(return, k); throw 0 to deref(resume) end
+in let first = letcc k in begin setref(return, k); (gen list(10, 20, 30)) end
+in let second = (next 0)
+in let third = (next 0)
+in let fourth = (next 0)
+in list(first, second, third, fourth)`
+	result, err := EvalProgramCPS(parseSource(t, input), ast.NewRuntime())
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if result.String() != "(10 20 30 ())" {
+		t.Fatalf("Expected result to be (10 20 30 ()) but was %s", result)
+	}
+}
+
+func parseProgramSource(t *testing.T, input string) *ast.Program {
+	lxr := lexer.New(input)
+	tokens := []token.Token{}
+	for tok := lxr.NextToken(); tok.Type != token.EOF; tok = lxr.NextToken() {
+		tokens = append(tokens, tok)
+	}
+	tokens = append(tokens, token.Token{Type: token.EOF, Literal: ""})
+	prs := parser.New(tokens)
+	program := prs.ParseProgram()
+	if len(prs.Errors()) != 0 {
+		t.Fatalf("Could not parse %q: %s", input, strings.Join(prs.Errors(), "; "))
+	}
+	return program
+}
+
+func evalModules(t *testing.T, input string) (ast.ExpVal, error) {
+	return bothEvaluators(parseProgramSource(t, input), ast.NewRuntime(), ast.NewRuntime())
+}
+
+func TestModules(t *testing.T) {
+	result, err := evalModules(t, `
+module m1
+  interface [a : int  inc : (int -> int)]
+  body [a = 33
+        b = 44
+        inc = proc (x) minus(x, -1)]
+module m2
+  interface [c : int]
+  body [c = (from m1 take inc  minus(from m1 take a, b))]
+c`)
+	checkErrorResult(t, err, "Could not find variable name: b")
+
+	result, err = evalModules(t, `
+module m1
+  interface [a : int  inc : (int -> int)]
+  body [a = 33
+        b = 44
+        inc = proc (x) minus(x, minus(0, 1))
+        a = (inc b)]
+module m2
+  interface [c : int]
+  body [c = (from m1 take inc  from m1 take a)]
+minus(from m2 take c, from m1 take a)`)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if result != (ast.NumVal{Value: 1}) {
+		t.Fatalf("Expected result to be 1 but was %v", result)
+	}
+}
+
+func TestModuleErrors(t *testing.T) {
+	_, err := evalModules(t, "module m interface [a : int] body [b = 1] 0")
+	checkErrorResult(t, err, "Module m does not define a declared in its interface")
+	_, err = evalModules(t, "module m interface [a : int] body [a = 1 b = 2] from m take b")
+	checkErrorResult(t, err, "Module m does not export b")
+	_, err = evalModules(t, "from m take a")
+	checkErrorResult(t, err, "Unknown module: m")
+	_, err = evalModules(t, "module m interface [] body [] module m interface [] body [] 0")
+	checkErrorResult(t, err, "Module m is already defined")
+}
+
+const classesProgram = `
+class c1 extends object
+  field i
+  field j
+  method initialize (x) begin set i = x; set j = minus(0, x) end
+  method countup (d) begin set i = i + d; set j = j - d end
+  method getstate () list(i, j)
+class c2 extends c1
+  field k
+  method initialize (x) begin super initialize(x); set k = x * 10 end
+  method getstate () cons(k, super getstate())
+`
+
+func evalClasses(t *testing.T, input string) (ast.ExpVal, *ast.Runtime, error) {
+	rt := ast.NewRuntime()
+	rt.ImplicitRefs = true
+	cpsRt := ast.NewRuntime()
+	cpsRt.ImplicitRefs = true
+	result, err := bothEvaluators(parseProgramSource(t, classesProgram+input), rt, cpsRt)
+	return result, rt, err
+}
+
+func TestClasses(t *testing.T) {
+	result, _, err := evalClasses(t, `
+let o1 = new c1(3)
+in let t1 = send o1 getstate()
+in begin send o1 countup(2); list(t1, send o1 getstate()) end`)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if result.String() != "((3 -3) (5 -5))" {
+		t.Errorf("Expected result to be ((3 -3) (5 -5)) but was %s", result)
+	}
+}
+
+func TestClassesInheritance(t *testing.T) {
+	//countup is inherited from c1, getstate is overridden and calls c1's with super.
+	result, rt, err := evalClasses(t, "let o2 = new c2(4) in begin send o2 countup(1); list(send o2 getstate(), o2) end")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	expected := "((40 5 -5) <object c2 (i 5) (j -5) (k 40)>)"
+	if rt.Show(result) != expected {
+		t.Errorf("Expected result to be %s but was %s", expected, rt.Show(result))
+	}
+}
+
+func TestClassesSelf(t *testing.T) {
+	result, _, err := evalClasses(t, `
+class c3 extends c2
+  method getstate () 0
+  method both () list(send self getstate(), super getstate())
+send new c3(1) both()`)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if result.String() != "(0 (10 1 -1))" {
+		t.Errorf("Expected result to be (0 (10 1 -1)) but was %s", result)
+	}
+}
+
+func TestClassesShadowedField(t *testing.T) {
+	//c4's i shadows c1's in c4's methods, c1's methods still see their own i.
+	result, _, err := evalClasses(t, `
+class c4 extends c1
+  field i
+  method seti (x) set i = x
+  method geti () i
+let o = new c4(3) in begin send o seti(7); list(send o geti(), send o getstate()) end`)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if result.String() != "(7 (3 -3))" {
+		t.Errorf("Expected result to be (7 (3 -3)) but was %s", result)
+	}
+}
+
+func TestClassesRequireImplicitRefs(t *testing.T) {
+	_, err := EvalProgram(parseProgramSource(t, classesProgram+"1"), ast.NewRuntime())
+	if err != ast.ErrClassesWithoutImplicitRefs {
+		t.Fatalf("Expected classes without implicit refs to fail but got %v", err)
+	}
+	_, err = EvalProgramCPS(parseProgramSource(t, classesProgram+"1"), ast.NewRuntime())
+	if err != ast.ErrClassesWithoutImplicitRefs {
+		t.Fatalf("Expected classes without implicit refs to fail with CPS but got %v", err)
+	}
+}
+
+func TestClassesMalformedBindings(t *testing.T) {
+	rt := ast.NewRuntime()
+	rt.ImplicitRefs = true
+	obj, err := rt.NewObject("object")
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	//self is found through the location it is bound to.
+	self, err := rt.Self(ast.BindingList{{VarName: "%self", Value: rt.Store.NewRef(obj)}})
+	if err != nil || self != obj {
+		t.Fatalf("Expected self to be found through a reference but got %v (error %v)", self, err)
+	}
+	_, err = rt.Self(ast.BindingList{{VarName: "%self", Value: ast.NumVal{Value: 1}}})
+	checkErrorResult(t, err, "self expects an object, got number")
+	_, err = rt.SuperMethodProc(ast.BindingList{
+		{VarName: "%self", Value: obj},
+		{VarName: "%super", Value: ast.NumVal{Value: 1}},
+	}, "m")
+	checkErrorResult(t, err, "super expects a class, got number")
+}
+
+func TestClassesErrors(t *testing.T) {
+	_, _, err := evalClasses(t, "send new c1(1) missing()")
+	checkErrorResult(t, err, "Method missing not found in class c1")
+	_, _, err = evalClasses(t, "new c5(1)")
+	checkErrorResult(t, err, "Unknown class: c5")
+	_, _, err = evalClasses(t, "send 5 getstate()")
+	checkErrorResult(t, err, "send expects an object, got number")
+	_, _, err = evalClasses(t, "self")
+	checkErrorResult(t, err, "self and super can only be used inside a method")
+	_, _, err = evalClasses(t, "class c5 extends object new c5(1)")
+	checkErrorResult(t, err, "Class c5 has no initialize method, but new was given 1 argument(s)")
+	_, _, err = evalClasses(t, "class c5 extends c6 0")
+	checkErrorResult(t, err, "Class c5 extends unknown class c6")
+	_, _, err = evalClasses(t, "class c1 extends object 0")
+	checkErrorResult(t, err, "Class c1 is already defined")
+	_, _, err = evalClasses(t, "class c5 extends object method m () super m() send new c5() m()")
+	checkErrorResult(t, err, "Method m not found in class object")
+}
+
+//evalWith runs input with every evaluator, on runtimes that configure has set up.
+func evalWith(t *testing.T, input string, configure func(*ast.Runtime)) (ast.ExpVal, error) {
+	rt := ast.NewRuntime()
+	configure(rt)
+	cpsRt := ast.NewRuntime()
+	configure(cpsRt)
+	return bothEvaluators(parseProgramSource(t, input), rt, cpsRt)
+}
+
+type resultCase struct {
+	input    string
+	expected string
+}
+
+func checkResults(t *testing.T, cases []resultCase, configure func(*ast.Runtime)) {
+	for _, tc := range cases {
+		result, err := evalWith(t, tc.input, configure)
+		if err != nil {
+			t.Fatalf("Could not evaluate %q: %s", tc.input, err)
+		}
+		if result.String() != tc.expected {
+			t.Errorf("Expected %q to be %s but was %s", tc.input, tc.expected, result)
+		}
+	}
+}
+
+func withDefaults(*ast.Runtime) {}
+
+func withBignums(rt *ast.Runtime) { rt.Bignums = true }
+
+func TestBignums(t *testing.T) {
+	checkResults(t, []resultCase{
+		{"minus(123456789012345678901234567890, -1)", "123456789012345678901234567891"},
+		{"minus(-9223372036854775808, 1)", "-9223372036854775809"},
+		{"times(9223372036854775807, 9223372036854775807)", "85070591730234615847396907784232501249"},
+		{"quotient(100000000000000000000, 3)", "33333333333333333333"},
+		{"remainder(100000000000000000000, 3)", "1"},
+		{"list(zero?(minus(99999999999999999999, 99999999999999999999)), less?(1, 99999999999999999999))", "(true true)"},
+		{`letrec fact(n) = if zero?(n) then 1 else times(n, (fact minus(n, 1))) in (fact 25)`, "15511210043330985984000000"},
+	}, withBignums)
+}
+
+func TestBignumsErrors(t *testing.T) {
+	_, err := evalWith(t, "quotient(100000000000000000000, 0)", withBignums)
+	checkErrorResult(t, err, "quotient: division by zero (dividend was 100000000000000000000)")
+	_, err = evalWith(t, "minus(1, true)", withBignums)
+	checkErrorResult(t, err, "minus expects numbers, got bool")
+	_, err = bothEvaluators(parseProgramSource(t, "minus(123456789012345678901234567890, 1)"), ast.NewRuntime(), ast.NewRuntime())
+	checkErrorResult(t, err, "123456789012345678901234567890 does not fit in a machine int, run with --bignum")
+}
+
+//minIntProgram binds min to the smallest int, negative literals are negated positive ones so it
+//can not be written directly.
+const minIntProgram = "let min = minus(-9223372036854775807, 1) in "
+
+func withCheckOverflow(rt *ast.Runtime) { rt.CheckOverflow = true }
+
+func TestCheckOverflow(t *testing.T) {
+	cases := []resultCase{
+		{minIntProgram + "minus(min, 1)", "minus: integer overflow (operands were -9223372036854775808 and 1)"},
+		{"minus(9223372036854775807, -1)", "minus: integer overflow (operands were 9223372036854775807 and -1)"},
+		{"plus(9223372036854775807, 1)", "plus: integer overflow (operands were 9223372036854775807 and 1)"},
+		{minIntProgram + "plus(min, -1)", "plus: integer overflow (operands were -9223372036854775808 and -1)"},
+		{"times(4294967296, 4294967296)", "times: integer overflow (operands were 4294967296 and 4294967296)"},
+		{minIntProgram + "times(-1, min)", "times: integer overflow (operands were -1 and -9223372036854775808)"},
+		{minIntProgram + "quotient(min, -1)", "quotient: integer overflow (operands were -9223372036854775808 and -1)"},
+	}
+	for _, tc := range cases {
+		_, err := evalWith(t, tc.input, withCheckOverflow)
+		checkErrorResult(t, err, tc.expected)
+		if _, ok := err.(*ast.OverflowError); !ok {
+			t.Fatalf("Expected error of %q to be %T but was %T", tc.input, &ast.OverflowError{}, err)
+		}
+	}
+}
+
+func TestCheckOverflowInRange(t *testing.T) {
+	checkResults(t, []resultCase{
+		{minIntProgram + `list(min, plus(9223372036854775806, 1),
+		                       times(-3037000499, 3037000499), quotient(min, 1), remainder(min, -1))`,
+			"(-9223372036854775808 9223372036854775807 -9223372030926249001 -9223372036854775808 0)"},
+	}, withCheckOverflow)
+	//Without checking, minus wraps around.
+	checkResults(t, []resultCase{{minIntProgram + "minus(min, 1)", "9223372036854775807"}}, withDefaults)
+}
+
+func withRationals(rt *ast.Runtime) { rt.Rationals = true }
+
+func TestNumericTower(t *testing.T) {
+	checkResults(t, []resultCase{
+		{"minus(3.14, 1)", "2.14"},
+		{"times(2.5, 4)", "10.0"},
+		{"minus(1e-3, 0)", "0.001"},
+		{"quotient(7, 2)", "3"},
+		{"remainder(7.5, 2)", "1.5"},
+		{"quotient(7.0, 2)", "3.5"},
+	}, withDefaults)
+	checkResults(t, []resultCase{
+		{"quotient(7, 2)", "7/2"},
+		{"quotient(8, 2)", "4"},
+		{"quotient(-6, 4)", "-3/2"},
+		{"plus(quotient(1, 3), quotient(2, 3))", "1"},
+		{"times(quotient(1, 3), 0.5)", "0.16666666666666666"},
+		{"remainder(quotient(7, 2), 2)", "3/2"},
+		{"list(equal?(1, 1.0), less?(quotient(1, 3), 0.34), greater?(quotient(1, 2), quotient(1, 3)))", "(true true true)"},
+		{"list(zero?(0.0), zero?(quotient(1, 3)))", "(true false)"},
+	}, withRationals)
+}
+
+func TestNumericTowerErrors(t *testing.T) {
+	_, err := evalWith(t, "quotient(1.5, 0)", withDefaults)
+	checkErrorResult(t, err, "quotient: division by zero (dividend was 1.5)")
+	_, err = evalWith(t, "remainder(quotient(1, 2), 0.0)", withRationals)
+	checkErrorResult(t, err, "remainder: division by zero (dividend was 1/2)")
+	_, err = evalWith(t, "plus(2.5, true)", withDefaults)
+	checkErrorResult(t, err, "plus expects numbers, got bool")
+	_, err = evalWith(t, minIntProgram+"quotient(min, -1)", func(rt *ast.Runtime) {
+		rt.Rationals = true
+		rt.CheckOverflow = true
+	})
+	checkErrorResult(t, err, "quotient: integer overflow (operands were -9223372036854775808 and -1)")
+}
+
+func TestNumericTowerWrapsWholeRationals(t *testing.T) {
+	//A whole quotient too big for an int wraps around like one of machine ints.
+	checkResults(t, []resultCase{
+		{minIntProgram + "quotient(min, -1)", "-9223372036854775808"},
+		{minIntProgram + "times(quotient(min, 3), -3)", "-9223372036854775808"},
+	}, withRationals)
+}
+
+func TestStrings(t *testing.T) {
+	checkResults(t, []resultCase{
+		{`"hi"`, `"hi"`},
+		{`string-length("héllo")`, "5"},
+		{`string-append("let", "-", "lang", "")`, `"let-lang"`},
+		{`string-append()`, `""`},
+		{`substring("héllo", 1, 3)`, `"él"`},
+		{`list(string=?("a", "a"), string=?("a", "b"))`, "(true false)"},
+		{`list(number->string(-42), number->string(2.5))`, `("-42" "2.5")`},
+		{`let greet = proc (name) string-append("hello, ", name, "\n") in (greet "world")`, `"hello, world\n"`},
+	}, withDefaults)
+}
+
+func TestSubtractionOfIdentifiers(t *testing.T) {
+	//Only primitive names have hyphens, a-b is a subtraction.
+	checkResults(t, []resultCase{{"let a = 5 in let b = 2 in a-b", "3"}}, withDefaults)
+}
+
+func TestStringErrors(t *testing.T) {
+	_, err := evalWith(t, `substring("abc", 2, 4)`, withDefaults)
+	checkErrorResult(t, err, "substring: indices 2 and 4 are out of range for a string of length 3")
+	_, err = evalWith(t, `substring("abc", 2, 1)`, withDefaults)
+	checkErrorResult(t, err, "substring: indices 2 and 1 are out of range for a string of length 3")
+	_, err = evalWith(t, `string-length(5)`, withDefaults)
+	checkErrorResult(t, err, "string-length expects a string, got number")
+	_, err = evalWith(t, `string-append("a", 1)`, withDefaults)
+	checkErrorResult(t, err, "string-append expects a string, got number")
+	_, err = evalWith(t, `substring("abc", 0, 1.5)`, withDefaults)
+	checkErrorResult(t, err, "substring expects an int, got number")
+	_, err = evalWith(t, `number->string("5")`, withDefaults)
+	checkErrorResult(t, err, "number->string expects a number, got string")
+}
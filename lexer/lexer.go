@@ -1,21 +1,51 @@
 package lexer
 
-import "let_lang_proj_michael_andrepont/token"
+import (
+	"let_lang_proj_michael_andrepont/token"
+	"fmt"
+)
 
 type Lexer struct {
 	input        string
 	position     int  // The current position in the input
 	readPosition int  // The current reading position (one after position)
 	ch           byte // The current char we are reading.
+	line         int  // The line and column of ch
+	column       int
+	errors       []string
+	keepComments bool
 }
 
 func New(input string) *Lexer {
-	l := Lexer{input: input}
+	l := Lexer{input: input, line: 1}
 	l.readChar()
 	return &l
 }
 
+//NewWithComments returns a lexer that returns comments as COMMENT tokens instead of skipping
+//them, for tools that need to keep them like formatters.
+func NewWithComments(input string) *Lexer {
+	l := New(input)
+	l.keepComments = true
+	return l
+}
+
+//Errors returns the problems found in the tokens read so far, prefixed with their line and column.
+func (l *Lexer) Errors() []string {
+	return l.errors
+}
+
+func (l *Lexer) errorf(line int, column int, format string, args ...interface{}) {
+	l.errors = append(l.errors, fmt.Sprintf("%d:%d: %s", line, column, fmt.Sprintf(format, args...)))
+}
+
 func (l *Lexer) readChar() {
+	if l.ch == '\n' {
+		l.line++
+		l.column = 1
+	} else {
+		l.column++
+	}
 	if l.readPosition >= len(l.input) {
 		l.ch = 0 //EOF
 	} else {
@@ -33,6 +63,14 @@ func (l *Lexer) peekChar() byte {
 	}
 }
 
+//peekCharAt returns the char n chars after the next one.
+func (l *Lexer) peekCharAt(n int) byte {
+	if l.readPosition+n >= len(l.input) {
+		return 0
+	}
+	return l.input[l.readPosition+n]
+}
+
 func isDigit(ch byte) bool  { return ch >= '0' && ch <= '9' }
 func isLetter(ch byte) bool { return (ch >= 'a' && ch <= 'z') || (ch >= 'A' && ch <= 'Z') }
 
@@ -45,15 +83,58 @@ func (l *Lexer) skipWhitespace() {
 func (l *Lexer) NextToken() token.Token {
 	var returnToken token.Token
 	l.skipWhitespace()
+	line, column := l.line, l.column
+	for l.atComment() {
+		comment := l.readComment(line, column)
+		if l.keepComments {
+			return token.Token{Type: token.COMMENT, Literal: comment, Line: line, Column: column}
+		}
+		l.skipWhitespace()
+		line, column = l.line, l.column
+	}
 	switch l.ch {
 	case '=':
-		returnToken = token.MakeToken(token.ASSIGN, l.ch)
+		if l.peekChar() == '=' {
+			l.readChar()
+			returnToken = token.Token{Type: token.EQ, Literal: "=="}
+		} else {
+			returnToken = token.MakeToken(token.ASSIGN, l.ch)
+		}
+	case '+':
+		returnToken = token.MakeToken(token.PLUS, l.ch)
+	case '-':
+		if l.peekChar() == '>' {
+			l.readChar()
+			returnToken = token.Token{Type: token.ARROW, Literal: "->"}
+		} else {
+			returnToken = token.MakeToken(token.SUB, l.ch)
+		}
+	case ':':
+		returnToken = token.MakeToken(token.COLON, l.ch)
+	case '?':
+		returnToken = token.MakeToken(token.QUESTION, l.ch)
+	case '*':
+		returnToken = token.MakeToken(token.ASTERISK, l.ch)
+	case '/':
+		returnToken = token.MakeToken(token.SLASH, l.ch)
+	case '<':
+		returnToken = token.MakeToken(token.LT, l.ch)
+	case '>':
+		returnToken = token.MakeToken(token.GT, l.ch)
 	case ',':
 		returnToken = token.MakeToken(token.COMMA, l.ch)
+	case ';':
+		returnToken = token.MakeToken(token.SEMICOLON, l.ch)
+	case '[':
+		returnToken = token.MakeToken(token.LBRACKET, l.ch)
+	case ']':
+		returnToken = token.MakeToken(token.RBRACKET, l.ch)
 	case '(':
 		returnToken = token.MakeToken(token.LPAREN, l.ch)
 	case ')':
 		returnToken = token.MakeToken(token.RPAREN, l.ch)
+	case '"':
+		returnToken = l.readString(line, column)
 	case 0:
 		returnToken.Type = token.EOF
 		returnToken.Literal = ""
@@ -62,28 +143,145 @@ func (l *Lexer) NextToken() token.Token {
 			returnToken.Literal = l.readIdent()
 			returnToken.Type = token.KeywordLookup(returnToken.Literal)
 		} else if isDigit(l.ch) {
-			returnToken.Type = token.INT
-			returnToken.Literal = l.readDigit()
+			returnToken.Literal, returnToken.Type = l.readDigit()
 		} else {
 			returnToken = token.MakeToken(token.ILLEGAL, l.ch)
 		}
 	}
 	l.readChar()
+	returnToken.Line, returnToken.Column = line, column
 	return returnToken
 }
 
+//Comments are % or // to the end of the line, as in EOPL, or /* */ which can be nested.
+func (l *Lexer) atComment() bool {
+	return l.ch == '%' || (l.ch == '/' && (l.peekChar() == '/' || l.peekChar() == '*'))
+}
+
+//readComment reads the comment at the current char and returns its text, it stops on the char
+//after the comment instead of its last char like the other read functions.
+func (l *Lexer) readComment(line int, column int) string {
+	startPos := l.position
+	if l.ch == '/' && l.peekChar() == '*' {
+		depth := 0
+		for {
+			if l.ch == '/' && l.peekChar() == '*' {
+				depth++
+				l.readChar()
+			} else if l.ch == '*' && l.peekChar() == '/' {
+				depth--
+				l.readChar()
+			} else if l.ch == 0 {
+				l.errorf(line, column, "unterminated comment")
+				break
+			}
+			l.readChar()
+			if depth == 0 {
+				break
+			}
+		}
+	} else {
+		for l.ch != '\n' && l.ch != 0 {
+			l.readChar()
+		}
+	}
+	return l.input[startPos:l.position]
+}
+
 func (l *Lexer) readIdent() string {
 	startPos := l.position
-	for isDigit(l.peekChar()) || isLetter(l.peekChar()) {
+	for isIdentChar(l.peekChar()) {
 		l.readChar()
 	}
+	//let* is the only keyword with a symbol in it.
+	if l.input[startPos:l.position+1] == "let" && l.peekChar() == '*' {
+		l.readChar()
+	}
+	//Primitives like string-length, number->string and string=? have symbols in their names, a
+	//name with symbols is only read as one identifier if it is one of them so a-b is a subtraction.
+	end := l.position + 1
+	for end < len(l.input) && (isIdentChar(l.input[end]) || isPrimitiveSymbol(l.input[end])) {
+		end++
+	}
+	if token.IsSymbolicName(l.input[startPos:end]) {
+		for l.position+1 < end {
+			l.readChar()
+		}
+	}
 	return l.input[startPos : l.position+1]
 }
 
-func (l *Lexer) readDigit() string {
+func isIdentChar(ch byte) bool {
+	return isDigit(ch) || isLetter(ch) || ch == '?'
+}
+
+func isPrimitiveSymbol(ch byte) bool {
+	return ch == '-' || ch == '>' || ch == '='
+}
+
+//readString reads a string literal, the token's literal is the string with its escape
+//sequences replaced. An unterminated string is an ILLEGAL token.
+func (l *Lexer) readString(line int, column int) token.Token {
+	var value []byte
+	for {
+		l.readChar()
+		switch l.ch {
+		case '"':
+			return token.Token{Type: token.STRING, Literal: string(value)}
+		case 0:
+			l.errorf(line, column, "unterminated string")
+			return token.Token{Type: token.ILLEGAL, Literal: "\"" + string(value)}
+		case '\\':
+			escLine, escColumn := l.line, l.column
+			l.readChar()
+			switch l.ch {
+			case 'n':
+				value = append(value, '\n')
+			case 't':
+				value = append(value, '\t')
+			case 'r':
+				value = append(value, '\r')
+			case '"', '\\':
+				value = append(value, l.ch)
+			case 0:
+				l.errorf(line, column, "unterminated string")
+				return token.Token{Type: token.ILLEGAL, Literal: "\"" + string(value)}
+			default:
+				l.errorf(escLine, escColumn, "unknown escape sequence \\%c", l.ch)
+				value = append(value, l.ch)
+			}
+		default:
+			value = append(value, l.ch)
+		}
+	}
+}
+
+//readDigit reads an int, or a float when the digits are followed by a fraction or an exponent.
+func (l *Lexer) readDigit() (string, token.TokenType) {
 	startPos := l.position
+	tokenType := token.TokenType(token.INT)
+	l.readDigits()
+	if l.peekChar() == '.' && isDigit(l.peekCharAt(1)) {
+		tokenType = token.FLOAT
+		l.readChar()
+		l.readDigits()
+	}
+	if l.peekChar() == 'e' || l.peekChar() == 'E' {
+		sign := l.peekCharAt(1) == '+' || l.peekCharAt(1) == '-'
+		if isDigit(l.peekCharAt(1)) || (sign && isDigit(l.peekCharAt(2))) {
+			tokenType = token.FLOAT
+			l.readChar()
+			if sign {
+				l.readChar()
+			}
+			l.readDigits()
+		}
+	}
+	return l.input[startPos : l.position+1], tokenType
+}
+
+func (l *Lexer) readDigits() {
 	for isDigit(l.peekChar()) {
 		l.readChar()
 	}
-	return l.input[startPos : l.position+1]
 }
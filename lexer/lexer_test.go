@@ -12,7 +12,7 @@ func checkTokens(t *testing.T, input string, expectedToken ExpectedTokens) {
 	for i, et := range expectedToken {
 		nextToken := lexer.NextToken()
 
-		if nextToken != et {
+		if nextToken.Type != et.Type || nextToken.Literal != et.Literal {
 			t.Fatalf("nextToken[%d] - nextToken is not expected. expected=%+v, got=%+v",
 				i, et, nextToken)
 		}
@@ -22,11 +22,11 @@ func checkTokens(t *testing.T, input string, expectedToken ExpectedTokens) {
 func TestSingleTokenLex(t *testing.T) {
 	input := `=(),`
 	expectedTokens := ExpectedTokens{
-		{token.ASSIGN, "="},
-		{token.LPAREN, "("},
-		{token.RPAREN, ")"},
-		{token.COMMA, ","},
-		{token.EOF, ""},
+		{Type: token.ASSIGN, Literal: "="},
+		{Type: token.LPAREN, Literal: "("},
+		{Type: token.RPAREN, Literal: ")"},
+		{Type: token.COMMA, Literal: ","},
+		{Type: token.EOF, Literal: ""},
 	}
 	checkTokens(t, input, expectedTokens)
 }
@@ -34,15 +34,15 @@ func TestSingleTokenLex(t *testing.T) {
 func TestSingleTokenWithIllegalLex(t *testing.T) {
 	input := `=(),[]{}`
 	expectedTokens := ExpectedTokens{
-		{token.ASSIGN, "="},
-		{token.LPAREN, "("},
-		{token.RPAREN, ")"},
-		{token.COMMA, ","},
-		{token.ILLEGAL, "["},
-		{token.ILLEGAL, "]"},
-		{token.ILLEGAL, "{"},
-		{token.ILLEGAL, "}"},
-		{token.EOF, ""},
+		{Type: token.ASSIGN, Literal: "="},
+		{Type: token.LPAREN, Literal: "("},
+		{Type: token.RPAREN, Literal: ")"},
+		{Type: token.COMMA, Literal: ","},
+		{Type: token.LBRACKET, Literal: "["},
+		{Type: token.RBRACKET, Literal: "]"},
+		{Type: token.ILLEGAL, Literal: "{"},
+		{Type: token.ILLEGAL, Literal: "}"},
+		{Type: token.EOF, Literal: ""},
 	}
 	checkTokens(t, input, expectedTokens)
 }
@@ -50,31 +50,176 @@ func TestSingleTokenWithIllegalLex(t *testing.T) {
 func TestDigitsAndIdentLex(t *testing.T) {
 	input := `myAwesome83StRIng 321 32n2x 3x3 3 y`
 	expectedTokens := ExpectedTokens{
-		{token.IDENT, "myAwesome83StRIng"},
-		{token.INT, "321"},
-		{token.INT, "32"},
-		{token.IDENT, "n2x"},
-		{token.INT, "3"},
-		{token.IDENT, "x3"},
-		{token.INT, "3"},
-		{token.IDENT, "y"},
-		{token.EOF, ""},
+		{Type: token.IDENT, Literal: "myAwesome83StRIng"},
+		{Type: token.INT, Literal: "321"},
+		{Type: token.INT, Literal: "32"},
+		{Type: token.IDENT, Literal: "n2x"},
+		{Type: token.INT, Literal: "3"},
+		{Type: token.IDENT, Literal: "x3"},
+		{Type: token.INT, Literal: "3"},
+		{Type: token.IDENT, Literal: "y"},
+		{Type: token.EOF, Literal: ""},
+	}
+	checkTokens(t, input, expectedTokens)
+}
+
+func TestFloatLex(t *testing.T) {
+	input := `3.14 1e-3 2.5E+10 7e2 4. 5.x 6e 8ex`
+	expectedTokens := ExpectedTokens{
+		{Type: token.FLOAT, Literal: "3.14"},
+		{Type: token.FLOAT, Literal: "1e-3"},
+		{Type: token.FLOAT, Literal: "2.5E+10"},
+		{Type: token.FLOAT, Literal: "7e2"},
+		{Type: token.INT, Literal: "4"},
+		{Type: token.ILLEGAL, Literal: "."},
+		{Type: token.INT, Literal: "5"},
+		{Type: token.ILLEGAL, Literal: "."},
+		{Type: token.IDENT, Literal: "x"},
+		{Type: token.INT, Literal: "6"},
+		{Type: token.IDENT, Literal: "e"},
+		{Type: token.INT, Literal: "8"},
+		{Type: token.IDENT, Literal: "ex"},
+		{Type: token.EOF, Literal: ""},
 	}
 	checkTokens(t, input, expectedTokens)
 }
 
 func TestKeywordsLex(t *testing.T) {
-	input := `let iszero mincus minus if then else in`
+	input := `let iszero mincus minus if then else in true false letrec let* lets emptylist set try catch raise letcc throw to module interface body from take class extends field method new send super self`
+	expectedTokens := ExpectedTokens{
+		{Type: token.LET, Literal: "let"},
+		{Type: token.IS_ZERO, Literal: "iszero"},
+		{Type: token.IDENT, Literal: "mincus"},
+		{Type: token.MINUS, Literal: "minus"},
+		{Type: token.IF, Literal: "if"},
+		{Type: token.THEN, Literal: "then"},
+		{Type: token.ELSE, Literal: "else"},
+		{Type: token.IN, Literal: "in"},
+		{Type: token.TRUE, Literal: "true"},
+		{Type: token.FALSE, Literal: "false"},
+		{Type: token.LETREC, Literal: "letrec"},
+		{Type: token.LET_STAR, Literal: "let*"},
+		{Type: token.IDENT, Literal: "lets"},
+		{Type: token.EMPTY_LIST, Literal: "emptylist"},
+		{Type: token.SET, Literal: "set"},
+		{Type: token.TRY, Literal: "try"},
+		{Type: token.CATCH, Literal: "catch"},
+		{Type: token.RAISE, Literal: "raise"},
+		{Type: token.LETCC, Literal: "letcc"},
+		{Type: token.THROW, Literal: "throw"},
+		{Type: token.TO, Literal: "to"},
+		{Type: token.MODULE, Literal: "module"},
+		{Type: token.INTERFACE, Literal: "interface"},
+		{Type: token.BODY, Literal: "body"},
+		{Type: token.FROM, Literal: "from"},
+		{Type: token.TAKE, Literal: "take"},
+		{Type: token.CLASS, Literal: "class"},
+		{Type: token.EXTENDS, Literal: "extends"},
+		{Type: token.FIELD, Literal: "field"},
+		{Type: token.METHOD, Literal: "method"},
+		{Type: token.NEW, Literal: "new"},
+		{Type: token.SEND, Literal: "send"},
+		{Type: token.SUPER, Literal: "super"},
+		{Type: token.SELF, Literal: "self"},
+		{Type: token.EOF, Literal: ""},
+	}
+	checkTokens(t, input, expectedTokens)
+}
+
+func TestQuestionMarkIdentLex(t *testing.T) {
+	input := `equal?(x, y) zero?(x) null? ?`
+	expectedTokens := ExpectedTokens{
+		{Type: token.IDENT, Literal: "equal?"},
+		{Type: token.LPAREN, Literal: "("},
+		{Type: token.IDENT, Literal: "x"},
+		{Type: token.COMMA, Literal: ","},
+		{Type: token.IDENT, Literal: "y"},
+		{Type: token.RPAREN, Literal: ")"},
+		{Type: token.IS_ZERO, Literal: "zero?"},
+		{Type: token.LPAREN, Literal: "("},
+		{Type: token.IDENT, Literal: "x"},
+		{Type: token.RPAREN, Literal: ")"},
+		{Type: token.IDENT, Literal: "null?"},
+		{Type: token.QUESTION, Literal: "?"},
+		{Type: token.EOF, Literal: ""},
+	}
+	checkTokens(t, input, expectedTokens)
+}
+
+func TestOperatorsLex(t *testing.T) {
+	input := `x - 8 == -y*(a+b)/c < d > e = f`
+	expectedTokens := ExpectedTokens{
+		{Type: token.IDENT, Literal: "x"},
+		{Type: token.SUB, Literal: "-"},
+		{Type: token.INT, Literal: "8"},
+		{Type: token.EQ, Literal: "=="},
+		{Type: token.SUB, Literal: "-"},
+		{Type: token.IDENT, Literal: "y"},
+		{Type: token.ASTERISK, Literal: "*"},
+		{Type: token.LPAREN, Literal: "("},
+		{Type: token.IDENT, Literal: "a"},
+		{Type: token.PLUS, Literal: "+"},
+		{Type: token.IDENT, Literal: "b"},
+		{Type: token.RPAREN, Literal: ")"},
+		{Type: token.SLASH, Literal: "/"},
+		{Type: token.IDENT, Literal: "c"},
+		{Type: token.LT, Literal: "<"},
+		{Type: token.IDENT, Literal: "d"},
+		{Type: token.GT, Literal: ">"},
+		{Type: token.IDENT, Literal: "e"},
+		{Type: token.ASSIGN, Literal: "="},
+		{Type: token.IDENT, Literal: "f"},
+		{Type: token.EOF, Literal: ""},
+	}
+	checkTokens(t, input, expectedTokens)
+}
+
+func TestBeginLex(t *testing.T) {
+	input := `begin setref(x, 1); deref(x) end`
 	expectedTokens := ExpectedTokens{
-		{token.LET, "let"},
-		{token.IS_ZERO, "iszero"},
-		{token.IDENT, "mincus"},
-		{token.MINUS, "minus"},
-		{token.IF, "if"},
-		{token.THEN, "then"},
-		{token.ELSE, "else"},
-		{token.IN, "in"},
-		{token.EOF, ""},
+		{Type: token.BEGIN, Literal: "begin"},
+		{Type: token.IDENT, Literal: "setref"},
+		{Type: token.LPAREN, Literal: "("},
+		{Type: token.IDENT, Literal: "x"},
+		{Type: token.COMMA, Literal: ","},
+		{Type: token.INT, Literal: "1"},
+		{Type: token.RPAREN, Literal: ")"},
+		{Type: token.SEMICOLON, Literal: ";"},
+		{Type: token.IDENT, Literal: "deref"},
+		{Type: token.LPAREN, Literal: "("},
+		{Type: token.IDENT, Literal: "x"},
+		{Type: token.RPAREN, Literal: ")"},
+		{Type: token.END, Literal: "end"},
+		{Type: token.EOF, Literal: ""},
+	}
+	checkTokens(t, input, expectedTokens)
+}
+
+func TestProcExample(t *testing.T) {
+	input := `let f = proc (x, y) minus(x, y) in (f 3 1)`
+	expectedTokens := ExpectedTokens{
+		{Type: token.LET, Literal: "let"},
+		{Type: token.IDENT, Literal: "f"},
+		{Type: token.ASSIGN, Literal: "="},
+		{Type: token.PROC, Literal: "proc"},
+		{Type: token.LPAREN, Literal: "("},
+		{Type: token.IDENT, Literal: "x"},
+		{Type: token.COMMA, Literal: ","},
+		{Type: token.IDENT, Literal: "y"},
+		{Type: token.RPAREN, Literal: ")"},
+		{Type: token.MINUS, Literal: "minus"},
+		{Type: token.LPAREN, Literal: "("},
+		{Type: token.IDENT, Literal: "x"},
+		{Type: token.COMMA, Literal: ","},
+		{Type: token.IDENT, Literal: "y"},
+		{Type: token.RPAREN, Literal: ")"},
+		{Type: token.IN, Literal: "in"},
+		{Type: token.LPAREN, Literal: "("},
+		{Type: token.IDENT, Literal: "f"},
+		{Type: token.INT, Literal: "3"},
+		{Type: token.INT, Literal: "1"},
+		{Type: token.RPAREN, Literal: ")"},
+		{Type: token.EOF, Literal: ""},
 	}
 	checkTokens(t, input, expectedTokens)
 }
@@ -89,53 +234,53 @@ func TestAssignmentExample(t *testing.T) {
 	`
 	expectedTokens := ExpectedTokens{
 		//Break = new line
-		{token.LET, "let"},
-		{token.IDENT, "x"},
-		{token.ASSIGN, "="},
-		{token.INT, "7"},
-
-		{token.IN, "in"},
-		{token.LET, "let"},
-		{token.IDENT, "y"},
-		{token.ASSIGN, "="},
-		{token.INT, "2"},
-
-		{token.IN, "in"},
-		{token.LET, "let"},
-		{token.IDENT, "y"},
-		{token.ASSIGN, "="},
-		{token.LET, "let"},
-		{token.IDENT, "x"},
-		{token.ASSIGN, "="},
-		{token.MINUS, "minus"},
-		{token.LPAREN, "("},
-		{token.IDENT, "x"},
-		{token.COMMA, ","},
-		{token.INT, "1"},
-		{token.RPAREN, ")"},
-
-		{token.IN, "in"},
-		{token.MINUS, "minus"},
-		{token.LPAREN, "("},
-		{token.IDENT, "x"},
-		{token.COMMA, ","},
-		{token.IDENT, "y"},
-		{token.RPAREN, ")"},
-
-		{token.IN, "in"},
-		{token.MINUS, "minus"},
-		{token.LPAREN, "("},
-		{token.MINUS, "minus"},
-		{token.LPAREN, "("},
-		{token.IDENT, "x"},
-		{token.COMMA, ","},
-		{token.INT, "8"},
-		{token.RPAREN, ")"},
-		{token.COMMA, ","},
-		{token.IDENT, "y"},
-		{token.RPAREN, ")"},
-
-		{token.EOF, ""},
+		{Type: token.LET, Literal: "let"},
+		{Type: token.IDENT, Literal: "x"},
+		{Type: token.ASSIGN, Literal: "="},
+		{Type: token.INT, Literal: "7"},
+
+		{Type: token.IN, Literal: "in"},
+		{Type: token.LET, Literal: "let"},
+		{Type: token.IDENT, Literal: "y"},
+		{Type: token.ASSIGN, Literal: "="},
+		{Type: token.INT, Literal: "2"},
+
+		{Type: token.IN, Literal: "in"},
+		{Type: token.LET, Literal: "let"},
+		{Type: token.IDENT, Literal: "y"},
+		{Type: token.ASSIGN, Literal: "="},
+		{Type: token.LET, Literal: "let"},
+		{Type: token.IDENT, Literal: "x"},
+		{Type: token.ASSIGN, Literal: "="},
+		{Type: token.MINUS, Literal: "minus"},
+		{Type: token.LPAREN, Literal: "("},
+		{Type: token.IDENT, Literal: "x"},
+		{Type: token.COMMA, Literal: ","},
+		{Type: token.INT, Literal: "1"},
+		{Type: token.RPAREN, Literal: ")"},
+
+		{Type: token.IN, Literal: "in"},
+		{Type: token.MINUS, Literal: "minus"},
+		{Type: token.LPAREN, Literal: "("},
+		{Type: token.IDENT, Literal: "x"},
+		{Type: token.COMMA, Literal: ","},
+		{Type: token.IDENT, Literal: "y"},
+		{Type: token.RPAREN, Literal: ")"},
+
+		{Type: token.IN, Literal: "in"},
+		{Type: token.MINUS, Literal: "minus"},
+		{Type: token.LPAREN, Literal: "("},
+		{Type: token.MINUS, Literal: "minus"},
+		{Type: token.LPAREN, Literal: "("},
+		{Type: token.IDENT, Literal: "x"},
+		{Type: token.COMMA, Literal: ","},
+		{Type: token.INT, Literal: "8"},
+		{Type: token.RPAREN, Literal: ")"},
+		{Type: token.COMMA, Literal: ","},
+		{Type: token.IDENT, Literal: "y"},
+		{Type: token.RPAREN, Literal: ")"},
+
+		{Type: token.EOF, Literal: ""},
 	}
 	checkTokens(t, input, expectedTokens)
 }
@@ -150,46 +295,46 @@ func TestAssignment2Example(t *testing.T) {
 	`
 	expectedTokens := ExpectedTokens{
 		//Break = new line
-		{token.LET, "let"},
-		{token.IDENT, "x"},
-		{token.ASSIGN, "="},
-		{token.INT, "11"},
-
-		{token.IN, "in"},
-		{token.LET, "let"},
-		{token.IDENT, "y"},
-		{token.ASSIGN, "="},
-		{token.INT, "20"},
-
-		{token.IN, "in"},
-		{token.IF, "if"},
-		{token.IS_ZERO, "iszero"},
-		{token.LPAREN, "("},
-		{token.MINUS, "minus"},
-		{token.LPAREN, "("},
-		{token.IDENT, "x"},
-		{token.COMMA, ","},
-		{token.INT, "11"},
-		{token.RPAREN, ")"},
-		{token.RPAREN, ")"},
-
-		{token.THEN, "then"},
-		{token.MINUS, "minus"},
-		{token.LPAREN, "("},
-		{token.IDENT, "y"},
-		{token.COMMA, ","},
-		{token.INT, "2"},
-		{token.RPAREN, ")"},
-
-		{token.ELSE, "else"},
-		{token.MINUS, "minus"},
-		{token.LPAREN, "("},
-		{token.IDENT, "y"},
-		{token.COMMA, ","},
-		{token.INT, "4"},
-		{token.RPAREN, ")"},
-
-		{token.EOF, ""},
+		{Type: token.LET, Literal: "let"},
+		{Type: token.IDENT, Literal: "x"},
+		{Type: token.ASSIGN, Literal: "="},
+		{Type: token.INT, Literal: "11"},
+
+		{Type: token.IN, Literal: "in"},
+		{Type: token.LET, Literal: "let"},
+		{Type: token.IDENT, Literal: "y"},
+		{Type: token.ASSIGN, Literal: "="},
+		{Type: token.INT, Literal: "20"},
+
+		{Type: token.IN, Literal: "in"},
+		{Type: token.IF, Literal: "if"},
+		{Type: token.IS_ZERO, Literal: "iszero"},
+		{Type: token.LPAREN, Literal: "("},
+		{Type: token.MINUS, Literal: "minus"},
+		{Type: token.LPAREN, Literal: "("},
+		{Type: token.IDENT, Literal: "x"},
+		{Type: token.COMMA, Literal: ","},
+		{Type: token.INT, Literal: "11"},
+		{Type: token.RPAREN, Literal: ")"},
+		{Type: token.RPAREN, Literal: ")"},
+
+		{Type: token.THEN, Literal: "then"},
+		{Type: token.MINUS, Literal: "minus"},
+		{Type: token.LPAREN, Literal: "("},
+		{Type: token.IDENT, Literal: "y"},
+		{Type: token.COMMA, Literal: ","},
+		{Type: token.INT, Literal: "2"},
+		{Type: token.RPAREN, Literal: ")"},
+
+		{Type: token.ELSE, Literal: "else"},
+		{Type: token.MINUS, Literal: "minus"},
+		{Type: token.LPAREN, Literal: "("},
+		{Type: token.IDENT, Literal: "y"},
+		{Type: token.COMMA, Literal: ","},
+		{Type: token.INT, Literal: "4"},
+		{Type: token.RPAREN, Literal: ")"},
+
+		{Type: token.EOF, Literal: ""},
 	}
 	checkTokens(t, input, expectedTokens)
 }
@@ -197,14 +342,14 @@ func TestAssignment2Example(t *testing.T) {
 func TestOnlyEOF(t *testing.T) {
 	input := ``
 	expectedTokens := ExpectedTokens{
-		{token.EOF, ""},
-		{token.EOF, ""},
-		{token.EOF, ""},
-		{token.EOF, ""},
-		{token.EOF, ""},
-		{token.EOF, ""},
-		{token.EOF, ""},
-		{token.EOF, ""},
+		{Type: token.EOF, Literal: ""},
+		{Type: token.EOF, Literal: ""},
+		{Type: token.EOF, Literal: ""},
+		{Type: token.EOF, Literal: ""},
+		{Type: token.EOF, Literal: ""},
+		{Type: token.EOF, Literal: ""},
+		{Type: token.EOF, Literal: ""},
+		{Type: token.EOF, Literal: ""},
 	}
 	checkTokens(t, input, expectedTokens)
 }
@@ -212,19 +357,173 @@ func TestOnlyEOF(t *testing.T) {
 func TestEOFWithToken(t *testing.T) {
 	input := `let x = 8`
 	expectedTokens := ExpectedTokens{
-		{token.LET, "let"},
-		{token.IDENT, "x"},
-		{token.ASSIGN, "="},
-		{token.INT, "8"},
+		{Type: token.LET, Literal: "let"},
+		{Type: token.IDENT, Literal: "x"},
+		{Type: token.ASSIGN, Literal: "="},
+		{Type: token.INT, Literal: "8"},
+
+		{Type: token.EOF, Literal: ""},
+		{Type: token.EOF, Literal: ""},
+		{Type: token.EOF, Literal: ""},
+		{Type: token.EOF, Literal: ""},
+		{Type: token.EOF, Literal: ""},
+		{Type: token.EOF, Literal: ""},
+		{Type: token.EOF, Literal: ""},
+		{Type: token.EOF, Literal: ""},
+	}
+	checkTokens(t, input, expectedTokens)
+}
+
+func TestTokenPositions(t *testing.T) {
+	input := "let x = 5\n  in minus(x,\n\t10)"
+	expected := []struct {
+		literal string
+		line    int
+		column  int
+	}{
+		{"let", 1, 1}, {"x", 1, 5}, {"=", 1, 7}, {"5", 1, 9},
+		{"in", 2, 3}, {"minus", 2, 6}, {"(", 2, 11}, {"x", 2, 12}, {",", 2, 13},
+		{"10", 3, 2}, {")", 3, 4}, {"", 3, 5},
+	}
+	lexer := New(input)
+	for i, e := range expected {
+		tok := lexer.NextToken()
+		if tok.Literal != e.literal || tok.Line != e.line || tok.Column != e.column {
+			t.Fatalf("token[%d] expected %q at %d:%d, got %q at %d:%d",
+				i, e.literal, e.line, e.column, tok.Literal, tok.Line, tok.Column)
+		}
+	}
+}
+
+func TestTypeAnnotationLex(t *testing.T) {
+	input := `proc (f : (int -> bool), x : int) -x`
+	expectedTokens := ExpectedTokens{
+		{Type: token.PROC, Literal: "proc"},
+		{Type: token.LPAREN, Literal: "("},
+		{Type: token.IDENT, Literal: "f"},
+		{Type: token.COLON, Literal: ":"},
+		{Type: token.LPAREN, Literal: "("},
+		{Type: token.IDENT, Literal: "int"},
+		{Type: token.ARROW, Literal: "->"},
+		{Type: token.IDENT, Literal: "bool"},
+		{Type: token.RPAREN, Literal: ")"},
+		{Type: token.COMMA, Literal: ","},
+		{Type: token.IDENT, Literal: "x"},
+		{Type: token.COLON, Literal: ":"},
+		{Type: token.IDENT, Literal: "int"},
+		{Type: token.RPAREN, Literal: ")"},
+		{Type: token.SUB, Literal: "-"},
+		{Type: token.IDENT, Literal: "x"},
+		{Type: token.EOF, Literal: ""},
+	}
+	checkTokens(t, input, expectedTokens)
+}
+
+func TestStringLex(t *testing.T) {
+	input := `"hello" "a \"quoted\" word\n\tand \\" ""`
+	expectedTokens := ExpectedTokens{
+		{Type: token.STRING, Literal: "hello"},
+		{Type: token.STRING, Literal: "a \"quoted\" word\n\tand \\"},
+		{Type: token.STRING, Literal: ""},
+		{Type: token.EOF, Literal: ""},
+	}
+	checkTokens(t, input, expectedTokens)
+}
+
+func TestHyphenatedIdentLex(t *testing.T) {
+	input := `string-length number->string string=? a-b x-1 x - y`
+	expectedTokens := ExpectedTokens{
+		{Type: token.IDENT, Literal: "string-length"},
+		{Type: token.IDENT, Literal: "number->string"},
+		{Type: token.IDENT, Literal: "string=?"},
+		{Type: token.IDENT, Literal: "a"},
+		{Type: token.SUB, Literal: "-"},
+		{Type: token.IDENT, Literal: "b"},
+		{Type: token.IDENT, Literal: "x"},
+		{Type: token.SUB, Literal: "-"},
+		{Type: token.INT, Literal: "1"},
+		{Type: token.IDENT, Literal: "x"},
+		{Type: token.SUB, Literal: "-"},
+		{Type: token.IDENT, Literal: "y"},
+		{Type: token.EOF, Literal: ""},
+	}
+	checkTokens(t, input, expectedTokens)
+}
 
-		{token.EOF, ""},
-		{token.EOF, ""},
-		{token.EOF, ""},
-		{token.EOF, ""},
-		{token.EOF, ""},
-		{token.EOF, ""},
-		{token.EOF, ""},
-		{token.EOF, ""},
+func lexAll(input string) *Lexer {
+	lexer := New(input)
+	for tok := lexer.NextToken(); tok.Type != token.EOF; tok = lexer.NextToken() {
+	}
+	return lexer
+}
+
+func TestStringErrors(t *testing.T) {
+	cases := []struct {
+		input    string
+		expected []string
+	}{
+		{`"ok"`, nil},
+		{"let x = 1 in\n  \"never closed", []string{"2:3: unterminated string"}},
+		{`"ends in an escape\`, []string{"1:1: unterminated string"}},
+		{`"bad \q escape" "fine"`, []string{`1:6: unknown escape sequence \q`}},
+	}
+	for _, tc := range cases {
+		errors := lexAll(tc.input).Errors()
+		if len(errors) != len(tc.expected) {
+			t.Fatalf("Expected %d error(s) lexing %q but got %v", len(tc.expected), tc.input, errors)
+		}
+		for i, err := range errors {
+			if err != tc.expected[i] {
+				t.Errorf("Expected error %q lexing %q but got %q", tc.expected[i], tc.input, err)
+			}
+		}
+	}
+	if tok := New(`"open`).NextToken(); tok.Type != token.ILLEGAL {
+		t.Errorf("Expected an unterminated string to be %s but was %s", token.ILLEGAL, tok.Type)
+	}
+}
+
+func TestCommentsSkipped(t *testing.T) {
+	input := `% an EOPL comment
+let x = 1 // to the end of the line
+/* a block /* with a nested */ comment */ in x %last`
+	expectedTokens := ExpectedTokens{
+		{Type: token.LET, Literal: "let"},
+		{Type: token.IDENT, Literal: "x"},
+		{Type: token.ASSIGN, Literal: "="},
+		{Type: token.INT, Literal: "1"},
+		{Type: token.IN, Literal: "in"},
+		{Type: token.IDENT, Literal: "x"},
+		{Type: token.EOF, Literal: ""},
 	}
 	checkTokens(t, input, expectedTokens)
 }
+
+func TestCommentsKept(t *testing.T) {
+	input := "1 % one\n/* a /* b */ */ 2 / 3 //end"
+	expected := []token.Token{
+		{Type: token.INT, Literal: "1", Line: 1, Column: 1},
+		{Type: token.COMMENT, Literal: "% one", Line: 1, Column: 3},
+		{Type: token.COMMENT, Literal: "/* a /* b */ */", Line: 2, Column: 1},
+		{Type: token.INT, Literal: "2", Line: 2, Column: 17},
+		{Type: token.SLASH, Literal: "/", Line: 2, Column: 19},
+		{Type: token.INT, Literal: "3", Line: 2, Column: 21},
+		{Type: token.COMMENT, Literal: "//end", Line: 2, Column: 23},
+		{Type: token.EOF, Literal: "", Line: 2, Column: 28},
+	}
+	lexer := NewWithComments(input)
+	for i, e := range expected {
+		tok := lexer.NextToken()
+		if tok != e {
+			t.Fatalf("token[%d] - expected=%+v, got=%+v", i, e, tok)
+		}
+	}
+}
+
+func TestUnterminatedComment(t *testing.T) {
+	lexer := lexAll("1 /* open /* nested */ still open")
+	errors := lexer.Errors()
+	if len(errors) != 1 || errors[0] != "1:3: unterminated comment" {
+		t.Fatalf("Expected one unterminated comment error at 1:3 but got %v", errors)
+	}
+}
@@ -4,9 +4,13 @@ import (
 	"let_lang_proj_michael_andrepont/ast"
 	"let_lang_proj_michael_andrepont/evaluator"
 	"let_lang_proj_michael_andrepont/lexer"
+	"let_lang_proj_michael_andrepont/nameless"
 	"let_lang_proj_michael_andrepont/parser"
 	"let_lang_proj_michael_andrepont/token"
+	"let_lang_proj_michael_andrepont/typecheck"
+	"let_lang_proj_michael_andrepont/vm"
 	"bufio"
+	"flag"
 	"fmt"
 	"io/ioutil"
 	"log"
@@ -14,10 +18,32 @@ import (
 	"strings"
 )
 
+var printStore = flag.Bool("store", false, "print the contents of the store after the result")
+var timeSlice = flag.Int("time-slice", ast.DefaultTimeSlice, "number of steps a thread runs before it is preempted")
+var backend = flag.String("backend", "direct", "evaluator to run the program with: direct, cps, nameless or vm")
+var noTypecheck = flag.Bool("no-typecheck", false, "evaluate the program even if it does not type check")
+var printTypes = flag.Bool("types", false, "print the AST with the inferred type of every subexpression")
+var printNameless = flag.Bool("nameless", false, "print the program with its variables replaced by lexical addresses")
+var implicitRefs = flag.Bool("implicit-refs", false, "bind every variable to a location so it can be assigned with set, classes require it")
+var bignums = flag.Bool("bignum", false, "use arbitrary precision integers, literals of any length can be used")
+var checkOverflow = flag.Bool("check-overflow", false, "fail with an overflow error instead of wrapping around on integer overflow")
+var rationals = flag.Bool("rationals", false, "make quotient of ints an exact rational instead of truncating it")
+var disasm = flag.Bool("disasm", false, "print the bytecode the program is compiled to for the vm backend")
+
 func main() {
+	flag.Parse()
+	if *timeSlice < 1 {
+		log.Fatalf("--time-slice must be at least 1, got %d", *timeSlice)
+	}
+	if _, ok := backends[*backend]; !ok {
+		log.Fatalf("Unknown --backend %q, expected direct, cps, nameless or vm", *backend)
+	}
+	if *disasm && *backend != "vm" {
+		log.Fatalf("--disasm requires --backend vm, got --backend %s", *backend)
+	}
 	fileName := ""
-	if len(os.Args) == 2 {
-		fileName = os.Args[1]
+	if flag.NArg() == 1 {
+		fileName = flag.Arg(0)
 	} else {
 		fmt.Print("Please input the file that contains the let program: ")
 		reader := bufio.NewReader(os.Stdin)
@@ -34,6 +60,18 @@ func main() {
 	fmt.Println(text)
 	tokens := getTokenList(text)
 	root := getAst(tokens)
+	if !*noTypecheck {
+		printType(root)
+	}
+	if *printNameless || *backend == "nameless" {
+		translated := translateNameless(root)
+		if *backend == "nameless" {
+			root = translated
+		}
+	}
+	if *disasm {
+		printBytecode(root)
+	}
 	printEvalResult(root)
 }
 
@@ -50,29 +88,115 @@ func getTokenList(lexerInput string) []token.Token {
 	for _, t := range tokens {
 		fmt.Printf("%+v\n", t)
 	}
+	if len(lxr.Errors()) > 0 {
+		fmt.Println("\nLexer errors:")
+		for _, err := range lxr.Errors() {
+			fmt.Println(err)
+		}
+		log.Fatalf("Could not lex the program with %d error(s)", len(lxr.Errors()))
+	}
 	return tokens
 }
 
-func getAst(tokens []token.Token) ast.Node {
+func getAst(tokens []token.Token) *ast.Program {
 	prs := parser.New(tokens)
-	expr := prs.ParseExpression()
+	program := prs.ParseProgram()
 	if len(prs.Errors()) > 0 {
 		for _, err := range prs.Errors() {
 			log.Fatalf(err)
 		}
 	}
 	fmt.Println("\nAST without env:")
-	expr.Print(0)
-	return expr
+	program.Print(0)
+	return program
+}
+
+var backends = map[string]func(ast.Node, *ast.Runtime) (ast.ExpVal, error){
+	"direct": evaluator.EvalProgram,
+	"cps":    evaluator.EvalProgramCPS,
+	//The nameless nodes have their own Eval methods, the translated program runs like any other.
+	"nameless": evaluator.EvalProgram,
+	"vm":       vm.EvalProgram,
 }
 
-func printEvalResult(root ast.Node) {
-	res, err := evaluator.EvalProgram(root)
+func printBytecode(root *ast.Program) {
+	compiled, err := vm.CompileProgram(root)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println("\nBytecode:")
+	fmt.Print(compiled.Chunk.Disassemble())
+}
+
+//translateNameless translates the program for the nameless evaluator, exiting on unbound
+//variables and constructs it does not support.
+func translateNameless(root *ast.Program) *ast.Program {
+	translated, errs := nameless.TranslateProgram(root)
+	if len(errs) > 0 {
+		fmt.Println("\nTranslation errors:")
+		for _, err := range errs {
+			fmt.Println(err)
+		}
+		log.Fatalf("Could not translate the program with %d error(s)", len(errs))
+	}
+	if *printNameless {
+		fmt.Println("\nNameless program:")
+		translated.Print(0)
+	}
+	return translated
+}
+
+//printType prints the type of the program, or its type errors and exits without evaluating it.
+//Constructs the checker does not support are only warned about, the program still runs.
+func printType(root *ast.Program) {
+	inference := typecheck.InferProgram(root)
+	if *printTypes {
+		inference.Annotate()
+		fmt.Println("\nAST with types:")
+		root.Print(0)
+	}
+	//The checker types quotient as int, in rationals mode it can give a rational.
+	fullyChecked := len(inference.Unsupported) == 0 && !*rationals
+	if !fullyChecked {
+		fmt.Println("\nType checker warnings:")
+		for _, warning := range inference.Unsupported {
+			fmt.Println(warning)
+		}
+		if *rationals {
+			fmt.Println("the type checker does not support --rationals")
+		}
+	}
+	if len(inference.Errors) > 0 {
+		fmt.Println("\nType errors:")
+		for _, err := range inference.Errors {
+			fmt.Println(err)
+		}
+		log.Fatalf("Refusing to evaluate a program with %d type error(s), run with --no-typecheck to evaluate it anyway",
+			len(inference.Errors))
+	}
+	if !fullyChecked {
+		fmt.Println("\nType:  not fully checked,", inference.Type)
+		return
+	}
+	fmt.Println("\nType: ", inference.Type)
+}
+
+func printEvalResult(root *ast.Program) {
+	rt := ast.NewRuntime()
+	rt.ImplicitRefs = *implicitRefs
+	rt.Bignums = *bignums
+	rt.CheckOverflow = *checkOverflow
+	rt.Rationals = *rationals
+	rt.Scheduler = ast.NewScheduler(*timeSlice)
+	res, err := backends[*backend](root, rt)
 	if err != nil {
 		log.Fatal(err)
 		return
 	}
 	fmt.Println("\nAST with env:")
 	root.Print(0)
-	fmt.Println("\nExpression Result: ", res)
+	fmt.Println("\nExpression Result: ", rt.Show(res))
+	if *printStore {
+		fmt.Println("\nStore: ", rt.Store.String())
+	}
 }
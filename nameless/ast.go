@@ -0,0 +1,151 @@
+package nameless
+
+import (
+	"let_lang_proj_michael_andrepont/ast"
+	"fmt"
+	"strings"
+)
+
+//The nameless nodes replace the nodes that bind or reference variables. Their envs are the
+//same BindingLists the Eval methods use, but a variable is found by its position, the names
+//are only kept so the envs and procs print the same way.
+
+func indentStr(indentLevel int) string {
+	return strings.Repeat("\t", indentLevel)
+}
+
+//VarExpression is a variable reference replaced by its lexical address, the number of
+//bindings in front of it in the env.
+type VarExpression struct {
+	ast.BaseExpression
+	Depth int
+}
+
+func (e *VarExpression) Eval(env ast.BindingList, rt *ast.Runtime) (ast.ExpVal, error) {
+	e.SetEnv(&env)
+	return rt.LookupAddress(e.Depth, env)
+}
+func (e *VarExpression) Print(indentLevel int) {
+	fmt.Printf("%s(%d) %s\n", indentStr(indentLevel), e.Depth, ast.GetEnvStr(e.GetEnv()))
+}
+
+//LetExpression binds its values in front of the env In is evaluated in, a let with several
+//bindings has all of them in Values.
+type LetExpression struct {
+	ast.BaseExpression
+	Names  []string
+	Values []ast.Expression
+	In     ast.Expression
+}
+
+func (e *LetExpression) Eval(env ast.BindingList, rt *ast.Runtime) (ast.ExpVal, error) {
+	e.SetEnv(&env)
+	newEnv := make(ast.BindingList, 0, len(e.Values)+len(env))
+	for i, valueExpr := range e.Values {
+		value, err := valueExpr.Eval(env, rt)
+		if err != nil {
+			return nil, err
+		}
+		newEnv = append(newEnv, rt.NewBinding(e.Names[i], value))
+	}
+	return e.In.Eval(append(newEnv, env...), rt)
+}
+func (e *LetExpression) Print(indentLevel int) {
+	fmt.Printf("%s%s %s\n", indentStr(indentLevel), "nameless-let", ast.GetEnvStr(e.GetEnv()))
+	for _, value := range e.Values {
+		value.Print(indentLevel + 1)
+	}
+	e.In.Print(indentLevel + 1)
+}
+
+type ProcExpression struct {
+	ast.BaseExpression
+	Params []string
+	Body   ast.Expression
+}
+
+func (e *ProcExpression) Eval(env ast.BindingList, rt *ast.Runtime) (ast.ExpVal, error) {
+	e.SetEnv(&env)
+	return &ast.ProcVal{Params: e.Params, Body: e.Body, Env: env}, nil
+}
+func (e *ProcExpression) Print(indentLevel int) {
+	fmt.Printf("%s%s %d %s\n", indentStr(indentLevel), "nameless-proc", len(e.Params), ast.GetEnvStr(e.GetEnv()))
+	e.Body.Print(indentLevel + 1)
+}
+
+type LetrecProc struct {
+	Name   string
+	Params []string
+	Body   ast.Expression
+}
+
+type LetrecExpression struct {
+	ast.BaseExpression
+	Procs []*LetrecProc
+	In    ast.Expression
+}
+
+func (e *LetrecExpression) Eval(env ast.BindingList, rt *ast.Runtime) (ast.ExpVal, error) {
+	e.SetEnv(&env)
+	newEnv := make(ast.BindingList, 0, len(e.Procs)+len(env))
+	for i, proc := range e.Procs {
+		newEnv = append(newEnv, ast.Binding{
+			VarName: proc.Name,
+			Rec:     &ast.RecProc{Params: proc.Params, Body: proc.Body, Index: i},
+		})
+	}
+	return e.In.Eval(append(newEnv, env...), rt)
+}
+func (e *LetrecExpression) Print(indentLevel int) {
+	fmt.Printf("%s%s %s\n", indentStr(indentLevel), "nameless-letrec", ast.GetEnvStr(e.GetEnv()))
+	for _, proc := range e.Procs {
+		fmt.Printf("%s%s %d\n", indentStr(indentLevel+1), "nameless-proc", len(proc.Params))
+		proc.Body.Print(indentLevel + 2)
+	}
+	e.In.Print(indentLevel + 1)
+}
+
+type SetExpression struct {
+	ast.BaseExpression
+	Depth int
+	Value ast.Expression
+}
+
+func (e *SetExpression) Eval(env ast.BindingList, rt *ast.Runtime) (ast.ExpVal, error) {
+	e.SetEnv(&env)
+	if !rt.ImplicitRefs {
+		return nil, ast.ErrSetWithoutImplicitRefs
+	}
+	value, err := e.Value.Eval(env, rt)
+	if err != nil {
+		return nil, err
+	}
+	return rt.SetAddress(e.Depth, value, env)
+}
+func (e *SetExpression) Print(indentLevel int) {
+	fmt.Printf("%s%s (%d) %s\n", indentStr(indentLevel), "nameless-set", e.Depth, ast.GetEnvStr(e.GetEnv()))
+	e.Value.Print(indentLevel + 1)
+}
+
+//TryExpression binds the raised value at address 0 of the handler's env.
+type TryExpression struct {
+	ast.BaseExpression
+	Var     string
+	Body    ast.Expression
+	Handler ast.Expression
+}
+
+func (e *TryExpression) Eval(env ast.BindingList, rt *ast.Runtime) (ast.ExpVal, error) {
+	e.SetEnv(&env)
+	result, err := e.Body.Eval(env, rt)
+	exception, ok := err.(*ast.RaisedException)
+	if !ok {
+		return result, err
+	}
+	return e.Handler.Eval(append(ast.BindingList{rt.NewBinding(e.Var, exception.Value)}, env...), rt)
+}
+func (e *TryExpression) Print(indentLevel int) {
+	fmt.Printf("%s%s %s\n", indentStr(indentLevel), "nameless-try-catch", ast.GetEnvStr(e.GetEnv()))
+	e.Body.Print(indentLevel + 1)
+	e.Handler.Print(indentLevel + 1)
+}
@@ -0,0 +1,192 @@
+//Package nameless replaces every variable of a program with its lexical address, so the
+//evaluator finds a variable by its position in the env instead of comparing names. Unbound
+//variables are found here, before the program runs.
+package nameless
+
+import (
+	"let_lang_proj_michael_andrepont/ast"
+	"let_lang_proj_michael_andrepont/token"
+	"fmt"
+)
+
+//Error is a translation error at the token it was found at.
+type Error struct {
+	Token   token.Token
+	Message string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%d:%d: %s", e.Token.Line, e.Token.Column, e.Message)
+}
+
+//staticEnv holds the names of the bindings the env will have at runtime, in the same order.
+type staticEnv []string
+
+func (senv staticEnv) extend(names ...string) staticEnv {
+	return append(append(make(staticEnv, 0, len(names)+len(senv)), names...), senv...)
+}
+
+func (senv staticEnv) address(name string) (int, bool) {
+	for i, boundName := range senv {
+		if boundName == name {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+type translator struct {
+	errors []*Error
+}
+
+func (t *translator) errorf(tok token.Token, format string, args ...interface{}) {
+	t.errors = append(t.errors, &Error{Token: tok, Message: fmt.Sprintf(format, args...)})
+}
+
+//Translate returns the nameless version of expr and every error found translating it.
+func Translate(expr ast.Expression) (ast.Expression, []*Error) {
+	t := &translator{}
+	translated := t.translate(expr, staticEnv{})
+	return translated, t.errors
+}
+
+//TranslateProgram translates the body of a program, modules and classes are not supported.
+func TranslateProgram(program *ast.Program) (*ast.Program, []*Error) {
+	t := &translator{}
+	for _, module := range program.Modules {
+		t.errorf(module.Token, "the nameless translator does not support module")
+	}
+	for _, class := range program.Classes {
+		t.errorf(class.Token, "the nameless translator does not support class")
+	}
+	translated := &ast.Program{Body: t.translate(program.Body, staticEnv{})}
+	return translated, t.errors
+}
+
+func (t *translator) translate(expr ast.Expression, senv staticEnv) ast.Expression {
+	base := ast.BaseExpression{Token: expr.GetToken()}
+	switch e := expr.(type) {
+	case *ast.IntLiteral:
+		return &ast.IntLiteral{BaseExpression: base, Value: e.Value, Big: e.Big}
+	case *ast.FloatLiteral:
+		return &ast.FloatLiteral{BaseExpression: base, Value: e.Value}
+	case *ast.StringLiteral:
+		return &ast.StringLiteral{BaseExpression: base, Value: e.Value}
+	case *ast.BoolLiteral:
+		return &ast.BoolLiteral{BaseExpression: base, Value: e.Value}
+	case *ast.EmptyListLiteral:
+		return &ast.EmptyListLiteral{BaseExpression: base}
+	case *ast.Identifier:
+		depth, ok := senv.address(e.Value)
+		if !ok {
+			t.errorf(e.Token, "unbound variable %s", e.Value)
+		}
+		return &VarExpression{BaseExpression: base, Depth: depth}
+	case *ast.LetExpression:
+		return &LetExpression{
+			BaseExpression: base,
+			Names:          []string{e.Name.Value},
+			Values:         []ast.Expression{t.translate(e.Value, senv)},
+			In:             t.translate(e.In, senv.extend(e.Name.Value)),
+		}
+	case *ast.MultiLetExpression:
+		let := &LetExpression{BaseExpression: base}
+		for _, binding := range e.Bindings {
+			let.Names = append(let.Names, binding.Name.Value)
+			let.Values = append(let.Values, t.translate(binding.Value, senv))
+		}
+		let.In = t.translate(e.In, senv.extend(let.Names...))
+		return let
+	case *ast.LetStarExpression:
+		return t.translateLetStar(e, 0, senv)
+	case *ast.MinusExpression:
+		return &ast.MinusExpression{BaseExpression: base, Arg1: t.translate(e.Arg1, senv), Arg2: t.translate(e.Arg2, senv)}
+	case *ast.IsZeroExpression:
+		return &ast.IsZeroExpression{BaseExpression: base, Arg1: t.translate(e.Arg1, senv)}
+	case *ast.PrimAppExpression:
+		return &ast.PrimAppExpression{BaseExpression: base, Name: e.Name, Args: t.translateAll(e.Args, senv)}
+	case *ast.IfThenElseExpression:
+		return &ast.IfThenElseExpression{
+			BaseExpression: base,
+			Value:          t.translate(e.Value, senv),
+			TrueBranch:     t.translate(e.TrueBranch, senv),
+			FalseBranch:    t.translate(e.FalseBranch, senv),
+		}
+	case *ast.ProcExpression:
+		params := names(e.Params)
+		return &ProcExpression{BaseExpression: base, Params: params, Body: t.translate(e.Body, senv.extend(params...))}
+	case *ast.CallExpression:
+		return &ast.CallExpression{BaseExpression: base, Operator: t.translate(e.Operator, senv), Operands: t.translateAll(e.Operands, senv)}
+	case *ast.LetrecExpression:
+		letrec := &LetrecExpression{BaseExpression: base}
+		var procNames []string
+		for _, proc := range e.Procs {
+			procNames = append(procNames, proc.Name.Value)
+		}
+		//The procs see each other, and their params in front of that.
+		recEnv := senv.extend(procNames...)
+		for _, proc := range e.Procs {
+			params := names(proc.Params)
+			letrec.Procs = append(letrec.Procs, &LetrecProc{
+				Name:   proc.Name.Value,
+				Params: params,
+				Body:   t.translate(proc.Body, recEnv.extend(params...)),
+			})
+		}
+		letrec.In = t.translate(e.In, recEnv)
+		return letrec
+	case *ast.BeginExpression:
+		return &ast.BeginExpression{BaseExpression: base, Exprs: t.translateAll(e.Exprs, senv)}
+	case *ast.SetExpression:
+		depth, ok := senv.address(e.Name.Value)
+		if !ok {
+			t.errorf(e.Name.Token, "unbound variable %s", e.Name.Value)
+		}
+		return &SetExpression{BaseExpression: base, Depth: depth, Value: t.translate(e.Value, senv)}
+	case *ast.RaiseExpression:
+		return &ast.RaiseExpression{BaseExpression: base, Value: t.translate(e.Value, senv)}
+	case *ast.TryExpression:
+		return &TryExpression{
+			BaseExpression: base,
+			Var:            e.Var.Value,
+			Body:           t.translate(e.Body, senv),
+			Handler:        t.translate(e.Handler, senv.extend(e.Var.Value)),
+		}
+	}
+	name := base.Token.Literal
+	if name == "" {
+		name = fmt.Sprintf("%T", expr)
+	}
+	t.errorf(base.Token, "the nameless translator does not support %s", name)
+	return expr
+}
+
+//translateLetStar nests a let for each binding from i on, so each value sees the ones before.
+func (t *translator) translateLetStar(e *ast.LetStarExpression, i int, senv staticEnv) ast.Expression {
+	if i == len(e.Bindings) {
+		return t.translate(e.In, senv)
+	}
+	binding := e.Bindings[i]
+	return &LetExpression{
+		BaseExpression: ast.BaseExpression{Token: e.Token},
+		Names:          []string{binding.Name.Value},
+		Values:         []ast.Expression{t.translate(binding.Value, senv)},
+		In:             t.translateLetStar(e, i+1, senv.extend(binding.Name.Value)),
+	}
+}
+
+func (t *translator) translateAll(exprs []ast.Expression, senv staticEnv) []ast.Expression {
+	translated := make([]ast.Expression, len(exprs))
+	for i, expr := range exprs {
+		translated[i] = t.translate(expr, senv)
+	}
+	return translated
+}
+
+func names(idents []*ast.Identifier) []string {
+	names := make([]string, len(idents))
+	for i, ident := range idents {
+		names[i] = ident.Value
+	}
+	return names
+}
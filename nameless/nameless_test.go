@@ -0,0 +1,149 @@
+package nameless
+
+import (
+	"let_lang_proj_michael_andrepont/ast"
+	"let_lang_proj_michael_andrepont/evaluator"
+	"let_lang_proj_michael_andrepont/lexer"
+	"let_lang_proj_michael_andrepont/parser"
+	"let_lang_proj_michael_andrepont/token"
+	"strings"
+	"testing"
+)
+
+func parseProgram(t *testing.T, input string) *ast.Program {
+	lxr := lexer.New(input)
+	tokens := []token.Token{}
+	for tok := lxr.NextToken(); tok.Type != token.EOF; tok = lxr.NextToken() {
+		tokens = append(tokens, tok)
+	}
+	tokens = append(tokens, token.Token{Type: token.EOF, Literal: ""})
+	prs := parser.New(tokens)
+	program := prs.ParseProgram()
+	if len(prs.Errors()) != 0 {
+		t.Fatalf("Could not parse %q: %s", input, strings.Join(prs.Errors(), "; "))
+	}
+	return program
+}
+
+//checkNameless checks that the nameless evaluator gives input the same result as the Eval methods.
+func checkNameless(t *testing.T, input string, expected string) {
+	translated, errs := TranslateProgram(parseProgram(t, input))
+	if len(errs) != 0 {
+		t.Fatalf("Expected %q to translate but got errors: %v", input, errs)
+	}
+	result, err := evaluator.EvalProgram(translated, ast.NewRuntime())
+	if err != nil {
+		t.Fatalf("Could not evaluate %q: %s", input, err)
+	}
+	named, err := evaluator.EvalProgram(parseProgram(t, input), ast.NewRuntime())
+	if err != nil {
+		t.Fatalf("Could not evaluate %q with names: %s", input, err)
+	}
+	if result.String() != expected || named.String() != expected {
+		t.Errorf("Expected %q to be %s but the nameless evaluator gave %s and Eval gave %s", input, expected, result, named)
+	}
+}
+
+func checkTranslateErrors(t *testing.T, input string, expected ...string) {
+	_, errs := TranslateProgram(parseProgram(t, input))
+	if len(errs) != len(expected) {
+		t.Fatalf("Expected %d translation error(s) for %q but got %d: %v", len(expected), input, len(errs), errs)
+	}
+	for i, err := range errs {
+		if !strings.Contains(err.Error(), expected[i]) {
+			t.Errorf("Expected translation error to contain: [%s] but was: [%s]", expected[i], err.Error())
+		}
+	}
+}
+
+func TestLexicalAddresses(t *testing.T) {
+	translated, errs := Translate(parseProgram(t, "let x = 1 in let y = 2 in proc (a, b) list(a, b, y, x)").Body)
+	if len(errs) != 0 {
+		t.Fatalf("Expected no translation errors but got %v", errs)
+	}
+	proc := translated.(*LetExpression).In.(*LetExpression).In.(*ProcExpression)
+	args := proc.Body.(*ast.PrimAppExpression).Args
+	for i, expected := range []int{0, 1, 2, 3} {
+		if depth := args[i].(*VarExpression).Depth; depth != expected {
+			t.Errorf("Expected argument %d to have address (%d) but was (%d)", i+1, expected, depth)
+		}
+	}
+}
+
+func TestLetrecAddresses(t *testing.T) {
+	translated, errs := Translate(parseProgram(t, "let x = 1 in letrec f(a) = (g a x) g(b, c) = (f b) in g").Body)
+	if len(errs) != 0 {
+		t.Fatalf("Expected no translation errors but got %v", errs)
+	}
+	letrec := translated.(*LetExpression).In.(*LetrecExpression)
+	call := letrec.Procs[0].Body.(*ast.CallExpression)
+	//f's body sees a, then f and g, then x.
+	depths := []int{call.Operator.(*VarExpression).Depth, call.Operands[0].(*VarExpression).Depth, call.Operands[1].(*VarExpression).Depth}
+	if depths[0] != 2 || depths[1] != 0 || depths[2] != 3 {
+		t.Errorf("Expected (g a x) to be ((2) (0) (3)) but was %v", depths)
+	}
+	if depth := letrec.In.(*VarExpression).Depth; depth != 1 {
+		t.Errorf("Expected g to have address (1) in the letrec body but was (%d)", depth)
+	}
+}
+
+func TestNamelessEval(t *testing.T) {
+	checkNameless(t, "let x = 3 y = 4 in minus(x, y)", "-1")
+	checkNameless(t, "let x = 3 in let x = 4 in x", "4")
+	checkNameless(t, "let* x = 3 y = minus(x, 1) in list(x, y)", "(3 2)")
+	checkNameless(t, "let f = proc (x, y) minus(x, y) in (f 10 3)", "7")
+	checkNameless(t, "let a = 1 in let f = proc (x) proc (y) list(a, x, y) in ((f 2) 3)", "(1 2 3)")
+	checkNameless(t, `letrec even(n) = if zero?(n) then true else (odd minus(n, 1))
+	                         odd(n) = if zero?(n) then false else (even minus(n, 1))
+	                  in list((even 10), (odd 7), (odd 4))`, "(true true false)")
+	checkNameless(t, "let x = 1 in try raise 5 catch (e) minus(e, x)", "4")
+	checkNameless(t, "let x = 2 in begin 1; x end", "2")
+}
+
+func TestNamelessSet(t *testing.T) {
+	translated, errs := TranslateProgram(parseProgram(t, "let x = 1 in let y = 2 in begin set x = 5; list(x, y) end"))
+	if len(errs) != 0 {
+		t.Fatalf("Expected no translation errors but got %v", errs)
+	}
+	rt := ast.NewRuntime()
+	rt.ImplicitRefs = true
+	result, err := evaluator.EvalProgram(translated, rt)
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	if result.String() != "(5 2)" {
+		t.Errorf("Expected result to be (5 2) but was %s", result)
+	}
+	_, err = evaluator.EvalProgram(translated, ast.NewRuntime())
+	if err != ast.ErrSetWithoutImplicitRefs {
+		t.Errorf("Expected set without implicit refs to fail but got %v", err)
+	}
+}
+
+func TestUnboundVariables(t *testing.T) {
+	checkTranslateErrors(t, "let x = 1 in minus(y, z)", "1:20: unbound variable y", "1:23: unbound variable z")
+	checkTranslateErrors(t, "let f = proc (x) x in x", "1:23: unbound variable x")
+	checkTranslateErrors(t, "letrec f(x) = (g x) in 1", "1:16: unbound variable g")
+	checkTranslateErrors(t, "set x = 1", "1:5: unbound variable x")
+}
+
+func TestTranslateUnsupported(t *testing.T) {
+	checkTranslateErrors(t, "letcc k in 1", "1:1: the nameless translator does not support letcc")
+	checkTranslateErrors(t, "module m interface [] body [] 1", "1:1: the nameless translator does not support module")
+	checkTranslateErrors(t, "class c extends object 1", "1:1: the nameless translator does not support class")
+}
+
+func TestSetAddressUsesPosition(t *testing.T) {
+	rt := ast.NewRuntime()
+	rt.ImplicitRefs = true
+	//Both bindings are named x, set must assign the one at the address and not the first x.
+	env := ast.BindingList{rt.NewBinding("x", ast.NumVal{Value: 1}), rt.NewBinding("x", ast.NumVal{Value: 2})}
+	if _, err := rt.SetAddress(1, ast.NumVal{Value: 5}, env); err != nil {
+		t.Fatal(err.Error())
+	}
+	inner, _ := rt.LookupAddress(0, env)
+	outer, _ := rt.LookupAddress(1, env)
+	if inner.String() != "1" || outer.String() != "5" {
+		t.Errorf("Expected (0) to stay 1 and (1) to be 5 but were %s and %s", inner, outer)
+	}
+}
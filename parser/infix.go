@@ -0,0 +1,135 @@
+package parser
+
+import (
+	"let_lang_proj_michael_andrepont/ast"
+	"let_lang_proj_michael_andrepont/token"
+	"fmt"
+)
+
+//Precedences for the infix layer, higher binds tighter.
+const (
+	_ int = iota
+	LOWEST
+	EQUALS      // ==
+	LESSGREATER // < >
+	SUM         // + -
+	PRODUCT     // * /
+	PREFIX      // -x
+)
+
+var precedences = map[token.TokenType]int{
+	token.EQ:       EQUALS,
+	token.LT:       LESSGREATER,
+	token.GT:       LESSGREATER,
+	token.PLUS:     SUM,
+	token.SUB:      SUM,
+	token.ASTERISK: PRODUCT,
+	token.SLASH:    PRODUCT,
+}
+
+//infixPrimitives maps the operators to the primitive they are sugar for, minus is handled
+//separately so x - y gives the same MinusExpression as minus(x, y).
+var infixPrimitives = map[token.TokenType]string{
+	token.EQ:       "equal?",
+	token.LT:       "less?",
+	token.GT:       "greater?",
+	token.PLUS:     "plus",
+	token.ASTERISK: "times",
+	token.SLASH:    "quotient",
+}
+
+func (p *Parser) peekPrecedence() int {
+	if prec, ok := precedences[p.peekToken.Type]; ok {
+		return prec
+	}
+	return LOWEST
+}
+
+func (p *Parser) parseExpression(precedence int) ast.Expression {
+	errorCount := len(p.errors)
+	left := p.parsePrefix()
+	if left == nil || len(p.errors) > errorCount {
+		return left
+	}
+	return p.parseInfix(left, precedence)
+}
+
+//parseInfix keeps folding operators into left while they bind tighter than precedence.
+func (p *Parser) parseInfix(left ast.Expression, precedence int) ast.Expression {
+	for p.peekPrecedence() > precedence {
+		p.nextToken()
+		errorCount := len(p.errors)
+		left = p.parseInfixExpression(left)
+		if len(p.errors) > errorCount {
+			return left
+		}
+	}
+	return left
+}
+
+//parseOperand parses an operand of a call. A - that touches the token after it but not the one
+//before it, like in (f x -1), starts the next operand instead of subtracting from this one.
+func (p *Parser) parseOperand() ast.Expression {
+	errorCount := len(p.errors)
+	left := p.parsePrefix()
+	if left == nil || len(p.errors) > errorCount {
+		return left
+	}
+	for p.peekPrecedence() > LOWEST && !p.peekIsNegativeOperand() {
+		p.nextToken()
+		left = p.parseInfixExpression(left)
+		if len(p.errors) > errorCount {
+			return left
+		}
+	}
+	return left
+}
+
+func (p *Parser) peekIsNegativeOperand() bool {
+	if !p.peekTokenIs(token.SUB) || p.position+2 >= len(p.tokenQueue) {
+		return false
+	}
+	sub, next := p.peekToken, p.tokenQueue[p.position+2]
+	touchesNext := next.Line == sub.Line && next.Column == sub.Column+1
+	touchesPrev := p.currentToken.Line == sub.Line && p.currentToken.Column+len(p.currentToken.Literal) == sub.Column
+	return touchesNext && !touchesPrev
+}
+
+func (p *Parser) parseInfixExpression(left ast.Expression) ast.Expression {
+	opToken := p.currentToken
+	precedence := precedences[opToken.Type]
+
+	p.nextToken()
+	right := p.parseExpression(precedence)
+	if right == nil {
+		p.errors = append(p.errors, fmt.Sprintf("Missing inner expression for right side of %s", opToken.Literal))
+		return left
+	}
+
+	if opToken.Type == token.SUB {
+		return &ast.MinusExpression{BaseExpression: ast.BaseExpression{Token: opToken}, Arg1: left, Arg2: right}
+	}
+	return &ast.PrimAppExpression{
+		BaseExpression: ast.BaseExpression{Token: opToken},
+		Name:           infixPrimitives[opToken.Type],
+		Args:           []ast.Expression{left, right},
+	}
+}
+
+//parseNegation turns -x into minus(0, x).
+func (p *Parser) parseNegation() *ast.MinusExpression {
+	expr := &ast.MinusExpression{BaseExpression: ast.BaseExpression{Token: p.currentToken}}
+	expr.Arg1 = &ast.IntLiteral{
+		BaseExpression: ast.BaseExpression{Token: token.Token{Type: token.INT, Literal: "0",
+			Line: p.currentToken.Line, Column: p.currentToken.Column}},
+		Value: 0,
+	}
+
+	p.nextToken()
+	expr.Arg2 = p.parseExpression(PREFIX)
+	if expr.Arg2 == nil {
+		p.errors = append(p.errors, "Missing inner expression for Arg2")
+		return nil
+	}
+	return expr
+}
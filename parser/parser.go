@@ -4,6 +4,7 @@ import (
 	"let_lang_proj_michael_andrepont/ast"
 	"let_lang_proj_michael_andrepont/token"
 	"fmt"
+	"math/big"
 	"strconv"
 )
 
@@ -16,6 +17,18 @@ type Parser struct {
 }
 
 func New(tokenQueue []token.Token) *Parser {
+	//Comments kept by the lexer are not part of the program.
+	var withoutComments []token.Token
+	for _, tok := range tokenQueue {
+		if tok.Type != token.COMMENT {
+			withoutComments = append(withoutComments, tok)
+		}
+	}
+	//A queue of only comments still needs an EOF to peek at.
+	if len(withoutComments) == 0 || withoutComments[len(withoutComments)-1].Type != token.EOF {
+		withoutComments = append(withoutComments, token.Token{Type: token.EOF, Literal: ""})
+	}
+	tokenQueue = withoutComments
 	p := &Parser{tokenQueue: tokenQueue, position: -1, peekToken: tokenQueue[0]}
 	p.nextToken()
 	return p
@@ -54,54 +67,320 @@ func (p *Parser) expectPeek(t token.TokenType) bool {
 }
 
 func (p *Parser) ParseExpression() ast.Expression {
+	return p.parseExpression(LOWEST)
+}
+
+//ParseProgram parses the module and class declarations at the start of a program and the expression after
+//them, which must be the last thing in the token queue.
+func (p *Parser) ParseProgram() *ast.Program {
+	program := &ast.Program{}
+	for p.currentToken.Type == token.MODULE || p.currentToken.Type == token.CLASS {
+		if p.currentToken.Type == token.CLASS {
+			class := p.parseClassDecl()
+			if class == nil {
+				return nil
+			}
+			program.Classes = append(program.Classes, class)
+		} else {
+			module := p.parseModuleDefinition()
+			if module == nil {
+				return nil
+			}
+			program.Modules = append(program.Modules, module)
+		}
+		p.nextToken()
+	}
+
+	program.Body = p.ParseExpression()
+	if len(p.errors) > 0 {
+		return nil
+	}
+	if program.Body == nil {
+		p.errors = append(p.errors, "Missing inner expression for Body")
+		return nil
+	}
+	if !p.peekTokenIs(token.EOF) {
+		p.errors = append(p.errors, fmt.Sprintf("Expected the program to end, got %s instead", p.peekToken.Type))
+		return nil
+	}
+	return program
+}
+
+func (p *Parser) parseModuleDefinition() *ast.ModuleDefinition {
+	module := &ast.ModuleDefinition{Token: p.currentToken}
+	if !p.expectPeek(token.IDENT) {
+		return nil
+	}
+	module.Name = p.parseIdentifier()
+
+	if !p.expectPeek(token.INTERFACE) || !p.expectPeek(token.LBRACKET) {
+		return nil
+	}
+	for p.peekTokenIs(token.IDENT) {
+		p.nextToken()
+		decl := &ast.InterfaceDecl{Name: p.parseIdentifier()}
+		if !p.expectPeek(token.COLON) {
+			return nil
+		}
+		p.nextToken()
+		if decl.Type = p.parseType(); decl.Type == nil {
+			return nil
+		}
+		module.Interface = append(module.Interface, decl)
+	}
+	if !p.expectPeek(token.RBRACKET) {
+		return nil
+	}
+
+	if !p.expectPeek(token.BODY) || !p.expectPeek(token.LBRACKET) {
+		return nil
+	}
+	for p.peekTokenIs(token.IDENT) {
+		p.nextToken()
+		binding := &ast.LetBinding{Name: p.parseIdentifier()}
+		if !p.expectPeek(token.ASSIGN) {
+			return nil
+		}
+		p.nextToken()
+		binding.Value = p.ParseExpression()
+		if binding.Value == nil || len(p.errors) > 0 {
+			p.errors = append(p.errors, fmt.Sprintf("Missing inner expression for %s", binding.Name.Value))
+			return nil
+		}
+		module.Body = append(module.Body, binding)
+	}
+	if !p.expectPeek(token.RBRACKET) {
+		return nil
+	}
+	return module
+}
+
+func (p *Parser) parseClassDecl() *ast.ClassDecl {
+	class := &ast.ClassDecl{Token: p.currentToken}
+	if !p.expectPeek(token.IDENT) {
+		return nil
+	}
+	class.Name = p.parseIdentifier()
+	if !p.expectPeek(token.EXTENDS) || !p.expectPeek(token.IDENT) {
+		return nil
+	}
+	class.Super = p.parseIdentifier()
+
+	for p.peekTokenIs(token.FIELD) {
+		p.nextToken()
+		if !p.expectPeek(token.IDENT) {
+			return nil
+		}
+		class.Fields = append(class.Fields, p.parseIdentifier())
+	}
+	for p.peekTokenIs(token.METHOD) {
+		p.nextToken()
+		method := &ast.MethodDecl{Token: p.currentToken}
+		if !p.expectPeek(token.IDENT) {
+			return nil
+		}
+		method.Name = p.parseIdentifier()
+		params, paramTypes, ok := p.parseParams()
+		if !ok {
+			return nil
+		}
+		method.Params = params
+		method.ParamTypes = paramTypes
+		p.nextToken()
+		method.Body = p.ParseExpression()
+		if method.Body == nil || len(p.errors) > 0 {
+			p.errors = append(p.errors, fmt.Sprintf("Missing inner expression for %s", method.Name.Value))
+			return nil
+		}
+		class.Methods = append(class.Methods, method)
+	}
+	return class
+}
+
+func (p *Parser) parseNewObjectExpression() *ast.NewObjectExpression {
+	expr := &ast.NewObjectExpression{BaseExpression: ast.BaseExpression{Token: p.currentToken}}
+	if !p.expectPeek(token.IDENT) {
+		return nil
+	}
+	expr.Class = p.parseIdentifier()
+	args, ok := p.parseArguments()
+	if !ok {
+		return nil
+	}
+	expr.Args = args
+	return expr
+}
+
+func (p *Parser) parseMethodCallExpression() *ast.MethodCallExpression {
+	expr := &ast.MethodCallExpression{BaseExpression: ast.BaseExpression{Token: p.currentToken}}
+	p.nextToken()
+	//The method name ends the object expression, so it must not be read as a call operand.
+	expr.Object = p.parsePrefix()
+	if expr.Object == nil || len(p.errors) > 0 {
+		p.errors = append(p.errors, "Missing inner expression for Object")
+		return nil
+	}
+	if !p.expectPeek(token.IDENT) {
+		return nil
+	}
+	expr.Method = p.parseIdentifier()
+	args, ok := p.parseArguments()
+	if !ok {
+		return nil
+	}
+	expr.Args = args
+	return expr
+}
+
+func (p *Parser) parseSuperCallExpression() *ast.SuperCallExpression {
+	expr := &ast.SuperCallExpression{BaseExpression: ast.BaseExpression{Token: p.currentToken}}
+	if !p.expectPeek(token.IDENT) {
+		return nil
+	}
+	expr.Method = p.parseIdentifier()
+	args, ok := p.parseArguments()
+	if !ok {
+		return nil
+	}
+	expr.Args = args
+	return expr
+}
+
+func (p *Parser) parseQualifiedVarExpression() *ast.QualifiedVarExpression {
+	expr := &ast.QualifiedVarExpression{BaseExpression: ast.BaseExpression{Token: p.currentToken}}
+	if !p.expectPeek(token.IDENT) {
+		return nil
+	}
+	expr.Module = p.parseIdentifier()
+	if !p.expectPeek(token.TAKE) || !p.expectPeek(token.IDENT) {
+		return nil
+	}
+	expr.Var = p.parseIdentifier()
+	return expr
+}
+
+//parsePrefix parses a single expression that starts at the current token, without looking for
+//infix operators after it.
+func (p *Parser) parsePrefix() ast.Expression {
 	switch p.currentToken.Type {
-	case token.LET:
+	case token.LET, token.LET_STAR:
 		return p.parseLetExpression()
+	case token.LETREC:
+		return p.parseLetrecExpression()
 	case token.IDENT:
+		if _, ok := ast.LookupPrimitive(p.currentToken.Literal); ok && p.peekTokenIs(token.LPAREN) {
+			return p.parsePrimAppExpression()
+		}
 		return p.parseIdentifier()
 	case token.INT:
 		return p.parseIntLiteral()
+	case token.FLOAT:
+		return p.parseFloatLiteral()
+	case token.STRING:
+		return &ast.StringLiteral{BaseExpression: ast.BaseExpression{Token: p.currentToken}, Value: p.currentToken.Literal}
+	case token.TRUE, token.FALSE:
+		return p.parseBoolLiteral()
+	case token.EMPTY_LIST:
+		return &ast.EmptyListLiteral{BaseExpression: ast.BaseExpression{Token: p.currentToken}}
 	case token.MINUS:
 		return p.parseMinusExpression()
 	case token.IS_ZERO:
 		return p.parseIsZeroExpression()
 	case token.IF:
 		return p.parseIfThenElseExpression()
+	case token.PROC:
+		return p.parseProcExpression()
+	case token.BEGIN:
+		return p.parseBeginExpression()
+	case token.SET:
+		return p.parseSetExpression()
+	case token.TRY:
+		return p.parseTryExpression()
+	case token.RAISE:
+		return p.parseRaiseExpression()
+	case token.FROM:
+		return p.parseQualifiedVarExpression()
+	case token.NEW:
+		return p.parseNewObjectExpression()
+	case token.SEND:
+		return p.parseMethodCallExpression()
+	case token.SUPER:
+		return p.parseSuperCallExpression()
+	case token.SELF:
+		return &ast.SelfExpression{BaseExpression: ast.BaseExpression{Token: p.currentToken}}
+	case token.LETCC:
+		return p.parseLetccExpression()
+	case token.THROW:
+		return p.parseThrowExpression()
+	case token.LPAREN:
+		return p.parseParenExpression()
+	case token.SUB:
+		return p.parseNegation()
 	}
 	return nil
 }
 
-func (p *Parser) parseLetExpression() *ast.LetExpression {
-	expr := &ast.LetExpression{BaseExpression: ast.BaseExpression{Token: p.currentToken}}
-
-	if !p.expectPeek(token.IDENT) {
-		return nil
+func (p *Parser) parseLetExpression() ast.Expression {
+	baseExpr := ast.BaseExpression{Token: p.currentToken}
+	bindings, in, ok := p.parseLetParts()
+	if !ok {
+		//Keep returning a typed nil like the other parse functions.
+		return (*ast.LetExpression)(nil)
 	}
-	expr.Name = p.parseIdentifier()
 
-	if !p.expectPeek(token.ASSIGN) {
-		return nil
+	if baseExpr.Token.Type == token.LET_STAR {
+		return &ast.LetStarExpression{BaseExpression: baseExpr, Bindings: bindings, In: in}
+	}
+	if len(bindings) > 1 {
+		return &ast.MultiLetExpression{BaseExpression: baseExpr, Bindings: bindings, In: in}
 	}
+	return &ast.LetExpression{BaseExpression: baseExpr, Name: bindings[0].Name, Value: bindings[0].Value, In: in}
+}
 
-	p.nextToken()
-	expr.Value = p.ParseExpression()
-	if expr.Value == nil {
-		p.errors = append(p.errors, "Missing inner expression for Value")
-		return nil
+func (p *Parser) parseLetParts() ([]*ast.LetBinding, ast.Expression, bool) {
+	bindings, ok := p.parseLetBindings()
+	if !ok {
+		return nil, nil, false
 	}
 
 	if !p.expectPeek(token.IN) {
-		return nil
+		return nil, nil, false
 	}
 
 	p.nextToken()
-	expr.In = p.ParseExpression()
-	if expr.In == nil {
+	in := p.ParseExpression()
+	if in == nil {
 		p.errors = append(p.errors, "Missing inner expression for In")
-		return nil
+		return nil, nil, false
 	}
+	return bindings, in, true
+}
 
-	return expr
+//parseLetBindings parses one or more IDENT = expr pairs, stopping before the IN token.
+func (p *Parser) parseLetBindings() ([]*ast.LetBinding, bool) {
+	var bindings []*ast.LetBinding
+	for {
+		if !p.expectPeek(token.IDENT) {
+			return nil, false
+		}
+		binding := &ast.LetBinding{Name: p.parseIdentifier()}
+
+		if !p.expectPeek(token.ASSIGN) {
+			return nil, false
+		}
+
+		p.nextToken()
+		binding.Value = p.ParseExpression()
+		if binding.Value == nil {
+			p.errors = append(p.errors, "Missing inner expression for Value")
+			return nil, false
+		}
+		bindings = append(bindings, binding)
+
+		if !p.peekTokenIs(token.IDENT) {
+			return bindings, true
+		}
+	}
 }
 
 func (p *Parser) parseMinusExpression() *ast.MinusExpression {
@@ -134,6 +413,56 @@ func (p *Parser) parseMinusExpression() *ast.MinusExpression {
 	return expr
 }
 
+func (p *Parser) parsePrimAppExpression() *ast.PrimAppExpression {
+	expr := &ast.PrimAppExpression{
+		BaseExpression: ast.BaseExpression{Token: p.currentToken},
+		Name:           p.currentToken.Literal,
+	}
+	args, ok := p.parseArguments()
+	if !ok {
+		return nil
+	}
+	expr.Args = args
+
+	prim, _ := ast.LookupPrimitive(expr.Name)
+	if prim.Arity != ast.VariadicArity && len(expr.Args) != prim.Arity {
+		p.errors = append(p.errors, fmt.Sprintf("%s expects %d argument(s), got %d",
+			expr.Name, prim.Arity, len(expr.Args)))
+		return nil
+	}
+	return expr
+}
+
+//parseArguments parses a parenthesized, comma separated list of expressions after the current
+//token, like the arguments of a primitive or a method.
+func (p *Parser) parseArguments() ([]ast.Expression, bool) {
+	if !p.expectPeek(token.LPAREN) {
+		return nil, false
+	}
+
+	var args []ast.Expression
+	if !p.peekTokenIs(token.RPAREN) {
+		for {
+			p.nextToken()
+			arg := p.ParseExpression()
+			if arg == nil {
+				p.errors = append(p.errors, fmt.Sprintf("Missing inner expression for Arg%d", len(args)+1))
+				return nil, false
+			}
+			args = append(args, arg)
+			if !p.peekTokenIs(token.COMMA) {
+				break
+			}
+			p.nextToken()
+		}
+	}
+
+	if !p.expectPeek(token.RPAREN) {
+		return nil, false
+	}
+	return args, true
+}
+
 func (p *Parser) parseIsZeroExpression() *ast.IsZeroExpression {
 	expr := &ast.IsZeroExpression{BaseExpression: ast.BaseExpression{Token: p.currentToken}}
 	if !p.expectPeek(token.LPAREN) {
@@ -188,6 +517,297 @@ func (p *Parser) parseIfThenElseExpression() *ast.IfThenElseExpression {
 	return expr
 }
 
+func (p *Parser) parseProcExpression() *ast.ProcExpression {
+	expr := &ast.ProcExpression{BaseExpression: ast.BaseExpression{Token: p.currentToken}}
+	params, paramTypes, ok := p.parseParams()
+	if !ok {
+		return nil
+	}
+	expr.Params = params
+	expr.ParamTypes = paramTypes
+
+	p.nextToken()
+	expr.Body = p.ParseExpression()
+	if expr.Body == nil {
+		p.errors = append(p.errors, "Missing inner expression for Body")
+		return nil
+	}
+	return expr
+}
+
+//parseParams parses a parenthesized parameter list, each parameter can have a type annotation
+//like (x : int), paramTypes holds nil for the ones that do not.
+func (p *Parser) parseParams() ([]*ast.Identifier, []ast.Type, bool) {
+	if !p.expectPeek(token.LPAREN) {
+		return nil, nil, false
+	}
+
+	var params []*ast.Identifier
+	var paramTypes []ast.Type
+	if !p.peekTokenIs(token.RPAREN) {
+		for {
+			if !p.expectPeek(token.IDENT) {
+				return nil, nil, false
+			}
+			params = append(params, p.parseIdentifier())
+			var paramType ast.Type
+			if p.peekTokenIs(token.COLON) {
+				p.nextToken()
+				p.nextToken()
+				if paramType = p.parseType(); paramType == nil {
+					return nil, nil, false
+				}
+			}
+			paramTypes = append(paramTypes, paramType)
+			if !p.peekTokenIs(token.COMMA) {
+				break
+			}
+			p.nextToken()
+		}
+	}
+
+	if !p.expectPeek(token.RPAREN) {
+		return nil, nil, false
+	}
+	return params, paramTypes, true
+}
+
+//parseType parses a type starting at the current token, int, bool, string, a proc type like
+//(int * bool -> int) or ? for a type left to be inferred.
+func (p *Parser) parseType() ast.Type {
+	switch p.currentToken.Type {
+	case token.QUESTION:
+		return ast.UnknownType{}
+	case token.IDENT:
+		switch p.currentToken.Literal {
+		case "int":
+			return ast.IntType{}
+		case "bool":
+			return ast.BoolType{}
+		case "string":
+			return ast.StringType{}
+		}
+	case token.LPAREN:
+		procType := &ast.ProcType{}
+		if !p.peekTokenIs(token.ARROW) {
+			for {
+				p.nextToken()
+				paramType := p.parseType()
+				if paramType == nil {
+					return nil
+				}
+				procType.Params = append(procType.Params, paramType)
+				if !p.peekTokenIs(token.ASTERISK) {
+					break
+				}
+				p.nextToken()
+			}
+		}
+		if !p.expectPeek(token.ARROW) {
+			return nil
+		}
+		p.nextToken()
+		if procType.Result = p.parseType(); procType.Result == nil {
+			return nil
+		}
+		if !p.expectPeek(token.RPAREN) {
+			return nil
+		}
+		return procType
+	}
+	p.errors = append(p.errors, fmt.Sprintf("Expected a type, got %s instead", p.currentToken.Literal))
+	return nil
+}
+
+func (p *Parser) parseLetrecExpression() *ast.LetrecExpression {
+	expr := &ast.LetrecExpression{BaseExpression: ast.BaseExpression{Token: p.currentToken}}
+
+	p.nextToken()
+	for {
+		proc := &ast.LetrecProc{}
+		//An annotated proc starts with its result type, like int f(x : int) or (int -> int) f(x : int).
+		if p.currentToken.Type == token.LPAREN || p.currentToken.Type == token.QUESTION ||
+			(p.currentToken.Type == token.IDENT && p.peekTokenIs(token.IDENT)) {
+			if proc.ResultType = p.parseType(); proc.ResultType == nil {
+				return nil
+			}
+			p.nextToken()
+		}
+		if p.currentToken.Type != token.IDENT {
+			p.errors = append(p.errors, fmt.Sprintf("Excpected next token to be %s, got %s instead",
+				token.IDENT, p.currentToken.Type))
+			return nil
+		}
+		proc.Name = p.parseIdentifier()
+		params, paramTypes, ok := p.parseParams()
+		if !ok {
+			return nil
+		}
+		proc.Params = params
+		proc.ParamTypes = paramTypes
+
+		if !p.expectPeek(token.ASSIGN) {
+			return nil
+		}
+
+		p.nextToken()
+		proc.Body = p.ParseExpression()
+		if proc.Body == nil {
+			p.errors = append(p.errors, "Missing inner expression for Body")
+			return nil
+		}
+		expr.Procs = append(expr.Procs, proc)
+
+		if !p.peekTokenIs(token.IDENT) && !p.peekTokenIs(token.LPAREN) && !p.peekTokenIs(token.QUESTION) {
+			break
+		}
+		p.nextToken()
+	}
+
+	if !p.expectPeek(token.IN) {
+		return nil
+	}
+
+	p.nextToken()
+	expr.In = p.ParseExpression()
+	if expr.In == nil {
+		p.errors = append(p.errors, "Missing inner expression for In")
+		return nil
+	}
+	return expr
+}
+
+//parseParenExpression parses a call like (f x y), or a grouping like (a + b) when the
+//first expression inside the parens is followed by an infix operator or starts with a unary minus.
+func (p *Parser) parseParenExpression() ast.Expression {
+	expr := &ast.CallExpression{BaseExpression: ast.BaseExpression{Token: p.currentToken}}
+
+	p.nextToken()
+	negated := p.currentToken.Type == token.SUB
+	errorCount := len(p.errors)
+	expr.Operator = p.parsePrefix()
+	if expr.Operator == nil {
+		p.errors = append(p.errors, "Missing inner expression for Operator")
+		return (*ast.CallExpression)(nil)
+	}
+	if len(p.errors) > errorCount {
+		return (*ast.CallExpression)(nil)
+	}
+
+	if negated || (p.peekPrecedence() > LOWEST && !p.peekIsNegativeOperand()) {
+		grouped := p.parseInfix(expr.Operator, LOWEST)
+		if !p.expectPeek(token.RPAREN) {
+			return (*ast.CallExpression)(nil)
+		}
+		return grouped
+	}
+	return p.parseCallOperands(expr)
+}
+
+func (p *Parser) parseCallOperands(expr *ast.CallExpression) *ast.CallExpression {
+	for !p.peekTokenIs(token.RPAREN) && !p.peekTokenIs(token.EOF) {
+		p.nextToken()
+		operand := p.parseOperand()
+		if operand == nil {
+			p.errors = append(p.errors, "Missing inner expression for Operand")
+			return nil
+		}
+		expr.Operands = append(expr.Operands, operand)
+	}
+
+	if !p.expectPeek(token.RPAREN) {
+		return nil
+	}
+	return expr
+}
+
+func (p *Parser) parseBeginExpression() *ast.BeginExpression {
+	expr := &ast.BeginExpression{BaseExpression: ast.BaseExpression{Token: p.currentToken}}
+	for {
+		p.nextToken()
+		inner := p.ParseExpression()
+		if inner == nil {
+			p.errors = append(p.errors, "Missing inner expression for Begin")
+			return nil
+		}
+		expr.Exprs = append(expr.Exprs, inner)
+		if !p.peekTokenIs(token.SEMICOLON) {
+			break
+		}
+		p.nextToken()
+	}
+
+	if !p.expectPeek(token.END) {
+		return nil
+	}
+	return expr
+}
+
+func (p *Parser) parseSetExpression() *ast.SetExpression {
+	expr := &ast.SetExpression{BaseExpression: ast.BaseExpression{Token: p.currentToken}}
+	if !p.expectPeek(token.IDENT) {
+		return nil
+	}
+	expr.Name = p.parseIdentifier()
+
+	if !p.expectPeek(token.ASSIGN) {
+		return nil
+	}
+
+	p.nextToken()
+	expr.Value = p.ParseExpression()
+	if expr.Value == nil {
+		p.errors = append(p.errors, "Missing inner expression for Value")
+		return nil
+	}
+	return expr
+}
+
+func (p *Parser) parseTryExpression() *ast.TryExpression {
+	expr := &ast.TryExpression{BaseExpression: ast.BaseExpression{Token: p.currentToken}}
+
+	p.nextToken()
+	expr.Body = p.ParseExpression()
+	if expr.Body == nil {
+		p.errors = append(p.errors, "Missing inner expression for Body")
+		return nil
+	}
+
+	if !p.expectPeek(token.CATCH) {
+		return nil
+	}
+	if !p.expectPeek(token.LPAREN) {
+		return nil
+	}
+	if !p.expectPeek(token.IDENT) {
+		return nil
+	}
+	expr.Var = p.parseIdentifier()
+	if !p.expectPeek(token.RPAREN) {
+		return nil
+	}
+
+	p.nextToken()
+	expr.Handler = p.ParseExpression()
+	if expr.Handler == nil {
+		p.errors = append(p.errors, "Missing inner expression for Handler")
+		return nil
+	}
+	return expr
+}
+
+func (p *Parser) parseRaiseExpression() *ast.RaiseExpression {
+	expr := &ast.RaiseExpression{BaseExpression: ast.BaseExpression{Token: p.currentToken}}
+
+	p.nextToken()
+	expr.Value = p.ParseExpression()
+	if expr.Value == nil {
+		p.errors = append(p.errors, "Missing inner expression for Value")
+		return nil
+	}
+	return expr
+}
+
 func (p *Parser) parseIdentifier() *ast.Identifier {
 	ident := &ast.Identifier{
 		BaseExpression: ast.BaseExpression{Token: p.currentToken},
@@ -198,6 +818,12 @@ func (p *Parser) parseIdentifier() *ast.Identifier {
 
 func (p *Parser) parseIntLiteral() *ast.IntLiteral {
 	value, err := strconv.Atoi(p.currentToken.Literal)
+	if numErr, ok := err.(*strconv.NumError); ok && numErr.Err == strconv.ErrRange {
+		//The literal is kept whole, the runtime decides if it can be used.
+		if bigValue, ok := new(big.Int).SetString(p.currentToken.Literal, 10); ok {
+			return &ast.IntLiteral{BaseExpression: ast.BaseExpression{Token: p.currentToken}, Big: bigValue}
+		}
+	}
 	if err != nil {
 		msg := fmt.Sprintf("Error parsing Int Literal, token literal: %s, Atio error: %s",
 			p.currentToken.Literal, err.Error())
@@ -210,3 +836,66 @@ func (p *Parser) parseIntLiteral() *ast.IntLiteral {
 	}
 	return intLit
 }
+
+func (p *Parser) parseFloatLiteral() *ast.FloatLiteral {
+	value, err := strconv.ParseFloat(p.currentToken.Literal, 64)
+	if err != nil {
+		msg := fmt.Sprintf("Error parsing Float Literal, token literal: %s, ParseFloat error: %s",
+			p.currentToken.Literal, err.Error())
+		p.errors = append(p.errors, msg)
+		return nil
+	}
+	return &ast.FloatLiteral{
+		BaseExpression: ast.BaseExpression{Token: p.currentToken},
+		Value:          value,
+	}
+}
+
+func (p *Parser) parseBoolLiteral() *ast.BoolLiteral {
+	return &ast.BoolLiteral{
+		BaseExpression: ast.BaseExpression{Token: p.currentToken},
+		Value:          p.currentToken.Type == token.TRUE,
+	}
+}
+
+func (p *Parser) parseLetccExpression() *ast.LetccExpression {
+	expr := &ast.LetccExpression{BaseExpression: ast.BaseExpression{Token: p.currentToken}}
+
+	if !p.expectPeek(token.IDENT) {
+		return nil
+	}
+	expr.Name = p.parseIdentifier()
+	if !p.expectPeek(token.IN) {
+		return nil
+	}
+
+	p.nextToken()
+	expr.Body = p.ParseExpression()
+	if expr.Body == nil {
+		p.errors = append(p.errors, "Missing inner expression for Body")
+		return nil
+	}
+	return expr
+}
+
+func (p *Parser) parseThrowExpression() *ast.ThrowExpression {
+	expr := &ast.ThrowExpression{BaseExpression: ast.BaseExpression{Token: p.currentToken}}
+
+	p.nextToken()
+	expr.Value = p.ParseExpression()
+	if expr.Value == nil {
+		p.errors = append(p.errors, "Missing inner expression for Value")
+		return nil
+	}
+	if !p.expectPeek(token.TO) {
+		return nil
+	}
+
+	p.nextToken()
+	expr.Cont = p.ParseExpression()
+	if expr.Cont == nil {
+		p.errors = append(p.errors, "Missing inner expression for Cont")
+		return nil
+	}
+	return expr
+}
@@ -55,6 +55,16 @@ func testIntLit(t *testing.T, expression ast.Expression, value int) {
 	}
 }
 
+func testBoolLit(t *testing.T, expression ast.Expression, value bool) {
+	v, ok := expression.(*ast.BoolLiteral)
+	if !ok {
+		t.Fatalf("Parse Expression expected %T, but returned %T", &ast.BoolLiteral{}, expression)
+	}
+	if v.Value != value {
+		t.Fatalf("Parse Expression expected Bool Lit to be %t, but was %t", value, v.Value)
+	}
+}
+
 func testLetExpression(t *testing.T, expression ast.Expression, identName string, valueCheck expressionCheck, inCheck expressionCheck) {
 	v, ok := expression.(*ast.LetExpression)
 	if !ok {
@@ -92,15 +102,59 @@ func testIfThenElse(t *testing.T, expression ast.Expression, predicateCheck expr
 	falseCheck(v.FalseBranch)
 }
 
+func testProc(t *testing.T, expression ast.Expression, params []string, bodyCheck expressionCheck) {
+	v, ok := expression.(*ast.ProcExpression)
+	if !ok {
+		t.Fatalf("Parse Expression expected %T, but returned %T", &ast.ProcExpression{}, expression)
+	}
+	if len(v.Params) != len(params) {
+		t.Fatalf("Parse Expression expected %d params, but got %d", len(params), len(v.Params))
+	}
+	for i, param := range params {
+		testIdent(t, v.Params[i], param)
+	}
+	bodyCheck(v.Body)
+}
+
+func testCall(t *testing.T, expression ast.Expression, operatorCheck expressionCheck, operandChecks ...expressionCheck) {
+	v, ok := expression.(*ast.CallExpression)
+	if !ok {
+		t.Fatalf("Parse Expression expected %T, but returned %T", &ast.CallExpression{}, expression)
+	}
+	operatorCheck(v.Operator)
+	if len(v.Operands) != len(operandChecks) {
+		t.Fatalf("Parse Expression expected %d operands, but got %d", len(operandChecks), len(v.Operands))
+	}
+	for i, operandCheck := range operandChecks {
+		operandCheck(v.Operands[i])
+	}
+}
+
+func testPrimApp(t *testing.T, expression ast.Expression, name string, argChecks ...expressionCheck) {
+	v, ok := expression.(*ast.PrimAppExpression)
+	if !ok {
+		t.Fatalf("Parse Expression expected %T, but returned %T", &ast.PrimAppExpression{}, expression)
+	}
+	if v.Name != name {
+		t.Fatalf("Parse Expression expected primitive to be %s, but was %s", name, v.Name)
+	}
+	if len(v.Args) != len(argChecks) {
+		t.Fatalf("Parse Expression expected %d args, but got %d", len(argChecks), len(v.Args))
+	}
+	for i, argCheck := range argChecks {
+		argCheck(v.Args[i])
+	}
+}
+
 func TestBasicLet(t *testing.T) {
 	input := []token.Token{
-		{token.LET, "let"},
-		{token.IDENT, "x"},
-		{token.ASSIGN, "="},
-		{token.INT, "8"},
-		{token.IN, "in"},
-		{token.IDENT, "y"},
-		{token.EOF, ""},
+		{Type: token.LET, Literal: "let"},
+		{Type: token.IDENT, Literal: "x"},
+		{Type: token.ASSIGN, Literal: "="},
+		{Type: token.INT, Literal: "8"},
+		{Type: token.IN, Literal: "in"},
+		{Type: token.IDENT, Literal: "y"},
+		{Type: token.EOF, Literal: ""},
 	}
 
 	p := New(input)
@@ -119,18 +173,18 @@ func TestBasicLet(t *testing.T) {
 
 func TestNestedLet(t *testing.T) {
 	input := []token.Token{
-		{token.LET, "let"},
-		{token.IDENT, "x"},
-		{token.ASSIGN, "="},
-		{token.INT, "8"},
-		{token.IN, "in"},
-		{token.LET, "let"},
-		{token.IDENT, "y"},
-		{token.ASSIGN, "="},
-		{token.INT, "9"},
-		{token.IN, "in"},
-		{token.IDENT, "x"},
-		{token.EOF, ""},
+		{Type: token.LET, Literal: "let"},
+		{Type: token.IDENT, Literal: "x"},
+		{Type: token.ASSIGN, Literal: "="},
+		{Type: token.INT, Literal: "8"},
+		{Type: token.IN, Literal: "in"},
+		{Type: token.LET, Literal: "let"},
+		{Type: token.IDENT, Literal: "y"},
+		{Type: token.ASSIGN, Literal: "="},
+		{Type: token.INT, Literal: "9"},
+		{Type: token.IN, Literal: "in"},
+		{Type: token.IDENT, Literal: "x"},
+		{Type: token.EOF, Literal: ""},
 	}
 
 	p := New(input)
@@ -153,12 +207,12 @@ func TestNestedLet(t *testing.T) {
 
 func TestLetMissingIdent(t *testing.T) {
 	input := []token.Token{
-		{token.LET, "let"},
-		{token.ASSIGN, "="},
-		{token.INT, "8"},
-		{token.IN, "in"},
-		{token.IDENT, "y"},
-		{token.EOF, ""},
+		{Type: token.LET, Literal: "let"},
+		{Type: token.ASSIGN, Literal: "="},
+		{Type: token.INT, Literal: "8"},
+		{Type: token.IN, Literal: "in"},
+		{Type: token.IDENT, Literal: "y"},
+		{Type: token.EOF, Literal: ""},
 	}
 
 	p := New(input)
@@ -178,12 +232,12 @@ func TestLetMissingIdent(t *testing.T) {
 
 func TestLetMissingAssign(t *testing.T) {
 	input := []token.Token{
-		{token.LET, "let"},
-		{token.IDENT, "x"},
-		{token.INT, "8"},
-		{token.IN, "in"},
-		{token.IDENT, "y"},
-		{token.EOF, ""},
+		{Type: token.LET, Literal: "let"},
+		{Type: token.IDENT, Literal: "x"},
+		{Type: token.INT, Literal: "8"},
+		{Type: token.IN, Literal: "in"},
+		{Type: token.IDENT, Literal: "y"},
+		{Type: token.EOF, Literal: ""},
 	}
 
 	p := New(input)
@@ -199,12 +253,12 @@ func TestLetMissingAssign(t *testing.T) {
 
 func TestLetMissingValueExpr(t *testing.T) {
 	input := []token.Token{
-		{token.LET, "let"},
-		{token.IDENT, "x"},
-		{token.ASSIGN, "="},
-		{token.IN, "in"},
-		{token.IDENT, "y"},
-		{token.EOF, ""},
+		{Type: token.LET, Literal: "let"},
+		{Type: token.IDENT, Literal: "x"},
+		{Type: token.ASSIGN, Literal: "="},
+		{Type: token.IN, Literal: "in"},
+		{Type: token.IDENT, Literal: "y"},
+		{Type: token.EOF, Literal: ""},
 	}
 
 	p := New(input)
@@ -220,12 +274,12 @@ func TestLetMissingValueExpr(t *testing.T) {
 
 func TestLetMissingInExpr(t *testing.T) {
 	input := []token.Token{
-		{token.LET, "let"},
-		{token.IDENT, "x"},
-		{token.ASSIGN, "="},
-		{token.IDENT, "y"},
-		{token.IN, "in"},
-		{token.EOF, ""},
+		{Type: token.LET, Literal: "let"},
+		{Type: token.IDENT, Literal: "x"},
+		{Type: token.ASSIGN, Literal: "="},
+		{Type: token.IDENT, Literal: "y"},
+		{Type: token.IN, Literal: "in"},
+		{Type: token.EOF, Literal: ""},
 	}
 
 	p := New(input)
@@ -241,11 +295,11 @@ func TestLetMissingInExpr(t *testing.T) {
 
 func TestLetMissingIn(t *testing.T) {
 	input := []token.Token{
-		{token.LET, "let"},
-		{token.IDENT, "x"},
-		{token.ASSIGN, "="},
-		{token.IDENT, "y"},
-		{token.EOF, ""},
+		{Type: token.LET, Literal: "let"},
+		{Type: token.IDENT, Literal: "x"},
+		{Type: token.ASSIGN, Literal: "="},
+		{Type: token.IDENT, Literal: "y"},
+		{Type: token.EOF, Literal: ""},
 	}
 
 	p := New(input)
@@ -261,8 +315,8 @@ func TestLetMissingIn(t *testing.T) {
 
 func TestIntLiteral(t *testing.T) {
 	input := []token.Token{
-		{token.INT, "4"},
-		{token.EOF, ""},
+		{Type: token.INT, Literal: "4"},
+		{Type: token.EOF, Literal: ""},
 	}
 
 	p := New(input)
@@ -274,10 +328,127 @@ func TestIntLiteral(t *testing.T) {
 	testIntLit(t, expression, 4)
 }
 
+func TestBigIntLiteral(t *testing.T) {
+	input := []token.Token{
+		{Type: token.INT, Literal: "123456789012345678901234567890"},
+		{Type: token.EOF, Literal: ""},
+	}
+
+	p := New(input)
+	expression := p.ParseExpression()
+
+	if reflect.ValueOf(expression).IsNil() {
+		t.Fatalf("Parse Expression returned nil")
+	}
+	v, ok := expression.(*ast.IntLiteral)
+	if !ok {
+		t.Fatalf("Parse Expression expected %T, but returned %T", &ast.IntLiteral{}, expression)
+	}
+	if v.Big == nil || v.Big.String() != "123456789012345678901234567890" {
+		t.Fatalf("Parse Expression expected the literal to be kept whole, but was %v", v.Big)
+	}
+}
+
+func TestFloatLiteral(t *testing.T) {
+	input := []token.Token{
+		{Type: token.FLOAT, Literal: "1e-3"},
+		{Type: token.EOF, Literal: ""},
+	}
+
+	p := New(input)
+	expression := p.ParseExpression()
+
+	if reflect.ValueOf(expression).IsNil() {
+		t.Fatalf("Parse Expression returned nil")
+	}
+	v, ok := expression.(*ast.FloatLiteral)
+	if !ok {
+		t.Fatalf("Parse Expression expected %T, but returned %T", &ast.FloatLiteral{}, expression)
+	}
+	if v.Value != 0.001 {
+		t.Fatalf("Parse Expression expected Float Lit to be 0.001, but was %g", v.Value)
+	}
+}
+
+func TestStringLiteral(t *testing.T) {
+	input := []token.Token{
+		{Type: token.STRING, Literal: "a\nb"},
+		{Type: token.EOF, Literal: ""},
+	}
+
+	p := New(input)
+	expression := p.ParseExpression()
+
+	v, ok := expression.(*ast.StringLiteral)
+	if !ok {
+		t.Fatalf("Parse Expression expected %T, but returned %T", &ast.StringLiteral{}, expression)
+	}
+	if v.Value != "a\nb" {
+		t.Fatalf("Parse Expression expected String Lit to be %q, but was %q", "a\nb", v.Value)
+	}
+}
+
+func TestCommentsIgnored(t *testing.T) {
+	input := []token.Token{
+		{Type: token.COMMENT, Literal: "% the answer"},
+		{Type: token.INT, Literal: "4"},
+		{Type: token.COMMENT, Literal: "/* done */"},
+		{Type: token.EOF, Literal: ""},
+	}
+
+	p := New(input)
+	program := p.ParseProgram()
+
+	if len(p.Errors()) != 0 {
+		t.Fatalf("Expected no parse errors but got %v", p.Errors())
+	}
+	testIntLit(t, program.Body, 4)
+}
+
+func TestOnlyComments(t *testing.T) {
+	for _, input := range [][]token.Token{
+		{{Type: token.COMMENT, Literal: "% nothing here"}},
+		{},
+	} {
+		p := New(input)
+		p.ParseProgram()
+		if len(p.Errors()) == 0 {
+			t.Errorf("Expected a program of only comments to be a parse error")
+		}
+	}
+}
+
+func TestBoolLiteral(t *testing.T) {
+	input := []token.Token{
+		{Type: token.IF, Literal: "if"},
+		{Type: token.TRUE, Literal: "true"},
+		{Type: token.THEN, Literal: "then"},
+		{Type: token.FALSE, Literal: "false"},
+		{Type: token.ELSE, Literal: "else"},
+		{Type: token.TRUE, Literal: "true"},
+		{Type: token.EOF, Literal: ""},
+	}
+
+	p := New(input)
+	expression := p.ParseExpression()
+
+	if reflect.ValueOf(expression).IsNil() {
+		t.Fatalf("Parse Expression return nil")
+	}
+	testIfThenElse(t, expression, func(expression ast.Expression) {
+		testBoolLit(t, expression, true)
+	}, func(expression ast.Expression) {
+		testBoolLit(t, expression, false)
+	}, func(expression ast.Expression) {
+		testBoolLit(t, expression, true)
+	})
+	checkForParseErrors(p, t)
+}
+
 func TestInvalidIntLiteral(t *testing.T) {
 	input := []token.Token{
-		{token.INT, "let"},
-		{token.EOF, ""},
+		{Type: token.INT, Literal: "let"},
+		{Type: token.EOF, Literal: ""},
 	}
 
 	p := New(input)
@@ -294,8 +465,8 @@ func TestInvalidIntLiteral(t *testing.T) {
 func TestIdent(t *testing.T) {
 	identLit := "testing"
 	input := []token.Token{
-		{token.IDENT, identLit},
-		{token.EOF, ""},
+		{Type: token.IDENT, Literal: identLit},
+		{Type: token.EOF, Literal: ""},
 	}
 
 	p := New(input)
@@ -309,12 +480,12 @@ func TestIdent(t *testing.T) {
 
 func TestMinus(t *testing.T) {
 	input := []token.Token{
-		{token.MINUS, "minus"},
-		{token.LPAREN, "("},
-		{token.IDENT, "y"},
-		{token.COMMA, ","},
-		{token.INT, "2"},
-		{token.RPAREN, ")"},
+		{Type: token.MINUS, Literal: "minus"},
+		{Type: token.LPAREN, Literal: "("},
+		{Type: token.IDENT, Literal: "y"},
+		{Type: token.COMMA, Literal: ","},
+		{Type: token.INT, Literal: "2"},
+		{Type: token.RPAREN, Literal: ")"},
 	}
 	p := New(input)
 	expression := p.ParseExpression()
@@ -331,11 +502,11 @@ func TestMinus(t *testing.T) {
 
 func TestInvalidMinusMissingLParen(t *testing.T) {
 	input := []token.Token{
-		{token.MINUS, "minus"},
-		{token.IDENT, "y"},
-		{token.COMMA, ","},
-		{token.INT, "2"},
-		{token.RPAREN, ")"},
+		{Type: token.MINUS, Literal: "minus"},
+		{Type: token.IDENT, Literal: "y"},
+		{Type: token.COMMA, Literal: ","},
+		{Type: token.INT, Literal: "2"},
+		{Type: token.RPAREN, Literal: ")"},
 	}
 
 	p := New(input)
@@ -351,11 +522,11 @@ func TestInvalidMinusMissingLParen(t *testing.T) {
 
 func TestInvalidMinusMissingRParen(t *testing.T) {
 	input := []token.Token{
-		{token.MINUS, "minus"},
-		{token.LPAREN, "("},
-		{token.IDENT, "y"},
-		{token.COMMA, ","},
-		{token.INT, "2"},
+		{Type: token.MINUS, Literal: "minus"},
+		{Type: token.LPAREN, Literal: "("},
+		{Type: token.IDENT, Literal: "y"},
+		{Type: token.COMMA, Literal: ","},
+		{Type: token.INT, Literal: "2"},
 	}
 
 	p := New(input)
@@ -371,11 +542,11 @@ func TestInvalidMinusMissingRParen(t *testing.T) {
 
 func TestInvalidMinusMissingComma(t *testing.T) {
 	input := []token.Token{
-		{token.MINUS, "minus"},
-		{token.LPAREN, "("},
-		{token.IDENT, "y"},
-		{token.INT, "2"},
-		{token.RPAREN, ")"},
+		{Type: token.MINUS, Literal: "minus"},
+		{Type: token.LPAREN, Literal: "("},
+		{Type: token.IDENT, Literal: "y"},
+		{Type: token.INT, Literal: "2"},
+		{Type: token.RPAREN, Literal: ")"},
 	}
 
 	p := New(input)
@@ -391,11 +562,11 @@ func TestInvalidMinusMissingComma(t *testing.T) {
 
 func TestInvalidMinusMissingExpression(t *testing.T) {
 	input := []token.Token{
-		{token.MINUS, "minus"},
-		{token.LPAREN, "("},
-		{token.COMMA, ","},
-		{token.INT, "2"},
-		{token.RPAREN, ")"},
+		{Type: token.MINUS, Literal: "minus"},
+		{Type: token.LPAREN, Literal: "("},
+		{Type: token.COMMA, Literal: ","},
+		{Type: token.INT, Literal: "2"},
+		{Type: token.RPAREN, Literal: ")"},
 	}
 
 	p := New(input)
@@ -411,11 +582,11 @@ func TestInvalidMinusMissingExpression(t *testing.T) {
 
 func TestInvalidMinusMissingExpression2(t *testing.T) {
 	input := []token.Token{
-		{token.MINUS, "minus"},
-		{token.LPAREN, "("},
-		{token.INT, "2"},
-		{token.COMMA, ","},
-		{token.RPAREN, ")"},
+		{Type: token.MINUS, Literal: "minus"},
+		{Type: token.LPAREN, Literal: "("},
+		{Type: token.INT, Literal: "2"},
+		{Type: token.COMMA, Literal: ","},
+		{Type: token.RPAREN, Literal: ")"},
 	}
 
 	p := New(input)
@@ -431,10 +602,10 @@ func TestInvalidMinusMissingExpression2(t *testing.T) {
 
 func TestIsZero(t *testing.T) {
 	input := []token.Token{
-		{token.IS_ZERO, "iszero"},
-		{token.LPAREN, "("},
-		{token.IDENT, "y"},
-		{token.RPAREN, ")"},
+		{Type: token.IS_ZERO, Literal: "iszero"},
+		{Type: token.LPAREN, Literal: "("},
+		{Type: token.IDENT, Literal: "y"},
+		{Type: token.RPAREN, Literal: ")"},
 	}
 	p := New(input)
 	expression := p.ParseExpression()
@@ -449,9 +620,9 @@ func TestIsZero(t *testing.T) {
 
 func TestIsZeroMissingLParen(t *testing.T) {
 	input := []token.Token{
-		{token.IS_ZERO, "iszero"},
-		{token.IDENT, "y"},
-		{token.RPAREN, ")"},
+		{Type: token.IS_ZERO, Literal: "iszero"},
+		{Type: token.IDENT, Literal: "y"},
+		{Type: token.RPAREN, Literal: ")"},
 	}
 	p := New(input)
 	expression := p.ParseExpression()
@@ -466,9 +637,9 @@ func TestIsZeroMissingLParen(t *testing.T) {
 
 func TestIsZeroMissingRParen(t *testing.T) {
 	input := []token.Token{
-		{token.IS_ZERO, "iszero"},
-		{token.LPAREN, "("},
-		{token.IDENT, "y"},
+		{Type: token.IS_ZERO, Literal: "iszero"},
+		{Type: token.LPAREN, Literal: "("},
+		{Type: token.IDENT, Literal: "y"},
 	}
 	p := New(input)
 	expression := p.ParseExpression()
@@ -483,9 +654,9 @@ func TestIsZeroMissingRParen(t *testing.T) {
 
 func TestIsZeroMissingArg(t *testing.T) {
 	input := []token.Token{
-		{token.IS_ZERO, "iszero"},
-		{token.LPAREN, "("},
-		{token.RPAREN, ")"},
+		{Type: token.IS_ZERO, Literal: "iszero"},
+		{Type: token.LPAREN, Literal: "("},
+		{Type: token.RPAREN, Literal: ")"},
 	}
 	p := New(input)
 	expression := p.ParseExpression()
@@ -500,15 +671,15 @@ func TestIsZeroMissingArg(t *testing.T) {
 
 func TestIfThenElse(t *testing.T) {
 	input := []token.Token{
-		{token.IF, "if"},
-		{token.IS_ZERO, "iszero"},
-		{token.LPAREN, "("},
-		{token.IDENT, "y"},
-		{token.RPAREN, ")"},
-		{token.THEN, "then"},
-		{token.IDENT, "y"},
-		{token.ELSE, "else"},
-		{token.INT, "2"},
+		{Type: token.IF, Literal: "if"},
+		{Type: token.IS_ZERO, Literal: "iszero"},
+		{Type: token.LPAREN, Literal: "("},
+		{Type: token.IDENT, Literal: "y"},
+		{Type: token.RPAREN, Literal: ")"},
+		{Type: token.THEN, Literal: "then"},
+		{Type: token.IDENT, Literal: "y"},
+		{Type: token.ELSE, Literal: "else"},
+		{Type: token.INT, Literal: "2"},
 	}
 	p := New(input)
 	expression := p.ParseExpression()
@@ -529,11 +700,11 @@ func TestIfThenElse(t *testing.T) {
 
 func TestIfThenElseMissingPredicate(t *testing.T) {
 	input := []token.Token{
-		{token.IF, "if"},
-		{token.THEN, "then"},
-		{token.IDENT, "y"},
-		{token.ELSE, "else"},
-		{token.INT, "2"},
+		{Type: token.IF, Literal: "if"},
+		{Type: token.THEN, Literal: "then"},
+		{Type: token.IDENT, Literal: "y"},
+		{Type: token.ELSE, Literal: "else"},
+		{Type: token.INT, Literal: "2"},
 	}
 	p := New(input)
 	expression := p.ParseExpression()
@@ -548,14 +719,14 @@ func TestIfThenElseMissingPredicate(t *testing.T) {
 
 func TestIfThenElseMissingThen(t *testing.T) {
 	input := []token.Token{
-		{token.IF, "if"},
-		{token.IS_ZERO, "iszero"},
-		{token.LPAREN, "("},
-		{token.IDENT, "y"},
-		{token.RPAREN, ")"},
-		{token.IDENT, "y"},
-		{token.ELSE, "else"},
-		{token.INT, "2"},
+		{Type: token.IF, Literal: "if"},
+		{Type: token.IS_ZERO, Literal: "iszero"},
+		{Type: token.LPAREN, Literal: "("},
+		{Type: token.IDENT, Literal: "y"},
+		{Type: token.RPAREN, Literal: ")"},
+		{Type: token.IDENT, Literal: "y"},
+		{Type: token.ELSE, Literal: "else"},
+		{Type: token.INT, Literal: "2"},
 	}
 	p := New(input)
 	expression := p.ParseExpression()
@@ -570,14 +741,14 @@ func TestIfThenElseMissingThen(t *testing.T) {
 
 func TestIfThenElseMissingThenExpr(t *testing.T) {
 	input := []token.Token{
-		{token.IF, "if"},
-		{token.IS_ZERO, "iszero"},
-		{token.LPAREN, "("},
-		{token.IDENT, "y"},
-		{token.RPAREN, ")"},
-		{token.THEN, "then"},
-		{token.ELSE, "else"},
-		{token.INT, "2"},
+		{Type: token.IF, Literal: "if"},
+		{Type: token.IS_ZERO, Literal: "iszero"},
+		{Type: token.LPAREN, Literal: "("},
+		{Type: token.IDENT, Literal: "y"},
+		{Type: token.RPAREN, Literal: ")"},
+		{Type: token.THEN, Literal: "then"},
+		{Type: token.ELSE, Literal: "else"},
+		{Type: token.INT, Literal: "2"},
 	}
 	p := New(input)
 	expression := p.ParseExpression()
@@ -592,14 +763,14 @@ func TestIfThenElseMissingThenExpr(t *testing.T) {
 
 func TestIfThenElseMissingElse(t *testing.T) {
 	input := []token.Token{
-		{token.IF, "if"},
-		{token.IS_ZERO, "iszero"},
-		{token.LPAREN, "("},
-		{token.IDENT, "y"},
-		{token.RPAREN, ")"},
-		{token.THEN, "then"},
-		{token.IDENT, "y"},
-		{token.INT, "2"},
+		{Type: token.IF, Literal: "if"},
+		{Type: token.IS_ZERO, Literal: "iszero"},
+		{Type: token.LPAREN, Literal: "("},
+		{Type: token.IDENT, Literal: "y"},
+		{Type: token.RPAREN, Literal: ")"},
+		{Type: token.THEN, Literal: "then"},
+		{Type: token.IDENT, Literal: "y"},
+		{Type: token.INT, Literal: "2"},
 	}
 	p := New(input)
 	expression := p.ParseExpression()
@@ -614,14 +785,14 @@ func TestIfThenElseMissingElse(t *testing.T) {
 
 func TestIfThenElseMissingElseExpr(t *testing.T) {
 	input := []token.Token{
-		{token.IF, "if"},
-		{token.IS_ZERO, "iszero"},
-		{token.LPAREN, "("},
-		{token.IDENT, "y"},
-		{token.RPAREN, ")"},
-		{token.THEN, "then"},
-		{token.IDENT, "y"},
-		{token.ELSE, "else"},
+		{Type: token.IF, Literal: "if"},
+		{Type: token.IS_ZERO, Literal: "iszero"},
+		{Type: token.LPAREN, Literal: "("},
+		{Type: token.IDENT, Literal: "y"},
+		{Type: token.RPAREN, Literal: ")"},
+		{Type: token.THEN, Literal: "then"},
+		{Type: token.IDENT, Literal: "y"},
+		{Type: token.ELSE, Literal: "else"},
 	}
 	p := New(input)
 	expression := p.ParseExpression()
@@ -636,53 +807,53 @@ func TestIfThenElseMissingElseExpr(t *testing.T) {
 
 func TestExample1Parse(t *testing.T) {
 	input := []token.Token{
-		{token.LET, "let"},
-		{token.IDENT, "x"},
-		{token.ASSIGN, "="},
-		{token.INT, "7"},
-
-		{token.IN, "in"},
-		{token.LET, "let"},
-		{token.IDENT, "y"},
-		{token.ASSIGN, "="},
-		{token.INT, "2"},
-
-		{token.IN, "in"},
-		{token.LET, "let"},
-		{token.IDENT, "y"},
-		{token.ASSIGN, "="},
-		{token.LET, "let"},
-		{token.IDENT, "x"},
-		{token.ASSIGN, "="},
-		{token.MINUS, "minus"},
-		{token.LPAREN, "("},
-		{token.IDENT, "x"},
-		{token.COMMA, ","},
-		{token.INT, "1"},
-		{token.RPAREN, ")"},
-
-		{token.IN, "in"},
-		{token.MINUS, "minus"},
-		{token.LPAREN, "("},
-		{token.IDENT, "x"},
-		{token.COMMA, ","},
-		{token.IDENT, "y"},
-		{token.RPAREN, ")"},
-
-		{token.IN, "in"},
-		{token.MINUS, "minus"},
-		{token.LPAREN, "("},
-		{token.MINUS, "minus"},
-		{token.LPAREN, "("},
-		{token.IDENT, "x"},
-		{token.COMMA, ","},
-		{token.INT, "8"},
-		{token.RPAREN, ")"},
-		{token.COMMA, ","},
-		{token.IDENT, "y"},
-		{token.RPAREN, ")"},
-
-		{token.EOF, ""},
+		{Type: token.LET, Literal: "let"},
+		{Type: token.IDENT, Literal: "x"},
+		{Type: token.ASSIGN, Literal: "="},
+		{Type: token.INT, Literal: "7"},
+
+		{Type: token.IN, Literal: "in"},
+		{Type: token.LET, Literal: "let"},
+		{Type: token.IDENT, Literal: "y"},
+		{Type: token.ASSIGN, Literal: "="},
+		{Type: token.INT, Literal: "2"},
+
+		{Type: token.IN, Literal: "in"},
+		{Type: token.LET, Literal: "let"},
+		{Type: token.IDENT, Literal: "y"},
+		{Type: token.ASSIGN, Literal: "="},
+		{Type: token.LET, Literal: "let"},
+		{Type: token.IDENT, Literal: "x"},
+		{Type: token.ASSIGN, Literal: "="},
+		{Type: token.MINUS, Literal: "minus"},
+		{Type: token.LPAREN, Literal: "("},
+		{Type: token.IDENT, Literal: "x"},
+		{Type: token.COMMA, Literal: ","},
+		{Type: token.INT, Literal: "1"},
+		{Type: token.RPAREN, Literal: ")"},
+
+		{Type: token.IN, Literal: "in"},
+		{Type: token.MINUS, Literal: "minus"},
+		{Type: token.LPAREN, Literal: "("},
+		{Type: token.IDENT, Literal: "x"},
+		{Type: token.COMMA, Literal: ","},
+		{Type: token.IDENT, Literal: "y"},
+		{Type: token.RPAREN, Literal: ")"},
+
+		{Type: token.IN, Literal: "in"},
+		{Type: token.MINUS, Literal: "minus"},
+		{Type: token.LPAREN, Literal: "("},
+		{Type: token.MINUS, Literal: "minus"},
+		{Type: token.LPAREN, Literal: "("},
+		{Type: token.IDENT, Literal: "x"},
+		{Type: token.COMMA, Literal: ","},
+		{Type: token.INT, Literal: "8"},
+		{Type: token.RPAREN, Literal: ")"},
+		{Type: token.COMMA, Literal: ","},
+		{Type: token.IDENT, Literal: "y"},
+		{Type: token.RPAREN, Literal: ")"},
+
+		{Type: token.EOF, Literal: ""},
 	}
 
 	p := New(input)
@@ -737,46 +908,46 @@ func TestExample1Parse(t *testing.T) {
 
 func TestExample2Parse(t *testing.T) {
 	input := []token.Token{
-		{token.LET, "let"},
-		{token.IDENT, "x"},
-		{token.ASSIGN, "="},
-		{token.INT, "11"},
-
-		{token.IN, "in"},
-		{token.LET, "let"},
-		{token.IDENT, "y"},
-		{token.ASSIGN, "="},
-		{token.INT, "20"},
-
-		{token.IN, "in"},
-		{token.IF, "if"},
-		{token.IS_ZERO, "iszero"},
-		{token.LPAREN, "("},
-		{token.MINUS, "minus"},
-		{token.LPAREN, "("},
-		{token.IDENT, "x"},
-		{token.COMMA, ","},
-		{token.INT, "11"},
-		{token.RPAREN, ")"},
-		{token.RPAREN, ")"},
-
-		{token.THEN, "then"},
-		{token.MINUS, "minus"},
-		{token.LPAREN, "("},
-		{token.IDENT, "y"},
-		{token.COMMA, ","},
-		{token.INT, "2"},
-		{token.RPAREN, ")"},
-
-		{token.ELSE, "else"},
-		{token.MINUS, "minus"},
-		{token.LPAREN, "("},
-		{token.IDENT, "y"},
-		{token.COMMA, ","},
-		{token.INT, "4"},
-		{token.RPAREN, ")"},
-
-		{token.EOF, ""},
+		{Type: token.LET, Literal: "let"},
+		{Type: token.IDENT, Literal: "x"},
+		{Type: token.ASSIGN, Literal: "="},
+		{Type: token.INT, Literal: "11"},
+
+		{Type: token.IN, Literal: "in"},
+		{Type: token.LET, Literal: "let"},
+		{Type: token.IDENT, Literal: "y"},
+		{Type: token.ASSIGN, Literal: "="},
+		{Type: token.INT, Literal: "20"},
+
+		{Type: token.IN, Literal: "in"},
+		{Type: token.IF, Literal: "if"},
+		{Type: token.IS_ZERO, Literal: "iszero"},
+		{Type: token.LPAREN, Literal: "("},
+		{Type: token.MINUS, Literal: "minus"},
+		{Type: token.LPAREN, Literal: "("},
+		{Type: token.IDENT, Literal: "x"},
+		{Type: token.COMMA, Literal: ","},
+		{Type: token.INT, Literal: "11"},
+		{Type: token.RPAREN, Literal: ")"},
+		{Type: token.RPAREN, Literal: ")"},
+
+		{Type: token.THEN, Literal: "then"},
+		{Type: token.MINUS, Literal: "minus"},
+		{Type: token.LPAREN, Literal: "("},
+		{Type: token.IDENT, Literal: "y"},
+		{Type: token.COMMA, Literal: ","},
+		{Type: token.INT, Literal: "2"},
+		{Type: token.RPAREN, Literal: ")"},
+
+		{Type: token.ELSE, Literal: "else"},
+		{Type: token.MINUS, Literal: "minus"},
+		{Type: token.LPAREN, Literal: "("},
+		{Type: token.IDENT, Literal: "y"},
+		{Type: token.COMMA, Literal: ","},
+		{Type: token.INT, Literal: "4"},
+		{Type: token.RPAREN, Literal: ")"},
+
+		{Type: token.EOF, Literal: ""},
 	}
 
 	p := New(input)
@@ -823,3 +994,1419 @@ func TestExample2Parse(t *testing.T) {
 				})
 		})
 }
+
+func TestProc(t *testing.T) {
+	input := []token.Token{
+		{Type: token.PROC, Literal: "proc"},
+		{Type: token.LPAREN, Literal: "("},
+		{Type: token.IDENT, Literal: "x"},
+		{Type: token.COMMA, Literal: ","},
+		{Type: token.IDENT, Literal: "y"},
+		{Type: token.RPAREN, Literal: ")"},
+		{Type: token.IDENT, Literal: "x"},
+		{Type: token.EOF, Literal: ""},
+	}
+	p := New(input)
+	expression := p.ParseExpression()
+
+	if reflect.ValueOf(expression).IsNil() {
+		t.Fatalf("Parse Expression return nil")
+	}
+	testProc(t, expression, []string{"x", "y"}, func(expression ast.Expression) {
+		testIdent(t, expression, "x")
+	})
+	checkForParseErrors(p, t)
+}
+
+func TestProcNoParams(t *testing.T) {
+	input := []token.Token{
+		{Type: token.PROC, Literal: "proc"},
+		{Type: token.LPAREN, Literal: "("},
+		{Type: token.RPAREN, Literal: ")"},
+		{Type: token.INT, Literal: "3"},
+		{Type: token.EOF, Literal: ""},
+	}
+	p := New(input)
+	expression := p.ParseExpression()
+
+	if reflect.ValueOf(expression).IsNil() {
+		t.Fatalf("Parse Expression return nil")
+	}
+	testProc(t, expression, []string{}, func(expression ast.Expression) {
+		testIntLit(t, expression, 3)
+	})
+	checkForParseErrors(p, t)
+}
+
+func TestProcMissingBody(t *testing.T) {
+	input := []token.Token{
+		{Type: token.PROC, Literal: "proc"},
+		{Type: token.LPAREN, Literal: "("},
+		{Type: token.IDENT, Literal: "x"},
+		{Type: token.RPAREN, Literal: ")"},
+		{Type: token.EOF, Literal: ""},
+	}
+	p := New(input)
+	expression := p.ParseExpression()
+
+	if !reflect.ValueOf(expression).IsNil() {
+		t.Fatalf("Parse Expression did not return nil")
+	}
+	checkParseErrorsExist(p, t, []string{
+		"Missing inner expression for Body",
+	})
+}
+
+func TestProcMissingParamComma(t *testing.T) {
+	input := []token.Token{
+		{Type: token.PROC, Literal: "proc"},
+		{Type: token.LPAREN, Literal: "("},
+		{Type: token.IDENT, Literal: "x"},
+		{Type: token.IDENT, Literal: "y"},
+		{Type: token.RPAREN, Literal: ")"},
+		{Type: token.IDENT, Literal: "x"},
+		{Type: token.EOF, Literal: ""},
+	}
+	p := New(input)
+	expression := p.ParseExpression()
+
+	if !reflect.ValueOf(expression).IsNil() {
+		t.Fatalf("Parse Expression did not return nil")
+	}
+	checkParseErrorsExist(p, t, []string{
+		"to be )",
+	})
+}
+
+func TestCall(t *testing.T) {
+	input := []token.Token{
+		{Type: token.LPAREN, Literal: "("},
+		{Type: token.IDENT, Literal: "f"},
+		{Type: token.INT, Literal: "3"},
+		{Type: token.IDENT, Literal: "y"},
+		{Type: token.RPAREN, Literal: ")"},
+		{Type: token.EOF, Literal: ""},
+	}
+	p := New(input)
+	expression := p.ParseExpression()
+
+	if reflect.ValueOf(expression).IsNil() {
+		t.Fatalf("Parse Expression return nil")
+	}
+	testCall(t, expression, func(expression ast.Expression) {
+		testIdent(t, expression, "f")
+	}, func(expression ast.Expression) {
+		testIntLit(t, expression, 3)
+	}, func(expression ast.Expression) {
+		testIdent(t, expression, "y")
+	})
+	checkForParseErrors(p, t)
+}
+
+func TestCallProcLiteral(t *testing.T) {
+	input := []token.Token{
+		{Type: token.LPAREN, Literal: "("},
+		{Type: token.PROC, Literal: "proc"},
+		{Type: token.LPAREN, Literal: "("},
+		{Type: token.IDENT, Literal: "x"},
+		{Type: token.RPAREN, Literal: ")"},
+		{Type: token.IDENT, Literal: "x"},
+		{Type: token.INT, Literal: "3"},
+		{Type: token.RPAREN, Literal: ")"},
+		{Type: token.EOF, Literal: ""},
+	}
+	p := New(input)
+	expression := p.ParseExpression()
+
+	if reflect.ValueOf(expression).IsNil() {
+		t.Fatalf("Parse Expression return nil")
+	}
+	testCall(t, expression, func(expression ast.Expression) {
+		testProc(t, expression, []string{"x"}, func(expression ast.Expression) {
+			testIdent(t, expression, "x")
+		})
+	}, func(expression ast.Expression) {
+		testIntLit(t, expression, 3)
+	})
+	checkForParseErrors(p, t)
+}
+
+func TestCallMissingRParen(t *testing.T) {
+	input := []token.Token{
+		{Type: token.LPAREN, Literal: "("},
+		{Type: token.IDENT, Literal: "f"},
+		{Type: token.INT, Literal: "3"},
+		{Type: token.EOF, Literal: ""},
+	}
+	p := New(input)
+	expression := p.ParseExpression()
+
+	if !reflect.ValueOf(expression).IsNil() {
+		t.Fatalf("Parse Expression did not return nil")
+	}
+	checkParseErrorsExist(p, t, []string{
+		"to be )",
+	})
+}
+
+func TestLetrec(t *testing.T) {
+	input := []token.Token{
+		{Type: token.LETREC, Literal: "letrec"},
+		{Type: token.IDENT, Literal: "f"},
+		{Type: token.LPAREN, Literal: "("},
+		{Type: token.IDENT, Literal: "x"},
+		{Type: token.RPAREN, Literal: ")"},
+		{Type: token.ASSIGN, Literal: "="},
+		{Type: token.LPAREN, Literal: "("},
+		{Type: token.IDENT, Literal: "g"},
+		{Type: token.IDENT, Literal: "x"},
+		{Type: token.RPAREN, Literal: ")"},
+		{Type: token.IDENT, Literal: "g"},
+		{Type: token.LPAREN, Literal: "("},
+		{Type: token.IDENT, Literal: "y"},
+		{Type: token.COMMA, Literal: ","},
+		{Type: token.IDENT, Literal: "z"},
+		{Type: token.RPAREN, Literal: ")"},
+		{Type: token.ASSIGN, Literal: "="},
+		{Type: token.IDENT, Literal: "y"},
+		{Type: token.IN, Literal: "in"},
+		{Type: token.INT, Literal: "3"},
+		{Type: token.EOF, Literal: ""},
+	}
+	p := New(input)
+	expression := p.ParseExpression()
+
+	if reflect.ValueOf(expression).IsNil() {
+		t.Fatalf("Parse Expression return nil")
+	}
+	checkForParseErrors(p, t)
+	v, ok := expression.(*ast.LetrecExpression)
+	if !ok {
+		t.Fatalf("Parse Expression expected %T, but returned %T", &ast.LetrecExpression{}, expression)
+	}
+	if len(v.Procs) != 2 {
+		t.Fatalf("Parse Expression expected 2 procs, but got %d", len(v.Procs))
+	}
+	testIdent(t, v.Procs[0].Name, "f")
+	testIdent(t, v.Procs[0].Params[0], "x")
+	testCall(t, v.Procs[0].Body, func(expression ast.Expression) {
+		testIdent(t, expression, "g")
+	}, func(expression ast.Expression) {
+		testIdent(t, expression, "x")
+	})
+	testIdent(t, v.Procs[1].Name, "g")
+	testIdent(t, v.Procs[1].Params[0], "y")
+	testIdent(t, v.Procs[1].Params[1], "z")
+	testIdent(t, v.Procs[1].Body, "y")
+	testIntLit(t, v.In, 3)
+}
+
+func TestLetrecMissingIn(t *testing.T) {
+	input := []token.Token{
+		{Type: token.LETREC, Literal: "letrec"},
+		{Type: token.IDENT, Literal: "f"},
+		{Type: token.LPAREN, Literal: "("},
+		{Type: token.IDENT, Literal: "x"},
+		{Type: token.RPAREN, Literal: ")"},
+		{Type: token.ASSIGN, Literal: "="},
+		{Type: token.IDENT, Literal: "x"},
+		{Type: token.INT, Literal: "3"},
+		{Type: token.EOF, Literal: ""},
+	}
+	p := New(input)
+	expression := p.ParseExpression()
+
+	if !reflect.ValueOf(expression).IsNil() {
+		t.Fatalf("Parse Expression did not return nil")
+	}
+	checkParseErrorsExist(p, t, []string{
+		"to be IN",
+	})
+}
+
+func TestLetrecMissingParams(t *testing.T) {
+	input := []token.Token{
+		{Type: token.LETREC, Literal: "letrec"},
+		{Type: token.IDENT, Literal: "f"},
+		{Type: token.ASSIGN, Literal: "="},
+		{Type: token.IDENT, Literal: "x"},
+		{Type: token.IN, Literal: "in"},
+		{Type: token.INT, Literal: "3"},
+		{Type: token.EOF, Literal: ""},
+	}
+	p := New(input)
+	expression := p.ParseExpression()
+
+	if !reflect.ValueOf(expression).IsNil() {
+		t.Fatalf("Parse Expression did not return nil")
+	}
+	checkParseErrorsExist(p, t, []string{
+		"to be (",
+	})
+}
+
+func TestMultiLet(t *testing.T) {
+	input := []token.Token{
+		{Type: token.LET, Literal: "let"},
+		{Type: token.IDENT, Literal: "x"},
+		{Type: token.ASSIGN, Literal: "="},
+		{Type: token.INT, Literal: "1"},
+		{Type: token.IDENT, Literal: "y"},
+		{Type: token.ASSIGN, Literal: "="},
+		{Type: token.IDENT, Literal: "x"},
+		{Type: token.IN, Literal: "in"},
+		{Type: token.IDENT, Literal: "y"},
+		{Type: token.EOF, Literal: ""},
+	}
+	p := New(input)
+	expression := p.ParseExpression()
+
+	if reflect.ValueOf(expression).IsNil() {
+		t.Fatalf("Parse Expression return nil")
+	}
+	checkForParseErrors(p, t)
+	v, ok := expression.(*ast.MultiLetExpression)
+	if !ok {
+		t.Fatalf("Parse Expression expected %T, but returned %T", &ast.MultiLetExpression{}, expression)
+	}
+	if len(v.Bindings) != 2 {
+		t.Fatalf("Parse Expression expected 2 bindings, but got %d", len(v.Bindings))
+	}
+	testIdent(t, v.Bindings[0].Name, "x")
+	testIntLit(t, v.Bindings[0].Value, 1)
+	testIdent(t, v.Bindings[1].Name, "y")
+	testIdent(t, v.Bindings[1].Value, "x")
+	testIdent(t, v.In, "y")
+}
+
+func TestLetStar(t *testing.T) {
+	input := []token.Token{
+		{Type: token.LET_STAR, Literal: "let*"},
+		{Type: token.IDENT, Literal: "x"},
+		{Type: token.ASSIGN, Literal: "="},
+		{Type: token.INT, Literal: "1"},
+		{Type: token.IN, Literal: "in"},
+		{Type: token.IDENT, Literal: "x"},
+		{Type: token.EOF, Literal: ""},
+	}
+	p := New(input)
+	expression := p.ParseExpression()
+
+	if reflect.ValueOf(expression).IsNil() {
+		t.Fatalf("Parse Expression return nil")
+	}
+	checkForParseErrors(p, t)
+	v, ok := expression.(*ast.LetStarExpression)
+	if !ok {
+		t.Fatalf("Parse Expression expected %T, but returned %T", &ast.LetStarExpression{}, expression)
+	}
+	if len(v.Bindings) != 1 {
+		t.Fatalf("Parse Expression expected 1 binding, but got %d", len(v.Bindings))
+	}
+	testIdent(t, v.Bindings[0].Name, "x")
+	testIntLit(t, v.Bindings[0].Value, 1)
+	testIdent(t, v.In, "x")
+}
+
+func TestMultiLetMissingSecondAssign(t *testing.T) {
+	input := []token.Token{
+		{Type: token.LET, Literal: "let"},
+		{Type: token.IDENT, Literal: "x"},
+		{Type: token.ASSIGN, Literal: "="},
+		{Type: token.INT, Literal: "1"},
+		{Type: token.IDENT, Literal: "y"},
+		{Type: token.INT, Literal: "2"},
+		{Type: token.IN, Literal: "in"},
+		{Type: token.IDENT, Literal: "y"},
+		{Type: token.EOF, Literal: ""},
+	}
+	p := New(input)
+	expression := p.ParseExpression()
+
+	if !reflect.ValueOf(expression).IsNil() {
+		t.Fatalf("Parse Expression did not return nil")
+	}
+	checkParseErrorsExist(p, t, []string{
+		"to be =",
+	})
+}
+
+func TestPrimApp(t *testing.T) {
+	input := []token.Token{
+		{Type: token.IDENT, Literal: "plus"},
+		{Type: token.LPAREN, Literal: "("},
+		{Type: token.IDENT, Literal: "x"},
+		{Type: token.COMMA, Literal: ","},
+		{Type: token.IDENT, Literal: "equal?"},
+		{Type: token.LPAREN, Literal: "("},
+		{Type: token.INT, Literal: "1"},
+		{Type: token.COMMA, Literal: ","},
+		{Type: token.INT, Literal: "2"},
+		{Type: token.RPAREN, Literal: ")"},
+		{Type: token.RPAREN, Literal: ")"},
+		{Type: token.EOF, Literal: ""},
+	}
+	p := New(input)
+	expression := p.ParseExpression()
+
+	if reflect.ValueOf(expression).IsNil() {
+		t.Fatalf("Parse Expression return nil")
+	}
+	checkForParseErrors(p, t)
+	testPrimApp(t, expression, "plus", func(expression ast.Expression) {
+		testIdent(t, expression, "x")
+	}, func(expression ast.Expression) {
+		testPrimApp(t, expression, "equal?", func(expression ast.Expression) {
+			testIntLit(t, expression, 1)
+		}, func(expression ast.Expression) {
+			testIntLit(t, expression, 2)
+		})
+	})
+}
+
+func TestPrimitiveNameAsVariable(t *testing.T) {
+	input := []token.Token{
+		{Type: token.LET, Literal: "let"},
+		{Type: token.IDENT, Literal: "plus"},
+		{Type: token.ASSIGN, Literal: "="},
+		{Type: token.INT, Literal: "1"},
+		{Type: token.IN, Literal: "in"},
+		{Type: token.IDENT, Literal: "plus"},
+		{Type: token.EOF, Literal: ""},
+	}
+	p := New(input)
+	expression := p.ParseExpression()
+
+	if reflect.ValueOf(expression).IsNil() {
+		t.Fatalf("Parse Expression return nil")
+	}
+	checkForParseErrors(p, t)
+	testLetExpression(t, expression, "plus", func(expression ast.Expression) {
+		testIntLit(t, expression, 1)
+	}, func(expression ast.Expression) {
+		testIdent(t, expression, "plus")
+	})
+}
+
+func TestPrimAppWrongArity(t *testing.T) {
+	input := []token.Token{
+		{Type: token.IDENT, Literal: "times"},
+		{Type: token.LPAREN, Literal: "("},
+		{Type: token.INT, Literal: "1"},
+		{Type: token.COMMA, Literal: ","},
+		{Type: token.INT, Literal: "2"},
+		{Type: token.COMMA, Literal: ","},
+		{Type: token.INT, Literal: "3"},
+		{Type: token.RPAREN, Literal: ")"},
+		{Type: token.EOF, Literal: ""},
+	}
+	p := New(input)
+	expression := p.ParseExpression()
+
+	if !reflect.ValueOf(expression).IsNil() {
+		t.Fatalf("Parse Expression did not return nil")
+	}
+	checkParseErrorsExist(p, t, []string{
+		"times expects 2 argument(s), got 3",
+	})
+}
+
+func TestPrimAppMissingArg(t *testing.T) {
+	input := []token.Token{
+		{Type: token.IDENT, Literal: "plus"},
+		{Type: token.LPAREN, Literal: "("},
+		{Type: token.INT, Literal: "1"},
+		{Type: token.COMMA, Literal: ","},
+		{Type: token.RPAREN, Literal: ")"},
+		{Type: token.EOF, Literal: ""},
+	}
+	p := New(input)
+	expression := p.ParseExpression()
+
+	if !reflect.ValueOf(expression).IsNil() {
+		t.Fatalf("Parse Expression did not return nil")
+	}
+	checkParseErrorsExist(p, t, []string{
+		"Missing inner expression for Arg2",
+	})
+}
+
+func TestInfixLeftAssociative(t *testing.T) {
+	//x - 8 - y
+	input := []token.Token{
+		{Type: token.IDENT, Literal: "x"},
+		{Type: token.SUB, Literal: "-"},
+		{Type: token.INT, Literal: "8"},
+		{Type: token.SUB, Literal: "-"},
+		{Type: token.IDENT, Literal: "y"},
+		{Type: token.EOF, Literal: ""},
+	}
+	p := New(input)
+	expression := p.ParseExpression()
+	checkForParseErrors(p, t)
+	testMinus(t, expression, func(e ast.Expression) {
+		testMinus(t, e, func(e ast.Expression) {
+			testIdent(t, e, "x")
+		}, func(e ast.Expression) {
+			testIntLit(t, e, 8)
+		})
+	}, func(e ast.Expression) {
+		testIdent(t, e, "y")
+	})
+}
+
+func TestInfixPrecedence(t *testing.T) {
+	//1 + 2 * 3 == 7
+	input := []token.Token{
+		{Type: token.INT, Literal: "1"},
+		{Type: token.PLUS, Literal: "+"},
+		{Type: token.INT, Literal: "2"},
+		{Type: token.ASTERISK, Literal: "*"},
+		{Type: token.INT, Literal: "3"},
+		{Type: token.EQ, Literal: "=="},
+		{Type: token.INT, Literal: "7"},
+		{Type: token.EOF, Literal: ""},
+	}
+	p := New(input)
+	expression := p.ParseExpression()
+	checkForParseErrors(p, t)
+	testPrimApp(t, expression, "equal?", func(e ast.Expression) {
+		testPrimApp(t, e, "plus", func(e ast.Expression) {
+			testIntLit(t, e, 1)
+		}, func(e ast.Expression) {
+			testPrimApp(t, e, "times", func(e ast.Expression) {
+				testIntLit(t, e, 2)
+			}, func(e ast.Expression) {
+				testIntLit(t, e, 3)
+			})
+		})
+	}, func(e ast.Expression) {
+		testIntLit(t, e, 7)
+	})
+}
+
+func TestInfixGrouping(t *testing.T) {
+	//a * (b + c)
+	input := []token.Token{
+		{Type: token.IDENT, Literal: "a"},
+		{Type: token.ASTERISK, Literal: "*"},
+		{Type: token.LPAREN, Literal: "("},
+		{Type: token.IDENT, Literal: "b"},
+		{Type: token.PLUS, Literal: "+"},
+		{Type: token.IDENT, Literal: "c"},
+		{Type: token.RPAREN, Literal: ")"},
+		{Type: token.EOF, Literal: ""},
+	}
+	p := New(input)
+	expression := p.ParseExpression()
+	checkForParseErrors(p, t)
+	testPrimApp(t, expression, "times", func(e ast.Expression) {
+		testIdent(t, e, "a")
+	}, func(e ast.Expression) {
+		testPrimApp(t, e, "plus", func(e ast.Expression) {
+			testIdent(t, e, "b")
+		}, func(e ast.Expression) {
+			testIdent(t, e, "c")
+		})
+	})
+}
+
+func TestUnaryMinus(t *testing.T) {
+	//-x < y
+	input := []token.Token{
+		{Type: token.SUB, Literal: "-"},
+		{Type: token.IDENT, Literal: "x"},
+		{Type: token.LT, Literal: "<"},
+		{Type: token.IDENT, Literal: "y"},
+		{Type: token.EOF, Literal: ""},
+	}
+	p := New(input)
+	expression := p.ParseExpression()
+	checkForParseErrors(p, t)
+	testPrimApp(t, expression, "less?", func(e ast.Expression) {
+		testMinus(t, e, func(e ast.Expression) {
+			testIntLit(t, e, 0)
+		}, func(e ast.Expression) {
+			testIdent(t, e, "x")
+		})
+	}, func(e ast.Expression) {
+		testIdent(t, e, "y")
+	})
+}
+
+func TestInfixInsideLetAndCall(t *testing.T) {
+	//let x = 1 + 2 in (f x / 2)
+	input := []token.Token{
+		{Type: token.LET, Literal: "let"},
+		{Type: token.IDENT, Literal: "x"},
+		{Type: token.ASSIGN, Literal: "="},
+		{Type: token.INT, Literal: "1"},
+		{Type: token.PLUS, Literal: "+"},
+		{Type: token.INT, Literal: "2"},
+		{Type: token.IN, Literal: "in"},
+		{Type: token.LPAREN, Literal: "("},
+		{Type: token.IDENT, Literal: "f"},
+		{Type: token.IDENT, Literal: "x"},
+		{Type: token.SLASH, Literal: "/"},
+		{Type: token.INT, Literal: "2"},
+		{Type: token.RPAREN, Literal: ")"},
+		{Type: token.EOF, Literal: ""},
+	}
+	p := New(input)
+	expression := p.ParseExpression()
+	checkForParseErrors(p, t)
+	testLetExpression(t, expression, "x", func(e ast.Expression) {
+		testPrimApp(t, e, "plus", func(e ast.Expression) {
+			testIntLit(t, e, 1)
+		}, func(e ast.Expression) {
+			testIntLit(t, e, 2)
+		})
+	}, func(e ast.Expression) {
+		testCall(t, e, func(e ast.Expression) {
+			testIdent(t, e, "f")
+		}, func(e ast.Expression) {
+			testPrimApp(t, e, "quotient", func(e ast.Expression) {
+				testIdent(t, e, "x")
+			}, func(e ast.Expression) {
+				testIntLit(t, e, 2)
+			})
+		})
+	})
+}
+
+func TestParenthesizedNegation(t *testing.T) {
+	input := []token.Token{
+		{Type: token.LPAREN, Literal: "("},
+		{Type: token.SUB, Literal: "-"},
+		{Type: token.INT, Literal: "4"},
+		{Type: token.RPAREN, Literal: ")"},
+		{Type: token.EOF, Literal: ""},
+	}
+	p := New(input)
+	expression := p.ParseExpression()
+	checkForParseErrors(p, t)
+	testMinus(t, expression, func(e ast.Expression) {
+		testIntLit(t, e, 0)
+	}, func(e ast.Expression) {
+		testIntLit(t, e, 4)
+	})
+}
+
+func TestCallNegativeOperands(t *testing.T) {
+	//(f -1)
+	p := New([]token.Token{
+		{Type: token.LPAREN, Literal: "(", Line: 1, Column: 1},
+		{Type: token.IDENT, Literal: "f", Line: 1, Column: 2},
+		{Type: token.SUB, Literal: "-", Line: 1, Column: 4},
+		{Type: token.INT, Literal: "1", Line: 1, Column: 5},
+		{Type: token.RPAREN, Literal: ")", Line: 1, Column: 6},
+		{Type: token.EOF, Literal: ""},
+	})
+	expression := p.ParseExpression()
+	checkForParseErrors(p, t)
+	testCall(t, expression, func(e ast.Expression) {
+		testIdent(t, e, "f")
+	}, func(e ast.Expression) {
+		testMinus(t, e, func(e ast.Expression) {
+			testIntLit(t, e, 0)
+		}, func(e ast.Expression) {
+			testIntLit(t, e, 1)
+		})
+	})
+
+	//(f x -1)
+	p = New([]token.Token{
+		{Type: token.LPAREN, Literal: "(", Line: 1, Column: 1},
+		{Type: token.IDENT, Literal: "f", Line: 1, Column: 2},
+		{Type: token.IDENT, Literal: "x", Line: 1, Column: 4},
+		{Type: token.SUB, Literal: "-", Line: 1, Column: 6},
+		{Type: token.INT, Literal: "1", Line: 1, Column: 7},
+		{Type: token.RPAREN, Literal: ")", Line: 1, Column: 8},
+		{Type: token.EOF, Literal: ""},
+	})
+	expression = p.ParseExpression()
+	checkForParseErrors(p, t)
+	testCall(t, expression, func(e ast.Expression) {
+		testIdent(t, e, "f")
+	}, func(e ast.Expression) {
+		testIdent(t, e, "x")
+	}, func(e ast.Expression) {
+		testMinus(t, e, func(e ast.Expression) {
+			testIntLit(t, e, 0)
+		}, func(e ast.Expression) {
+			testIntLit(t, e, 1)
+		})
+	})
+}
+
+func TestParenthesizedSubtraction(t *testing.T) {
+	//(a - 1) and (a-1) are both a subtraction.
+	for _, columns := range [][]int{{1, 2, 4, 6, 7}, {1, 2, 3, 4, 5}} {
+		p := New([]token.Token{
+			{Type: token.LPAREN, Literal: "(", Line: 1, Column: columns[0]},
+			{Type: token.IDENT, Literal: "a", Line: 1, Column: columns[1]},
+			{Type: token.SUB, Literal: "-", Line: 1, Column: columns[2]},
+			{Type: token.INT, Literal: "1", Line: 1, Column: columns[3]},
+			{Type: token.RPAREN, Literal: ")", Line: 1, Column: columns[4]},
+			{Type: token.EOF, Literal: ""},
+		})
+		expression := p.ParseExpression()
+		checkForParseErrors(p, t)
+		testMinus(t, expression, func(e ast.Expression) {
+			testIdent(t, e, "a")
+		}, func(e ast.Expression) {
+			testIntLit(t, e, 1)
+		})
+	}
+}
+
+func TestInfixMissingRightSide(t *testing.T) {
+	input := []token.Token{
+		{Type: token.IDENT, Literal: "x"},
+		{Type: token.PLUS, Literal: "+"},
+		{Type: token.EOF, Literal: ""},
+	}
+	p := New(input)
+	p.ParseExpression()
+	checkParseErrorsExist(p, t, []string{
+		"Missing inner expression for right side of +",
+	})
+}
+
+func TestGroupingMissingRParen(t *testing.T) {
+	input := []token.Token{
+		{Type: token.LPAREN, Literal: "("},
+		{Type: token.IDENT, Literal: "x"},
+		{Type: token.PLUS, Literal: "+"},
+		{Type: token.INT, Literal: "1"},
+		{Type: token.EOF, Literal: ""},
+	}
+	p := New(input)
+	expression := p.ParseExpression()
+
+	if !reflect.ValueOf(expression).IsNil() {
+		t.Fatalf("Parse Expression did not return nil")
+	}
+	checkParseErrorsExist(p, t, []string{
+		"to be )",
+	})
+}
+
+func TestListPrimApp(t *testing.T) {
+	input := []token.Token{
+		{Type: token.IDENT, Literal: "list"},
+		{Type: token.LPAREN, Literal: "("},
+		{Type: token.INT, Literal: "1"},
+		{Type: token.COMMA, Literal: ","},
+		{Type: token.INT, Literal: "2"},
+		{Type: token.COMMA, Literal: ","},
+		{Type: token.EMPTY_LIST, Literal: "emptylist"},
+		{Type: token.RPAREN, Literal: ")"},
+		{Type: token.EOF, Literal: ""},
+	}
+	p := New(input)
+	expression := p.ParseExpression()
+	checkForParseErrors(p, t)
+	testPrimApp(t, expression, "list", func(e ast.Expression) {
+		testIntLit(t, e, 1)
+	}, func(e ast.Expression) {
+		testIntLit(t, e, 2)
+	}, func(e ast.Expression) {
+		if _, ok := e.(*ast.EmptyListLiteral); !ok {
+			t.Fatalf("Parse Expression expected %T, but returned %T", &ast.EmptyListLiteral{}, e)
+		}
+	})
+}
+
+func TestEmptyListPrimApp(t *testing.T) {
+	input := []token.Token{
+		{Type: token.IDENT, Literal: "list"},
+		{Type: token.LPAREN, Literal: "("},
+		{Type: token.RPAREN, Literal: ")"},
+		{Type: token.EOF, Literal: ""},
+	}
+	p := New(input)
+	expression := p.ParseExpression()
+	checkForParseErrors(p, t)
+	testPrimApp(t, expression, "list")
+}
+
+func TestBegin(t *testing.T) {
+	input := []token.Token{
+		{Type: token.BEGIN, Literal: "begin"},
+		{Type: token.IDENT, Literal: "setref"},
+		{Type: token.LPAREN, Literal: "("},
+		{Type: token.IDENT, Literal: "x"},
+		{Type: token.COMMA, Literal: ","},
+		{Type: token.INT, Literal: "1"},
+		{Type: token.RPAREN, Literal: ")"},
+		{Type: token.SEMICOLON, Literal: ";"},
+		{Type: token.IDENT, Literal: "deref"},
+		{Type: token.LPAREN, Literal: "("},
+		{Type: token.IDENT, Literal: "x"},
+		{Type: token.RPAREN, Literal: ")"},
+		{Type: token.END, Literal: "end"},
+		{Type: token.EOF, Literal: ""},
+	}
+	p := New(input)
+	expression := p.ParseExpression()
+
+	if reflect.ValueOf(expression).IsNil() {
+		t.Fatalf("Parse Expression return nil")
+	}
+	checkForParseErrors(p, t)
+	v, ok := expression.(*ast.BeginExpression)
+	if !ok {
+		t.Fatalf("Parse Expression expected %T, but returned %T", &ast.BeginExpression{}, expression)
+	}
+	if len(v.Exprs) != 2 {
+		t.Fatalf("Parse Expression expected 2 expressions, but got %d", len(v.Exprs))
+	}
+	testPrimApp(t, v.Exprs[0], "setref", func(e ast.Expression) {
+		testIdent(t, e, "x")
+	}, func(e ast.Expression) {
+		testIntLit(t, e, 1)
+	})
+	testPrimApp(t, v.Exprs[1], "deref", func(e ast.Expression) {
+		testIdent(t, e, "x")
+	})
+}
+
+func TestBeginMissingEnd(t *testing.T) {
+	input := []token.Token{
+		{Type: token.BEGIN, Literal: "begin"},
+		{Type: token.INT, Literal: "1"},
+		{Type: token.SEMICOLON, Literal: ";"},
+		{Type: token.INT, Literal: "2"},
+		{Type: token.EOF, Literal: ""},
+	}
+	p := New(input)
+	expression := p.ParseExpression()
+
+	if !reflect.ValueOf(expression).IsNil() {
+		t.Fatalf("Parse Expression did not return nil")
+	}
+	checkParseErrorsExist(p, t, []string{
+		"to be END",
+	})
+}
+
+func TestSet(t *testing.T) {
+	input := []token.Token{
+		{Type: token.SET, Literal: "set"},
+		{Type: token.IDENT, Literal: "x"},
+		{Type: token.ASSIGN, Literal: "="},
+		{Type: token.IDENT, Literal: "x"},
+		{Type: token.PLUS, Literal: "+"},
+		{Type: token.INT, Literal: "1"},
+		{Type: token.EOF, Literal: ""},
+	}
+	p := New(input)
+	expression := p.ParseExpression()
+
+	if reflect.ValueOf(expression).IsNil() {
+		t.Fatalf("Parse Expression return nil")
+	}
+	checkForParseErrors(p, t)
+	v, ok := expression.(*ast.SetExpression)
+	if !ok {
+		t.Fatalf("Parse Expression expected %T, but returned %T", &ast.SetExpression{}, expression)
+	}
+	testIdent(t, v.Name, "x")
+	testPrimApp(t, v.Value, "plus", func(e ast.Expression) {
+		testIdent(t, e, "x")
+	}, func(e ast.Expression) {
+		testIntLit(t, e, 1)
+	})
+}
+
+func TestSetMissingAssign(t *testing.T) {
+	input := []token.Token{
+		{Type: token.SET, Literal: "set"},
+		{Type: token.IDENT, Literal: "x"},
+		{Type: token.INT, Literal: "1"},
+		{Type: token.EOF, Literal: ""},
+	}
+	p := New(input)
+	expression := p.ParseExpression()
+
+	if !reflect.ValueOf(expression).IsNil() {
+		t.Fatalf("Parse Expression did not return nil")
+	}
+	checkParseErrorsExist(p, t, []string{
+		"to be =",
+	})
+}
+
+func TestTryCatch(t *testing.T) {
+	input := []token.Token{
+		{Type: token.TRY, Literal: "try"},
+		{Type: token.RAISE, Literal: "raise"},
+		{Type: token.INT, Literal: "1"},
+		{Type: token.CATCH, Literal: "catch"},
+		{Type: token.LPAREN, Literal: "("},
+		{Type: token.IDENT, Literal: "e"},
+		{Type: token.RPAREN, Literal: ")"},
+		{Type: token.IDENT, Literal: "e"},
+		{Type: token.EOF, Literal: ""},
+	}
+	p := New(input)
+	expression := p.ParseExpression()
+
+	if reflect.ValueOf(expression).IsNil() {
+		t.Fatalf("Parse Expression return nil")
+	}
+	checkForParseErrors(p, t)
+	v, ok := expression.(*ast.TryExpression)
+	if !ok {
+		t.Fatalf("Parse Expression expected %T, but returned %T", &ast.TryExpression{}, expression)
+	}
+	raise, ok := v.Body.(*ast.RaiseExpression)
+	if !ok {
+		t.Fatalf("Parse Expression expected %T, but returned %T", &ast.RaiseExpression{}, v.Body)
+	}
+	testIntLit(t, raise.Value, 1)
+	testIdent(t, v.Var, "e")
+	testIdent(t, v.Handler, "e")
+}
+
+func TestTryMissingCatch(t *testing.T) {
+	input := []token.Token{
+		{Type: token.TRY, Literal: "try"},
+		{Type: token.INT, Literal: "1"},
+		{Type: token.LPAREN, Literal: "("},
+		{Type: token.IDENT, Literal: "e"},
+		{Type: token.RPAREN, Literal: ")"},
+		{Type: token.IDENT, Literal: "e"},
+		{Type: token.EOF, Literal: ""},
+	}
+	p := New(input)
+	expression := p.ParseExpression()
+
+	if !reflect.ValueOf(expression).IsNil() {
+		t.Fatalf("Parse Expression did not return nil")
+	}
+	checkParseErrorsExist(p, t, []string{
+		"to be CATCH",
+	})
+}
+
+func TestRaiseMissingValue(t *testing.T) {
+	input := []token.Token{
+		{Type: token.RAISE, Literal: "raise"},
+		{Type: token.EOF, Literal: ""},
+	}
+	p := New(input)
+	expression := p.ParseExpression()
+
+	if !reflect.ValueOf(expression).IsNil() {
+		t.Fatalf("Parse Expression did not return nil")
+	}
+	checkParseErrorsExist(p, t, []string{
+		"Missing inner expression for Value",
+	})
+}
+
+func TestLetccThrow(t *testing.T) {
+	input := []token.Token{
+		{Type: token.LETCC, Literal: "letcc"},
+		{Type: token.IDENT, Literal: "k"},
+		{Type: token.IN, Literal: "in"},
+		{Type: token.THROW, Literal: "throw"},
+		{Type: token.INT, Literal: "1"},
+		{Type: token.TO, Literal: "to"},
+		{Type: token.IDENT, Literal: "k"},
+		{Type: token.EOF, Literal: ""},
+	}
+	p := New(input)
+	expression := p.ParseExpression()
+
+	if reflect.ValueOf(expression).IsNil() {
+		t.Fatalf("Parse Expression return nil")
+	}
+	checkForParseErrors(p, t)
+	v, ok := expression.(*ast.LetccExpression)
+	if !ok {
+		t.Fatalf("Parse Expression expected %T, but returned %T", &ast.LetccExpression{}, expression)
+	}
+	testIdent(t, v.Name, "k")
+	throw, ok := v.Body.(*ast.ThrowExpression)
+	if !ok {
+		t.Fatalf("Parse Expression expected %T, but returned %T", &ast.ThrowExpression{}, v.Body)
+	}
+	testIntLit(t, throw.Value, 1)
+	testIdent(t, throw.Cont, "k")
+}
+
+func TestLetccMissingIn(t *testing.T) {
+	input := []token.Token{
+		{Type: token.LETCC, Literal: "letcc"},
+		{Type: token.IDENT, Literal: "k"},
+		{Type: token.INT, Literal: "1"},
+		{Type: token.EOF, Literal: ""},
+	}
+	p := New(input)
+	expression := p.ParseExpression()
+
+	if !reflect.ValueOf(expression).IsNil() {
+		t.Fatalf("Parse Expression did not return nil")
+	}
+	checkParseErrorsExist(p, t, []string{
+		"to be IN",
+	})
+}
+
+func TestThrowMissingTo(t *testing.T) {
+	input := []token.Token{
+		{Type: token.THROW, Literal: "throw"},
+		{Type: token.INT, Literal: "1"},
+		{Type: token.IDENT, Literal: "k"},
+		{Type: token.EOF, Literal: ""},
+	}
+	p := New(input)
+	expression := p.ParseExpression()
+
+	if !reflect.ValueOf(expression).IsNil() {
+		t.Fatalf("Parse Expression did not return nil")
+	}
+	checkParseErrorsExist(p, t, []string{
+		"to be TO",
+	})
+}
+
+func TestThrowMissingCont(t *testing.T) {
+	input := []token.Token{
+		{Type: token.THROW, Literal: "throw"},
+		{Type: token.INT, Literal: "1"},
+		{Type: token.TO, Literal: "to"},
+		{Type: token.EOF, Literal: ""},
+	}
+	p := New(input)
+	expression := p.ParseExpression()
+
+	if !reflect.ValueOf(expression).IsNil() {
+		t.Fatalf("Parse Expression did not return nil")
+	}
+	checkParseErrorsExist(p, t, []string{
+		"Missing inner expression for Cont",
+	})
+}
+
+func TestProcParamTypes(t *testing.T) {
+	input := []token.Token{
+		{Type: token.PROC, Literal: "proc"},
+		{Type: token.LPAREN, Literal: "("},
+		{Type: token.IDENT, Literal: "f"},
+		{Type: token.COLON, Literal: ":"},
+		{Type: token.LPAREN, Literal: "("},
+		{Type: token.IDENT, Literal: "int"},
+		{Type: token.ASTERISK, Literal: "*"},
+		{Type: token.IDENT, Literal: "bool"},
+		{Type: token.ARROW, Literal: "->"},
+		{Type: token.IDENT, Literal: "int"},
+		{Type: token.RPAREN, Literal: ")"},
+		{Type: token.COMMA, Literal: ","},
+		{Type: token.IDENT, Literal: "x"},
+		{Type: token.COMMA, Literal: ","},
+		{Type: token.IDENT, Literal: "y"},
+		{Type: token.COLON, Literal: ":"},
+		{Type: token.IDENT, Literal: "bool"},
+		{Type: token.RPAREN, Literal: ")"},
+		{Type: token.IDENT, Literal: "x"},
+		{Type: token.EOF, Literal: ""},
+	}
+	p := New(input)
+	expression := p.ParseExpression()
+
+	if reflect.ValueOf(expression).IsNil() {
+		t.Fatalf("Parse Expression return nil")
+	}
+	checkForParseErrors(p, t)
+	v, ok := expression.(*ast.ProcExpression)
+	if !ok {
+		t.Fatalf("Parse Expression expected %T, but returned %T", &ast.ProcExpression{}, expression)
+	}
+	if len(v.ParamTypes) != 3 {
+		t.Fatalf("Parse Expression expected 3 param types, but got %d", len(v.ParamTypes))
+	}
+	if v.ParamTypes[0] == nil || v.ParamTypes[0].String() != "(int * bool -> int)" {
+		t.Errorf("Expected first param type to be (int * bool -> int) but was %v", v.ParamTypes[0])
+	}
+	if v.ParamTypes[1] != nil {
+		t.Errorf("Expected second param to have no type but was %v", v.ParamTypes[1])
+	}
+	if v.ParamTypes[2] != (ast.BoolType{}) {
+		t.Errorf("Expected third param type to be bool but was %v", v.ParamTypes[2])
+	}
+}
+
+func TestLetrecTypes(t *testing.T) {
+	input := []token.Token{
+		{Type: token.LETREC, Literal: "letrec"},
+		{Type: token.IDENT, Literal: "int"},
+		{Type: token.IDENT, Literal: "f"},
+		{Type: token.LPAREN, Literal: "("},
+		{Type: token.IDENT, Literal: "x"},
+		{Type: token.COLON, Literal: ":"},
+		{Type: token.IDENT, Literal: "int"},
+		{Type: token.RPAREN, Literal: ")"},
+		{Type: token.ASSIGN, Literal: "="},
+		{Type: token.IDENT, Literal: "x"},
+		{Type: token.LPAREN, Literal: "("},
+		{Type: token.ARROW, Literal: "->"},
+		{Type: token.IDENT, Literal: "bool"},
+		{Type: token.RPAREN, Literal: ")"},
+		{Type: token.IDENT, Literal: "g"},
+		{Type: token.LPAREN, Literal: "("},
+		{Type: token.RPAREN, Literal: ")"},
+		{Type: token.ASSIGN, Literal: "="},
+		{Type: token.TRUE, Literal: "true"},
+		{Type: token.IDENT, Literal: "h"},
+		{Type: token.LPAREN, Literal: "("},
+		{Type: token.RPAREN, Literal: ")"},
+		{Type: token.ASSIGN, Literal: "="},
+		{Type: token.INT, Literal: "1"},
+		{Type: token.IN, Literal: "in"},
+		{Type: token.INT, Literal: "3"},
+		{Type: token.EOF, Literal: ""},
+	}
+	p := New(input)
+	expression := p.ParseExpression()
+
+	if reflect.ValueOf(expression).IsNil() {
+		t.Fatalf("Parse Expression return nil")
+	}
+	checkForParseErrors(p, t)
+	v, ok := expression.(*ast.LetrecExpression)
+	if !ok {
+		t.Fatalf("Parse Expression expected %T, but returned %T", &ast.LetrecExpression{}, expression)
+	}
+	if len(v.Procs) != 3 {
+		t.Fatalf("Parse Expression expected 3 procs, but got %d", len(v.Procs))
+	}
+	testIdent(t, v.Procs[0].Name, "f")
+	if v.Procs[0].ResultType != (ast.IntType{}) || v.Procs[0].ParamTypes[0] != (ast.IntType{}) {
+		t.Errorf("Expected f to be annotated int f(x : int) but got %v and %v",
+			v.Procs[0].ResultType, v.Procs[0].ParamTypes)
+	}
+	testIdent(t, v.Procs[1].Name, "g")
+	if v.Procs[1].ResultType == nil || v.Procs[1].ResultType.String() != "(-> bool)" {
+		t.Errorf("Expected g to return (-> bool) but got %v", v.Procs[1].ResultType)
+	}
+	testIdent(t, v.Procs[2].Name, "h")
+	if v.Procs[2].ResultType != nil {
+		t.Errorf("Expected h to have no result type but got %v", v.Procs[2].ResultType)
+	}
+	testIntLit(t, v.In, 3)
+}
+
+func TestBadType(t *testing.T) {
+	input := []token.Token{
+		{Type: token.PROC, Literal: "proc"},
+		{Type: token.LPAREN, Literal: "("},
+		{Type: token.IDENT, Literal: "x"},
+		{Type: token.COLON, Literal: ":"},
+		{Type: token.IDENT, Literal: "integer"},
+		{Type: token.RPAREN, Literal: ")"},
+		{Type: token.IDENT, Literal: "x"},
+		{Type: token.EOF, Literal: ""},
+	}
+	p := New(input)
+	expression := p.ParseExpression()
+
+	if !reflect.ValueOf(expression).IsNil() {
+		t.Fatalf("Parse Expression did not return nil")
+	}
+	checkParseErrorsExist(p, t, []string{
+		"Expected a type, got integer instead",
+	})
+}
+
+func TestProcTypeMissingArrow(t *testing.T) {
+	input := []token.Token{
+		{Type: token.PROC, Literal: "proc"},
+		{Type: token.LPAREN, Literal: "("},
+		{Type: token.IDENT, Literal: "f"},
+		{Type: token.COLON, Literal: ":"},
+		{Type: token.LPAREN, Literal: "("},
+		{Type: token.IDENT, Literal: "int"},
+		{Type: token.IDENT, Literal: "int"},
+		{Type: token.RPAREN, Literal: ")"},
+		{Type: token.RPAREN, Literal: ")"},
+		{Type: token.IDENT, Literal: "f"},
+		{Type: token.EOF, Literal: ""},
+	}
+	p := New(input)
+	expression := p.ParseExpression()
+
+	if !reflect.ValueOf(expression).IsNil() {
+		t.Fatalf("Parse Expression did not return nil")
+	}
+	checkParseErrorsExist(p, t, []string{
+		"to be ->",
+	})
+}
+
+func TestUnknownTypeAnnotation(t *testing.T) {
+	input := []token.Token{
+		{Type: token.PROC, Literal: "proc"},
+		{Type: token.LPAREN, Literal: "("},
+		{Type: token.IDENT, Literal: "x"},
+		{Type: token.COLON, Literal: ":"},
+		{Type: token.QUESTION, Literal: "?"},
+		{Type: token.RPAREN, Literal: ")"},
+		{Type: token.IDENT, Literal: "x"},
+		{Type: token.EOF, Literal: ""},
+	}
+	p := New(input)
+	expression := p.ParseExpression()
+
+	if reflect.ValueOf(expression).IsNil() {
+		t.Fatalf("Parse Expression return nil")
+	}
+	checkForParseErrors(p, t)
+	v, ok := expression.(*ast.ProcExpression)
+	if !ok {
+		t.Fatalf("Parse Expression expected %T, but returned %T", &ast.ProcExpression{}, expression)
+	}
+	if v.ParamTypes[0] != (ast.UnknownType{}) {
+		t.Errorf("Expected param type to be ? but was %v", v.ParamTypes[0])
+	}
+}
+
+func TestProgramWithModules(t *testing.T) {
+	input := []token.Token{
+		{Type: token.MODULE, Literal: "module"},
+		{Type: token.IDENT, Literal: "m"},
+		{Type: token.INTERFACE, Literal: "interface"},
+		{Type: token.LBRACKET, Literal: "["},
+		{Type: token.IDENT, Literal: "a"},
+		{Type: token.COLON, Literal: ":"},
+		{Type: token.IDENT, Literal: "int"},
+		{Type: token.RBRACKET, Literal: "]"},
+		{Type: token.BODY, Literal: "body"},
+		{Type: token.LBRACKET, Literal: "["},
+		{Type: token.IDENT, Literal: "a"},
+		{Type: token.ASSIGN, Literal: "="},
+		{Type: token.INT, Literal: "1"},
+		{Type: token.IDENT, Literal: "b"},
+		{Type: token.ASSIGN, Literal: "="},
+		{Type: token.IDENT, Literal: "a"},
+		{Type: token.RBRACKET, Literal: "]"},
+		{Type: token.FROM, Literal: "from"},
+		{Type: token.IDENT, Literal: "m"},
+		{Type: token.TAKE, Literal: "take"},
+		{Type: token.IDENT, Literal: "a"},
+		{Type: token.EOF, Literal: ""},
+	}
+	p := New(input)
+	program := p.ParseProgram()
+
+	if program == nil {
+		t.Fatalf("Parse Program return nil")
+	}
+	checkForParseErrors(p, t)
+	if len(program.Modules) != 1 {
+		t.Fatalf("Parse Program expected 1 module, but got %d", len(program.Modules))
+	}
+	module := program.Modules[0]
+	if module.Name.Value != "m" || len(module.Interface) != 1 || len(module.Body) != 2 {
+		t.Fatalf("Parse Program returned the wrong module: %s with %d decls and %d defs",
+			module.Name.Value, len(module.Interface), len(module.Body))
+	}
+	if module.Interface[0].Name.Value != "a" || module.Interface[0].Type != (ast.IntType{}) {
+		t.Errorf("Expected interface to declare a : int but was %s : %v", module.Interface[0].Name.Value, module.Interface[0].Type)
+	}
+	if module.Body[1].Name.Value != "b" {
+		t.Errorf("Expected second definition to be b but was %s", module.Body[1].Name.Value)
+	}
+	v, ok := program.Body.(*ast.QualifiedVarExpression)
+	if !ok {
+		t.Fatalf("Parse Program expected %T, but returned %T", &ast.QualifiedVarExpression{}, program.Body)
+	}
+	if v.Module.Value != "m" || v.Var.Value != "a" {
+		t.Errorf("Expected from m take a but was from %s take %s", v.Module.Value, v.Var.Value)
+	}
+}
+
+func TestProgramWithoutModules(t *testing.T) {
+	input := []token.Token{
+		{Type: token.INT, Literal: "1"},
+		{Type: token.EOF, Literal: ""},
+	}
+	p := New(input)
+	program := p.ParseProgram()
+
+	if program == nil {
+		t.Fatalf("Parse Program return nil")
+	}
+	checkForParseErrors(p, t)
+	if len(program.Modules) != 0 {
+		t.Errorf("Parse Program expected no modules, but got %d", len(program.Modules))
+	}
+}
+
+func TestModuleMissingBody(t *testing.T) {
+	input := []token.Token{
+		{Type: token.MODULE, Literal: "module"},
+		{Type: token.IDENT, Literal: "m"},
+		{Type: token.INTERFACE, Literal: "interface"},
+		{Type: token.LBRACKET, Literal: "["},
+		{Type: token.RBRACKET, Literal: "]"},
+		{Type: token.INT, Literal: "1"},
+		{Type: token.EOF, Literal: ""},
+	}
+	p := New(input)
+	program := p.ParseProgram()
+
+	if program != nil {
+		t.Fatalf("Parse Program did not return nil")
+	}
+	checkParseErrorsExist(p, t, []string{
+		"to be BODY",
+	})
+}
+
+func TestProgramTrailingTokens(t *testing.T) {
+	input := []token.Token{
+		{Type: token.INT, Literal: "1"},
+		{Type: token.INT, Literal: "2"},
+		{Type: token.EOF, Literal: ""},
+	}
+	p := New(input)
+	program := p.ParseProgram()
+
+	if program != nil {
+		t.Fatalf("Parse Program did not return nil")
+	}
+	checkParseErrorsExist(p, t, []string{
+		"Expected the program to end, got INT instead",
+	})
+}
+
+func TestFromMissingTake(t *testing.T) {
+	input := []token.Token{
+		{Type: token.FROM, Literal: "from"},
+		{Type: token.IDENT, Literal: "m"},
+		{Type: token.IDENT, Literal: "a"},
+		{Type: token.EOF, Literal: ""},
+	}
+	p := New(input)
+	expression := p.ParseExpression()
+
+	if !reflect.ValueOf(expression).IsNil() {
+		t.Fatalf("Parse Expression did not return nil")
+	}
+	checkParseErrorsExist(p, t, []string{
+		"to be TAKE",
+	})
+}
+
+func TestClassDecl(t *testing.T) {
+	input := []token.Token{
+		{Type: token.CLASS, Literal: "class"},
+		{Type: token.IDENT, Literal: "c"},
+		{Type: token.EXTENDS, Literal: "extends"},
+		{Type: token.IDENT, Literal: "object"},
+		{Type: token.FIELD, Literal: "field"},
+		{Type: token.IDENT, Literal: "i"},
+		{Type: token.METHOD, Literal: "method"},
+		{Type: token.IDENT, Literal: "get"},
+		{Type: token.LPAREN, Literal: "("},
+		{Type: token.RPAREN, Literal: ")"},
+		{Type: token.IDENT, Literal: "i"},
+		{Type: token.METHOD, Literal: "method"},
+		{Type: token.IDENT, Literal: "add"},
+		{Type: token.LPAREN, Literal: "("},
+		{Type: token.IDENT, Literal: "x"},
+		{Type: token.COLON, Literal: ":"},
+		{Type: token.IDENT, Literal: "int"},
+		{Type: token.RPAREN, Literal: ")"},
+		{Type: token.SUPER, Literal: "super"},
+		{Type: token.IDENT, Literal: "add"},
+		{Type: token.LPAREN, Literal: "("},
+		{Type: token.SELF, Literal: "self"},
+		{Type: token.RPAREN, Literal: ")"},
+		{Type: token.SEND, Literal: "send"},
+		{Type: token.NEW, Literal: "new"},
+		{Type: token.IDENT, Literal: "c"},
+		{Type: token.LPAREN, Literal: "("},
+		{Type: token.RPAREN, Literal: ")"},
+		{Type: token.IDENT, Literal: "add"},
+		{Type: token.LPAREN, Literal: "("},
+		{Type: token.INT, Literal: "1"},
+		{Type: token.RPAREN, Literal: ")"},
+		{Type: token.EOF, Literal: ""},
+	}
+	p := New(input)
+	program := p.ParseProgram()
+
+	if program == nil {
+		t.Fatalf("Parse Program return nil")
+	}
+	checkForParseErrors(p, t)
+	if len(program.Classes) != 1 {
+		t.Fatalf("Parse Program expected 1 class, but got %d", len(program.Classes))
+	}
+	class := program.Classes[0]
+	if class.Name.Value != "c" || class.Super.Value != "object" || len(class.Fields) != 1 || len(class.Methods) != 2 {
+		t.Fatalf("Parse Program returned the wrong class: %s extends %s with %d fields and %d methods",
+			class.Name.Value, class.Super.Value, len(class.Fields), len(class.Methods))
+	}
+	if paramTypes := class.Methods[1].ParamTypes; len(paramTypes) != 1 || paramTypes[0] != (ast.IntType{}) {
+		t.Errorf("Expected add's param type to be int but was %v", paramTypes)
+	}
+	superCall, ok := class.Methods[1].Body.(*ast.SuperCallExpression)
+	if !ok {
+		t.Fatalf("Expected add's body to be %T, but was %T", &ast.SuperCallExpression{}, class.Methods[1].Body)
+	}
+	if _, ok := superCall.Args[0].(*ast.SelfExpression); !ok {
+		t.Errorf("Expected super's argument to be %T, but was %T", &ast.SelfExpression{}, superCall.Args[0])
+	}
+	send, ok := program.Body.(*ast.MethodCallExpression)
+	if !ok {
+		t.Fatalf("Parse Program expected %T, but returned %T", &ast.MethodCallExpression{}, program.Body)
+	}
+	if _, ok := send.Object.(*ast.NewObjectExpression); !ok || send.Method.Value != "add" || len(send.Args) != 1 {
+		t.Errorf("Expected send new c() add(1) but got send %T %s with %d argument(s)", send.Object, send.Method.Value, len(send.Args))
+	}
+}
+
+func TestClassMissingExtends(t *testing.T) {
+	input := []token.Token{
+		{Type: token.CLASS, Literal: "class"},
+		{Type: token.IDENT, Literal: "c"},
+		{Type: token.FIELD, Literal: "field"},
+		{Type: token.IDENT, Literal: "i"},
+		{Type: token.INT, Literal: "1"},
+		{Type: token.EOF, Literal: ""},
+	}
+	p := New(input)
+	program := p.ParseProgram()
+
+	if program != nil {
+		t.Fatalf("Parse Program did not return nil")
+	}
+	checkParseErrorsExist(p, t, []string{
+		"to be EXTENDS",
+	})
+}
+
+func TestSendMissingMethod(t *testing.T) {
+	input := []token.Token{
+		{Type: token.SEND, Literal: "send"},
+		{Type: token.IDENT, Literal: "o"},
+		{Type: token.LPAREN, Literal: "("},
+		{Type: token.RPAREN, Literal: ")"},
+		{Type: token.EOF, Literal: ""},
+	}
+	p := New(input)
+	expression := p.ParseExpression()
+
+	if !reflect.ValueOf(expression).IsNil() {
+		t.Fatalf("Parse Expression did not return nil")
+	}
+	checkParseErrorsExist(p, t, []string{
+		"to be IDENT",
+	})
+}
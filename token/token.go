@@ -5,6 +5,8 @@ type TokenType string
 type Token struct {
 	Type    TokenType
 	Literal string
+	Line    int //Line and Column of the token's first character, both start at 1.
+	Column  int
 }
 
 func MakeToken(tokenType TokenType, char byte) Token {
@@ -16,13 +18,42 @@ func MakeToken(tokenType TokenType, char byte) Token {
 
 func KeywordLookup(literal string) TokenType {
 	keywordsMap := map[string]TokenType{
-		"if":     IF,
-		"else":   ELSE,
-		"then":   THEN,
-		"let":    LET,
-		"in":     IN,
-		"minus":  MINUS,
-		"iszero": IS_ZERO,
+		"if":        IF,
+		"else":      ELSE,
+		"then":      THEN,
+		"let":       LET,
+		"letrec":    LETREC,
+		"let*":      LET_STAR,
+		"in":        IN,
+		"minus":     MINUS,
+		"iszero":    IS_ZERO,
+		"zero?":     IS_ZERO,
+		"proc":      PROC,
+		"true":      TRUE,
+		"false":     FALSE,
+		"emptylist": EMPTY_LIST,
+		"begin":     BEGIN,
+		"end":       END,
+		"set":       SET,
+		"try":       TRY,
+		"catch":     CATCH,
+		"raise":     RAISE,
+		"letcc":     LETCC,
+		"throw":     THROW,
+		"to":        TO,
+		"module":    MODULE,
+		"interface": INTERFACE,
+		"body":      BODY,
+		"from":      FROM,
+		"take":      TAKE,
+		"class":     CLASS,
+		"extends":   EXTENDS,
+		"field":     FIELD,
+		"method":    METHOD,
+		"new":       NEW,
+		"send":      SEND,
+		"super":     SUPER,
+		"self":      SELF,
 	}
 	if tokType, ok := keywordsMap[literal]; ok {
 		return tokType
@@ -30,25 +61,86 @@ func KeywordLookup(literal string) TokenType {
 	return IDENT
 }
 
+//symbolicNames are the primitive names with symbols in them, the lexer reads each as one
+//identifier while a-b is still a subtraction.
+var symbolicNames = map[string]bool{
+	"string-length":  true,
+	"string-append":  true,
+	"string=?":       true,
+	"number->string": true,
+}
+
+func IsSymbolicName(literal string) bool {
+	return symbolicNames[literal]
+}
+
 const (
 	ILLEGAL = "ILLEGAL"
 	EOF     = "EOF"
 
 	//Ident and lit
-	IDENT = "IDENT"
-	INT   = "INT"
+	IDENT  = "IDENT"
+	INT    = "INT"
+	FLOAT  = "FLOAT"
+	STRING = "STRING"
+
+	//COMMENT is only returned by a lexer that keeps comments.
+	COMMENT = "COMMENT"
 
 	//Keywords
-	LET     = "LET"
-	IN      = "IN"
-	IF      = "IF"
-	THEN    = "THEN"
-	ELSE    = "ELSE"
-	IS_ZERO = "IS_ZERO"
-	MINUS   = "MINUS"
-
-	ASSIGN = "="
-	COMMA  = ","
-	LPAREN = "("
-	RPAREN = ")"
+	LET        = "LET"
+	LETREC     = "LETREC"
+	LET_STAR   = "LET_STAR"
+	IN         = "IN"
+	IF         = "IF"
+	THEN       = "THEN"
+	ELSE       = "ELSE"
+	IS_ZERO    = "IS_ZERO"
+	MINUS      = "MINUS"
+	PROC       = "PROC"
+	TRUE       = "TRUE"
+	FALSE      = "FALSE"
+	EMPTY_LIST = "EMPTY_LIST"
+	BEGIN      = "BEGIN"
+	END        = "END"
+	SET        = "SET"
+	TRY        = "TRY"
+	CATCH      = "CATCH"
+	RAISE      = "RAISE"
+	LETCC      = "LETCC"
+	THROW      = "THROW"
+	TO         = "TO"
+	MODULE     = "MODULE"
+	INTERFACE  = "INTERFACE"
+	BODY       = "BODY"
+	FROM       = "FROM"
+	TAKE       = "TAKE"
+	CLASS      = "CLASS"
+	EXTENDS    = "EXTENDS"
+	FIELD      = "FIELD"
+	METHOD     = "METHOD"
+	NEW        = "NEW"
+	SEND       = "SEND"
+	SUPER      = "SUPER"
+	SELF       = "SELF"
+
+	COLON     = ":"
+	QUESTION  = "?"
+	LBRACKET  = "["
+	RBRACKET  = "]"
+	ARROW     = "->"
+	ASSIGN    = "="
+	COMMA     = ","
+	SEMICOLON = ";"
+	LPAREN    = "("
+	RPAREN    = ")"
+
+	//Infix operators
+	PLUS     = "+"
+	SUB      = "-"
+	ASTERISK = "*"
+	SLASH    = "/"
+	EQ       = "=="
+	LT       = "<"
+	GT       = ">"
 )
@@ -0,0 +1,662 @@
+package typecheck
+
+import (
+	"let_lang_proj_michael_andrepont/ast"
+	"let_lang_proj_michael_andrepont/token"
+	"errors"
+	"fmt"
+)
+
+//Error is a type error found before the program runs, Token is where it was found.
+type Error struct {
+	Token   token.Token
+	Message string
+}
+
+func (e *Error) Error() string {
+	if e.Token.Line == 0 {
+		return e.Message
+	}
+	return fmt.Sprintf("%d:%d: %s", e.Token.Line, e.Token.Column, e.Message)
+}
+
+//TypeVar is a type the checker has not worked out yet. Once unification finds what it stands
+//for, binding holds that type.
+type TypeVar struct {
+	id      int
+	binding ast.Type
+}
+
+func (t *TypeVar) String() string {
+	if t.binding != nil {
+		return t.binding.String()
+	}
+	return fmt.Sprintf("t%d", t.id)
+}
+
+//scheme is a polymorphic type, vars can be replaced by fresh type vars at each use.
+type scheme struct {
+	vars []*TypeVar
+	t    ast.Type
+}
+
+type typeBinding struct {
+	name string
+	s    scheme
+}
+
+//typeEnv is searched from the front like a BindingList, so inner bindings shadow outer ones.
+type typeEnv []typeBinding
+
+func (tenv typeEnv) extend(name string, t ast.Type) typeEnv {
+	return tenv.extendScheme(name, scheme{t: t})
+}
+
+func (tenv typeEnv) extendScheme(name string, s scheme) typeEnv {
+	return append(typeEnv{{name: name, s: s}}, tenv...)
+}
+
+func (tenv typeEnv) lookup(name string) (scheme, bool) {
+	for _, b := range tenv {
+		if b.name == name {
+			return b.s, true
+		}
+	}
+	return scheme{}, false
+}
+
+var intType, boolType, stringType ast.Type = ast.IntType{}, ast.BoolType{}, ast.StringType{}
+
+var primitiveTypes = map[string]*ast.ProcType{
+	"minus":     {Params: []ast.Type{intType, intType}, Result: intType},
+	"plus":      {Params: []ast.Type{intType, intType}, Result: intType},
+	"times":     {Params: []ast.Type{intType, intType}, Result: intType},
+	"quotient":  {Params: []ast.Type{intType, intType}, Result: intType},
+	"remainder": {Params: []ast.Type{intType, intType}, Result: intType},
+	"equal?":    {Params: []ast.Type{intType, intType}, Result: boolType},
+	"greater?":  {Params: []ast.Type{intType, intType}, Result: boolType},
+	"less?":     {Params: []ast.Type{intType, intType}, Result: boolType},
+	"zero?":     {Params: []ast.Type{intType}, Result: boolType},
+
+	"string-length":  {Params: []ast.Type{stringType}, Result: intType},
+	"string-append":  {Params: []ast.Type{stringType}, Result: stringType},
+	"substring":      {Params: []ast.Type{stringType, intType, intType}, Result: stringType},
+	"string=?":       {Params: []ast.Type{stringType, stringType}, Result: boolType},
+	"number->string": {Params: []ast.Type{intType}, Result: stringType},
+}
+
+//variadicPrimitives take any number of arguments of their one param type.
+var variadicPrimitives = map[string]bool{"string-append": true}
+
+//Inference is the result of type checking a program, Type is the type of the whole program.
+//Unsupported holds the constructs the checker has no type for, they are not type errors but
+//the program is only partly checked when there are any.
+type Inference struct {
+	Type        ast.Type
+	Errors      []*Error
+	Unsupported []*Error
+	types       map[ast.Expression]ast.Type
+	names       map[*TypeVar]*TypeVar
+}
+
+//Infer works out the type of expr and of each of its subexpressions. Parameters without an
+//annotation, or annotated with ?, get their type inferred, and names bound by let and letrec
+//are polymorphic.
+func Infer(expr ast.Expression) *Inference {
+	c := &checker{types: map[ast.Expression]ast.Type{}}
+	t := c.check(expr, typeEnv{})
+	inf := &Inference{Errors: c.errors, Unsupported: c.unsupported, types: c.types, names: map[*TypeVar]*TypeVar{}}
+	inf.Type = inf.display(t)
+	return inf
+}
+
+//InferProgram checks the modules of a program against their interfaces, then infers the type
+//of its body.
+func InferProgram(program *ast.Program) *Inference {
+	c := &checker{types: map[ast.Expression]ast.Type{}, modules: map[string]map[string]scheme{}}
+	for _, class := range program.Classes {
+		c.unsupportedf(class.Token, "class")
+	}
+	for _, module := range program.Modules {
+		c.checkModule(module)
+	}
+	t := c.check(program.Body, typeEnv{})
+	inf := &Inference{Errors: c.errors, Unsupported: c.unsupported, types: c.types, names: map[*TypeVar]*TypeVar{}}
+	inf.Type = inf.display(t)
+	return inf
+}
+
+//Check returns the type of expr and every type error found in it.
+func Check(expr ast.Expression) (ast.Type, []*Error) {
+	inf := Infer(expr)
+	return inf.Type, inf.Errors
+}
+
+//TypeOf returns the type inferred for a subexpression, or nil if it was not checked.
+func (inf *Inference) TypeOf(expr ast.Expression) ast.Type {
+	t, ok := inf.types[expr]
+	if !ok {
+		return nil
+	}
+	return inf.display(t)
+}
+
+//Annotate sets the type of every checked subexpression, so Print shows them.
+func (inf *Inference) Annotate() {
+	for expr := range inf.types {
+		expr.SetType(inf.TypeOf(expr))
+	}
+}
+
+//display resolves t and renames its type vars to t1, t2... in the order they are first shown,
+//the names are shared by every type shown from the same Inference.
+func (inf *Inference) display(t ast.Type) ast.Type {
+	switch t := resolve(t).(type) {
+	case *TypeVar:
+		if name, ok := inf.names[t]; ok {
+			return name
+		}
+		name := &TypeVar{id: len(inf.names) + 1}
+		inf.names[t] = name
+		return name
+	case *ast.ProcType:
+		params := make([]ast.Type, len(t.Params))
+		for i, param := range t.Params {
+			params[i] = inf.display(param)
+		}
+		return &ast.ProcType{Params: params, Result: inf.display(t.Result)}
+	default:
+		return t
+	}
+}
+
+type checker struct {
+	errors      []*Error
+	unsupported []*Error
+	types       map[ast.Expression]ast.Type
+	modules     map[string]map[string]scheme
+	nextVar     int
+}
+
+func (c *checker) errorf(tok token.Token, format string, args ...interface{}) {
+	c.errors = append(c.errors, &Error{Token: tok, Message: fmt.Sprintf(format, args...)})
+}
+
+//unsupportedf records a construct the checker has no type for, it gets a fresh type so the rest
+//of the program is still checked.
+func (c *checker) unsupportedf(tok token.Token, name string) {
+	c.unsupported = append(c.unsupported, &Error{Token: tok, Message: "the type checker does not support " + name})
+}
+
+func (c *checker) fresh() *TypeVar {
+	c.nextVar++
+	return &TypeVar{id: c.nextVar}
+}
+
+//resolve follows bound type vars until it reaches a type that is not a bound var.
+func resolve(t ast.Type) ast.Type {
+	for {
+		v, ok := t.(*TypeVar)
+		if !ok || v.binding == nil {
+			return t
+		}
+		t = v.binding
+	}
+}
+
+//Equal reports whether two types are the same.
+func Equal(a, b ast.Type) bool {
+	a, b = resolve(a), resolve(b)
+	switch a := a.(type) {
+	case ast.IntType:
+		_, ok := b.(ast.IntType)
+		return ok
+	case ast.BoolType:
+		_, ok := b.(ast.BoolType)
+		return ok
+	case ast.StringType:
+		_, ok := b.(ast.StringType)
+		return ok
+	case *TypeVar:
+		return a == b
+	case *ast.ProcType:
+		bProc, ok := b.(*ast.ProcType)
+		if !ok || len(a.Params) != len(bProc.Params) {
+			return false
+		}
+		for i := range a.Params {
+			if !Equal(a.Params[i], bProc.Params[i]) {
+				return false
+			}
+		}
+		return Equal(a.Result, bProc.Result)
+	}
+	return false
+}
+
+var errMismatch = errors.New("types do not match")
+
+type infiniteTypeError struct {
+	v *TypeVar
+	t ast.Type
+}
+
+func (e *infiniteTypeError) Error() string {
+	return fmt.Sprintf("cannot construct the infinite type %s = %s", e.v, e.t)
+}
+
+func occurs(v *TypeVar, t ast.Type) bool {
+	switch t := resolve(t).(type) {
+	case *TypeVar:
+		return t == v
+	case *ast.ProcType:
+		for _, param := range t.Params {
+			if occurs(v, param) {
+				return true
+			}
+		}
+		return occurs(v, t.Result)
+	}
+	return false
+}
+
+//unify binds type vars so that a and b become the same type.
+func unify(a, b ast.Type) error {
+	a, b = resolve(a), resolve(b)
+	if aVar, ok := a.(*TypeVar); ok {
+		if a == b {
+			return nil
+		}
+		if occurs(aVar, b) {
+			return &infiniteTypeError{v: aVar, t: b}
+		}
+		aVar.binding = b
+		return nil
+	}
+	if _, ok := b.(*TypeVar); ok {
+		return unify(b, a)
+	}
+	switch a := a.(type) {
+	case *ast.ProcType:
+		bProc, ok := b.(*ast.ProcType)
+		if !ok || len(a.Params) != len(bProc.Params) {
+			return errMismatch
+		}
+		for i := range a.Params {
+			if err := unify(a.Params[i], bProc.Params[i]); err != nil {
+				return err
+			}
+		}
+		return unify(a.Result, bProc.Result)
+	default:
+		if !Equal(a, b) {
+			return errMismatch
+		}
+		return nil
+	}
+}
+
+//unifyOrReport unifies a and b, reporting a mismatch with the message from format.
+func (c *checker) unifyOrReport(tok token.Token, a, b ast.Type, format string, args ...interface{}) {
+	err := unify(a, b)
+	if infinite, ok := err.(*infiniteTypeError); ok {
+		c.errorf(tok, "%s", infinite)
+	} else if err != nil {
+		c.errorf(tok, format, args...)
+	}
+}
+
+//expect checks expr and reports an error if its type can not be want.
+func (c *checker) expect(expr ast.Expression, tenv typeEnv, want ast.Type, operation string) {
+	t := c.check(expr, tenv)
+	c.unifyOrReport(expr.GetToken(), t, want, "%s expects %s, got %s", operation, want, resolve(t))
+}
+
+//annotation turns a type annotation into a type, nil and ? become fresh type vars.
+func (c *checker) annotation(t ast.Type) ast.Type {
+	switch t := t.(type) {
+	case nil, ast.UnknownType:
+		return c.fresh()
+	case *ast.ProcType:
+		params := make([]ast.Type, len(t.Params))
+		for i, param := range t.Params {
+			params[i] = c.annotation(param)
+		}
+		return &ast.ProcType{Params: params, Result: c.annotation(t.Result)}
+	}
+	return t
+}
+
+func freeVars(t ast.Type, vars map[*TypeVar]bool) {
+	switch t := resolve(t).(type) {
+	case *TypeVar:
+		vars[t] = true
+	case *ast.ProcType:
+		for _, param := range t.Params {
+			freeVars(param, vars)
+		}
+		freeVars(t.Result, vars)
+	}
+}
+
+//generalize makes a scheme of t over the type vars that are not used by tenv.
+func generalize(t ast.Type, tenv typeEnv) scheme {
+	inEnv := map[*TypeVar]bool{}
+	for _, b := range tenv {
+		bound := map[*TypeVar]bool{}
+		for _, v := range b.s.vars {
+			bound[v] = true
+		}
+		vars := map[*TypeVar]bool{}
+		freeVars(b.s.t, vars)
+		for v := range vars {
+			if !bound[v] {
+				inEnv[v] = true
+			}
+		}
+	}
+	vars := map[*TypeVar]bool{}
+	freeVars(t, vars)
+	s := scheme{t: t}
+	//Collect the vars in the order they appear so the scheme is the same on every run.
+	var ordered func(t ast.Type)
+	ordered = func(t ast.Type) {
+		switch t := resolve(t).(type) {
+		case *TypeVar:
+			if vars[t] && !inEnv[t] {
+				s.vars = append(s.vars, t)
+				delete(vars, t)
+			}
+		case *ast.ProcType:
+			for _, param := range t.Params {
+				ordered(param)
+			}
+			ordered(t.Result)
+		}
+	}
+	ordered(t)
+	return s
+}
+
+func (c *checker) instantiate(s scheme) ast.Type {
+	if len(s.vars) == 0 {
+		return s.t
+	}
+	subst := map[*TypeVar]ast.Type{}
+	for _, v := range s.vars {
+		subst[v] = c.fresh()
+	}
+	var replace func(t ast.Type) ast.Type
+	replace = func(t ast.Type) ast.Type {
+		switch t := resolve(t).(type) {
+		case *TypeVar:
+			if newVar, ok := subst[t]; ok {
+				return newVar
+			}
+			return t
+		case *ast.ProcType:
+			params := make([]ast.Type, len(t.Params))
+			for i, param := range t.Params {
+				params[i] = replace(param)
+			}
+			return &ast.ProcType{Params: params, Result: replace(t.Result)}
+		default:
+			return t
+		}
+	}
+	return replace(s.t)
+}
+
+//check infers the type of expr and records it for TypeOf.
+func (c *checker) check(expr ast.Expression, tenv typeEnv) ast.Type {
+	t := c.infer(expr, tenv)
+	c.types[expr] = t
+	return t
+}
+
+func (c *checker) infer(expr ast.Expression, tenv typeEnv) ast.Type {
+	switch e := expr.(type) {
+	case *ast.IntLiteral:
+		return intType
+	case *ast.FloatLiteral:
+		//Floats have no type of their own, and would be promoted past int by the primitives.
+		c.unsupportedf(e.Token, "float literals")
+		return c.fresh()
+	case *ast.StringLiteral:
+		return stringType
+	case *ast.BoolLiteral:
+		return boolType
+	case *ast.Identifier:
+		if s, ok := tenv.lookup(e.Value); ok {
+			return c.instantiate(s)
+		}
+		c.errorf(e.Token, "unbound variable %s", e.Value)
+		return c.fresh()
+	case *ast.QualifiedVarExpression:
+		exports, ok := c.modules[e.Module.Value]
+		if !ok {
+			c.errorf(e.Module.Token, "unknown module %s", e.Module.Value)
+			return c.fresh()
+		}
+		if s, ok := exports[e.Var.Value]; ok {
+			return c.instantiate(s)
+		}
+		c.errorf(e.Var.Token, "module %s does not export %s", e.Module.Value, e.Var.Value)
+		return c.fresh()
+	case *ast.MinusExpression:
+		c.expect(e.Arg1, tenv, intType, "minus")
+		c.expect(e.Arg2, tenv, intType, "minus")
+		return intType
+	case *ast.IsZeroExpression:
+		c.expect(e.Arg1, tenv, intType, "zero?")
+		return boolType
+	case *ast.PrimAppExpression:
+		return c.checkPrimApp(e, tenv)
+	case *ast.IfThenElseExpression:
+		c.expect(e.Value, tenv, boolType, "if")
+		trueType := c.check(e.TrueBranch, tenv)
+		falseType := c.check(e.FalseBranch, tenv)
+		c.unifyOrReport(e.Token, trueType, falseType, "if branches have different types, %s and %s",
+			resolve(trueType), resolve(falseType))
+		return trueType
+	case *ast.LetExpression:
+		valueType := c.check(e.Value, tenv)
+		c.types[e.Name] = valueType
+		return c.check(e.In, tenv.extendScheme(e.Name.Value, generalize(valueType, tenv)))
+	case *ast.MultiLetExpression:
+		newEnv := tenv
+		for _, binding := range e.Bindings {
+			valueType := c.check(binding.Value, tenv)
+			c.types[binding.Name] = valueType
+			newEnv = newEnv.extendScheme(binding.Name.Value, generalize(valueType, tenv))
+		}
+		return c.check(e.In, newEnv)
+	case *ast.LetStarExpression:
+		for _, binding := range e.Bindings {
+			valueType := c.check(binding.Value, tenv)
+			c.types[binding.Name] = valueType
+			tenv = tenv.extendScheme(binding.Name.Value, generalize(valueType, tenv))
+		}
+		return c.check(e.In, tenv)
+	case *ast.ProcExpression:
+		paramTypes := c.paramTypes(e.Params, e.ParamTypes)
+		bodyEnv := tenv
+		for i, param := range e.Params {
+			bodyEnv = bodyEnv.extend(param.Value, paramTypes[i])
+		}
+		return &ast.ProcType{Params: paramTypes, Result: c.check(e.Body, bodyEnv)}
+	case *ast.CallExpression:
+		return c.checkCall(e, tenv)
+	case *ast.LetrecExpression:
+		return c.checkLetrec(e, tenv)
+	case *ast.BeginExpression:
+		var t ast.Type = c.fresh()
+		for _, expr := range e.Exprs {
+			t = c.check(expr, tenv)
+		}
+		return t
+	}
+	tok := expr.GetToken()
+	name := tok.Literal
+	if name == "" {
+		name = fmt.Sprintf("%T", expr)
+	}
+	c.unsupportedf(tok, name)
+	c.checkUnsupportedChildren(expr, tenv)
+	return c.fresh()
+}
+
+//checkUnsupportedChildren checks the subexpressions of a construct the checker has no type for,
+//so the errors inside it are still found. Names it binds get a fresh type.
+func (c *checker) checkUnsupportedChildren(expr ast.Expression, tenv typeEnv) {
+	switch e := expr.(type) {
+	case *ast.SetExpression:
+		c.checkAll(tenv, e.Name, e.Value)
+	case *ast.RaiseExpression:
+		c.checkAll(tenv, e.Value)
+	case *ast.TryExpression:
+		c.checkAll(tenv, e.Body)
+		c.checkAll(tenv.extend(e.Var.Value, c.fresh()), e.Handler)
+	case *ast.LetccExpression:
+		c.checkAll(tenv.extend(e.Name.Value, c.fresh()), e.Body)
+	case *ast.ThrowExpression:
+		c.checkAll(tenv, e.Value, e.Cont)
+	case *ast.NewObjectExpression:
+		c.checkAll(tenv, e.Args...)
+	case *ast.MethodCallExpression:
+		c.checkAll(tenv, append([]ast.Expression{e.Object}, e.Args...)...)
+	case *ast.SuperCallExpression:
+		c.checkAll(tenv, e.Args...)
+	}
+}
+
+func (c *checker) checkAll(tenv typeEnv, exprs ...ast.Expression) {
+	for _, expr := range exprs {
+		c.check(expr, tenv)
+	}
+}
+
+//checkModule checks the body of a module like let*, and that each name in its interface is
+//defined with a type that fits the declared one.
+func (c *checker) checkModule(module *ast.ModuleDefinition) {
+	if _, ok := c.modules[module.Name.Value]; ok {
+		c.errorf(module.Name.Token, "module %s is already defined", module.Name.Value)
+		return
+	}
+	tenv := typeEnv{}
+	for _, binding := range module.Body {
+		valueType := c.check(binding.Value, tenv)
+		c.types[binding.Name] = valueType
+		tenv = tenv.extendScheme(binding.Name.Value, generalize(valueType, tenv))
+	}
+
+	exports := map[string]scheme{}
+	for _, decl := range module.Interface {
+		s, ok := tenv.lookup(decl.Name.Value)
+		if !ok {
+			c.errorf(decl.Name.Token, "module %s does not define %s declared in its interface", module.Name.Value, decl.Name.Value)
+			continue
+		}
+		declared := c.annotation(decl.Type)
+		defined := c.instantiate(s)
+		c.unifyOrReport(decl.Name.Token, declared, defined, "module %s declares %s : %s, but its body defines it as %s",
+			module.Name.Value, decl.Name.Value, decl.Type, resolve(defined))
+		exports[decl.Name.Value] = generalize(declared, typeEnv{})
+	}
+	c.modules[module.Name.Value] = exports
+}
+
+func (c *checker) checkPrimApp(e *ast.PrimAppExpression, tenv typeEnv) ast.Type {
+	sig, ok := primitiveTypes[e.Name]
+	if !ok {
+		c.unsupportedf(e.Token, e.Name)
+		c.checkAll(tenv, e.Args...)
+		return c.fresh()
+	}
+	if variadicPrimitives[e.Name] {
+		for _, arg := range e.Args {
+			c.expect(arg, tenv, sig.Params[0], e.Name)
+		}
+		return sig.Result
+	}
+	if len(e.Args) != len(sig.Params) {
+		c.errorf(e.Token, "%s expects %d argument(s), got %d", e.Name, len(sig.Params), len(e.Args))
+		return sig.Result
+	}
+	for i, arg := range e.Args {
+		c.expect(arg, tenv, sig.Params[i], e.Name)
+	}
+	return sig.Result
+}
+
+func (c *checker) checkCall(e *ast.CallExpression, tenv typeEnv) ast.Type {
+	operatorType := c.check(e.Operator, tenv)
+	argTypes := make([]ast.Type, len(e.Operands))
+	for i, operand := range e.Operands {
+		argTypes[i] = c.check(operand, tenv)
+	}
+	switch proc := resolve(operatorType).(type) {
+	case *TypeVar:
+		result := c.fresh()
+		c.unifyOrReport(e.Token, proc, &ast.ProcType{Params: argTypes, Result: result},
+			"call expects a procedure, got %s", proc)
+		return result
+	case *ast.ProcType:
+		if len(argTypes) != len(proc.Params) {
+			c.errorf(e.Token, "Procedure of type %s expects %d argument(s), got %d",
+				proc, len(proc.Params), len(argTypes))
+			return proc.Result
+		}
+		for i, argType := range argTypes {
+			c.unifyOrReport(e.Operands[i].GetToken(), argType, proc.Params[i],
+				"argument %d of call expects %s, got %s", i+1, resolve(proc.Params[i]), resolve(argType))
+		}
+		return proc.Result
+	default:
+		c.errorf(e.Operator.GetToken(), "call expects a procedure, got %s", proc)
+		return c.fresh()
+	}
+}
+
+//checkLetrec checks the procs with their own types, then generalizes them for the body.
+func (c *checker) checkLetrec(e *ast.LetrecExpression, tenv typeEnv) ast.Type {
+	procTypes := make([]*ast.ProcType, len(e.Procs))
+	recEnv := tenv
+	for i, proc := range e.Procs {
+		procTypes[i] = &ast.ProcType{
+			Params: c.paramTypes(proc.Params, proc.ParamTypes),
+			Result: c.annotation(proc.ResultType),
+		}
+		recEnv = recEnv.extend(proc.Name.Value, procTypes[i])
+	}
+	for i, proc := range e.Procs {
+		bodyEnv := recEnv
+		for j, param := range proc.Params {
+			bodyEnv = bodyEnv.extend(param.Value, procTypes[i].Params[j])
+		}
+		bodyType := c.check(proc.Body, bodyEnv)
+		c.unifyOrReport(proc.Name.Token, bodyType, procTypes[i].Result,
+			"%s should return %s, but its body has type %s",
+			proc.Name.Value, resolve(procTypes[i].Result), resolve(bodyType))
+	}
+	newEnv := tenv
+	for i, proc := range e.Procs {
+		c.types[proc.Name] = procTypes[i]
+		newEnv = newEnv.extendScheme(proc.Name.Value, generalize(procTypes[i], tenv))
+	}
+	return c.check(e.In, newEnv)
+}
+
+//paramTypes returns the type of each param from its annotation, or a type var to infer it.
+func (c *checker) paramTypes(params []*ast.Identifier, annotations []ast.Type) []ast.Type {
+	types := make([]ast.Type, len(params))
+	for i, param := range params {
+		var annotation ast.Type
+		if i < len(annotations) {
+			annotation = annotations[i]
+		}
+		types[i] = c.annotation(annotation)
+		c.types[param] = types[i]
+	}
+	return types
+}
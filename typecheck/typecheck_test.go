@@ -0,0 +1,252 @@
+package typecheck
+
+import (
+	"let_lang_proj_michael_andrepont/ast"
+	"let_lang_proj_michael_andrepont/lexer"
+	"let_lang_proj_michael_andrepont/parser"
+	"let_lang_proj_michael_andrepont/token"
+	"strings"
+	"testing"
+)
+
+func parseSource(t *testing.T, input string) ast.Expression {
+	lxr := lexer.New(input)
+	tokens := []token.Token{}
+	for tok := lxr.NextToken(); tok.Type != token.EOF; tok = lxr.NextToken() {
+		tokens = append(tokens, tok)
+	}
+	tokens = append(tokens, token.Token{Type: token.EOF, Literal: ""})
+	prs := parser.New(tokens)
+	expr := prs.ParseExpression()
+	if len(prs.Errors()) != 0 {
+		t.Fatalf("Could not parse %q: %s", input, strings.Join(prs.Errors(), "; "))
+	}
+	return expr
+}
+
+func checkType(t *testing.T, input string, expected string) {
+	result, errs := Check(parseSource(t, input))
+	if len(errs) != 0 {
+		t.Fatalf("Expected %q to type check but got errors: %v", input, errs)
+	}
+	if result.String() != expected {
+		t.Errorf("Expected %q to have type %s but was %s", input, expected, result)
+	}
+}
+
+//checkTypeErrors checks that input has exactly the expected errors, in order.
+func checkTypeErrors(t *testing.T, input string, expected ...string) {
+	_, errs := Check(parseSource(t, input))
+	if len(errs) != len(expected) {
+		t.Fatalf("Expected %d type error(s) for %q but got %d: %v", len(expected), input, len(errs), errs)
+	}
+	for i, err := range errs {
+		if !strings.Contains(err.Error(), expected[i]) {
+			t.Errorf("Expected type error to contain: [%s] but was: [%s]", expected[i], err.Error())
+		}
+	}
+}
+
+func TestCheckSimpleTypes(t *testing.T) {
+	checkType(t, "5", "int")
+	checkType(t, "zero?(1)", "bool")
+	checkType(t, "let x = 5 in minus(x, 1)", "int")
+	checkType(t, "if less?(1, 2) then true else false", "bool")
+	checkType(t, "let x = 1 y = true in y", "bool")
+	checkType(t, "let* x = 1 y = zero?(x) in y", "bool")
+	checkType(t, "1 + 2 * 3", "int")
+	checkType(t, "begin 1; true end", "bool")
+	checkType(t, `string-append("a", number->string(string-length("bc")), "d")`, "string")
+	checkType(t, `let f = proc (s : string) string=?(s, substring("abc", 0, 1)) in (f "a")`, "bool")
+}
+
+func TestCheckProcs(t *testing.T) {
+	checkType(t, "proc (x : int) zero?(x)", "(int -> bool)")
+	checkType(t, "proc (x : int, y : bool) if y then x else 0", "(int * bool -> int)")
+	checkType(t, "proc () 1", "(-> int)")
+	checkType(t, "let f = proc (x : int) minus(x, 1) in (f 3)", "int")
+	checkType(t, "proc (f : (int -> bool), x : int) (f x)", "((int -> bool) * int -> bool)")
+}
+
+func TestCheckLetrec(t *testing.T) {
+	checkType(t, "letrec int double(x : int) = if zero?(x) then 0 else minus((double minus(x, 1)), -2) in (double 6)", "int")
+	checkType(t, `letrec bool even(x : int) = if zero?(x) then true else (odd minus(x, 1))
+	                    bool odd(x : int) = if zero?(x) then false else (even minus(x, 1))
+	              in odd`, "(int -> bool)")
+	checkType(t, "letrec (int -> int) adder(x : int) = proc (y : int) x + y in ((adder 1) 2)", "int")
+}
+
+func TestCheckErrorsHavePositions(t *testing.T) {
+	checkTypeErrors(t, "let x = 5\nin minus(x, zero?(x))", "2:13: minus expects int, got bool")
+	checkTypeErrors(t, "if 1 then 2 else 3", "1:4: if expects bool, got int")
+	checkTypeErrors(t, "y", "1:1: unbound variable y")
+}
+
+func TestCheckReportsAllErrors(t *testing.T) {
+	checkTypeErrors(t, "let a = zero?(true) in if a then minus(a, 1) else false",
+		"1:15: zero? expects int, got bool",
+		"1:40: minus expects int, got bool",
+		"1:24: if branches have different types, int and bool")
+}
+
+func TestCheckCallErrors(t *testing.T) {
+	checkTypeErrors(t, "(5 1)", "1:2: call expects a procedure, got int")
+	checkTypeErrors(t, "let f = proc (x : int) x in (f true)", "1:32: argument 1 of call expects int, got bool")
+	checkTypeErrors(t, "let f = proc (x : int) x in (f 1 2)", "1:29: Procedure of type (int -> int) expects 1 argument(s), got 2")
+}
+
+func TestCheckAnnotationErrors(t *testing.T) {
+	checkTypeErrors(t, "letrec bool f(x : int) = x in 1", "1:13: f should return bool, but its body has type int")
+	checkTypeErrors(t, "proc (x : bool) minus(x, 1)", "1:23: minus expects int, got bool")
+}
+
+func TestInferUnannotated(t *testing.T) {
+	checkType(t, "proc (x) minus(x, 1)", "(int -> int)")
+	checkType(t, "proc (x) x", "(t1 -> t1)")
+	checkType(t, "proc (f, x) (f (f x))", "((t1 -> t1) * t1 -> t1)")
+	checkType(t, "proc (f) proc (g) proc (x) (f (g x))", "((t1 -> t2) -> ((t3 -> t1) -> (t3 -> t2)))")
+	checkType(t, "letrec f(x) = if zero?(x) then 0 else minus((f minus(x, 1)), -2) in f", "(int -> int)")
+	checkType(t, "letrec loop(x) = (loop x) in loop", "(t1 -> t2)")
+}
+
+func TestInferPlaceholders(t *testing.T) {
+	checkType(t, "proc (x : ?) zero?(x)", "(int -> bool)")
+	checkType(t, "letrec ? f(x : ?) = zero?(x) in f", "(int -> bool)")
+	checkType(t, "proc (f : (? -> bool), x : ?) (f minus(x, 1))", "((int -> bool) * int -> bool)")
+}
+
+func TestInferLetPolymorphism(t *testing.T) {
+	checkType(t, "let id = proc (x) x in if (id true) then (id 1) else 2", "int")
+	checkType(t, "letrec id(x) = x in let f = proc (y) (id y) in (f zero?((f 0)))", "bool")
+	checkType(t, "let id = proc (x) x in proc (y) (id y)", "(t1 -> t1)")
+	//A proc parameter is not generalized, so it can only be used at one type.
+	checkTypeErrors(t, "proc (id) if (id true) then (id 1) else 2",
+		"1:33: argument 1 of call expects bool, got int",
+		"1:11: if branches have different types, bool and int")
+}
+
+func TestInferErrors(t *testing.T) {
+	checkTypeErrors(t, "proc (x) (x x)", "1:10: cannot construct the infinite type")
+	checkTypeErrors(t, "proc (x) if x then minus(x, 1) else 0", "1:26: minus expects int, got bool")
+	checkTypeErrors(t, "let f = proc (x) zero?(x) in (f true)", "1:33: argument 1 of call expects int, got bool")
+}
+
+func TestInferSubexpressionTypes(t *testing.T) {
+	expr := parseSource(t, "let f = proc (x) x in (f 3)")
+	inf := Infer(expr)
+	if len(inf.Errors) != 0 {
+		t.Fatalf("Expected no type errors but got %v", inf.Errors)
+	}
+	let := expr.(*ast.LetExpression)
+	proc := let.Value.(*ast.ProcExpression)
+	expected := map[ast.Expression]string{
+		let:            "int",
+		let.Name:       "(t1 -> t1)",
+		proc:           "(t1 -> t1)",
+		proc.Params[0]: "t1",
+		let.In:         "int",
+	}
+	for node, want := range expected {
+		if got := inf.TypeOf(node); got == nil || got.String() != want {
+			t.Errorf("Expected %T to have type %s but was %v", node, want, got)
+		}
+	}
+	inf.Annotate()
+	if proc.GetType().String() != "(t1 -> t1)" {
+		t.Errorf("Expected Annotate to set the proc's type but got %v", proc.GetType())
+	}
+}
+
+//checkUnsupported checks that input has no type errors and exactly the expected unsupported
+//constructs, in order.
+func checkUnsupported(t *testing.T, inf *Inference, input string, expected ...string) {
+	if len(inf.Errors) != 0 {
+		t.Fatalf("Expected %q to have no type errors but got %v", input, inf.Errors)
+	}
+	if len(inf.Unsupported) != len(expected) {
+		t.Fatalf("Expected %d unsupported construct(s) in %q but got %d: %v", len(expected), input, len(inf.Unsupported), inf.Unsupported)
+	}
+	for i, err := range inf.Unsupported {
+		if err.Error() != expected[i] {
+			t.Errorf("Expected unsupported construct to be: [%s] but was: [%s]", expected[i], err.Error())
+		}
+	}
+}
+
+func TestCheckUnsupported(t *testing.T) {
+	input := "let l = list(1, 2) in car(l)"
+	checkUnsupported(t, Infer(parseSource(t, input)), input,
+		"1:9: the type checker does not support list", "1:23: the type checker does not support car")
+	input = "letcc k in 1"
+	checkUnsupported(t, Infer(parseSource(t, input)), input, "1:1: the type checker does not support letcc")
+	input = "minus(3.14, 1)"
+	checkUnsupported(t, Infer(parseSource(t, input)), input, "1:7: the type checker does not support float literals")
+	//Type errors are still found around and inside unsupported constructs.
+	checkTypeErrors(t, "minus(car(emptylist), true)", "minus expects int, got bool")
+	checkTypeErrors(t, "try zero?(true) catch (e) e", "zero? expects int, got bool")
+	checkTypeErrors(t, "try 1 catch (e) raise minus(e, y)", "unbound variable y")
+	checkTypeErrors(t, "letcc k in throw zero?(true) to k", "zero? expects int, got bool")
+	checkTypeErrors(t, "car(list(1, zero?(true)))", "zero? expects int, got bool")
+	checkTypeErrors(t, "let x = 1 in set x = if 1 then 2 else 3", "if expects bool, got int")
+	checkTypeErrors(t, "send new c(zero?(true)) m(z)", "zero? expects int, got bool", "unbound variable z")
+}
+
+func parseProgram(t *testing.T, input string) *ast.Program {
+	lxr := lexer.New(input)
+	tokens := []token.Token{}
+	for tok := lxr.NextToken(); tok.Type != token.EOF; tok = lxr.NextToken() {
+		tokens = append(tokens, tok)
+	}
+	tokens = append(tokens, token.Token{Type: token.EOF, Literal: ""})
+	prs := parser.New(tokens)
+	program := prs.ParseProgram()
+	if len(prs.Errors()) != 0 {
+		t.Fatalf("Could not parse %q: %s", input, strings.Join(prs.Errors(), "; "))
+	}
+	return program
+}
+
+func checkProgramErrors(t *testing.T, input string, expected ...string) {
+	inf := InferProgram(parseProgram(t, input))
+	if len(inf.Errors) != len(expected) {
+		t.Fatalf("Expected %d type error(s) for %q but got %d: %v", len(expected), input, len(inf.Errors), inf.Errors)
+	}
+	for i, err := range inf.Errors {
+		if !strings.Contains(err.Error(), expected[i]) {
+			t.Errorf("Expected type error to contain: [%s] but was: [%s]", expected[i], err.Error())
+		}
+	}
+}
+
+func TestCheckModules(t *testing.T) {
+	inf := InferProgram(parseProgram(t, `
+module m1 interface [a : int  f : (int -> bool)  id : (? -> ?)]
+          body [a = 1  f = proc (x) zero?(x)  id = proc (x) x]
+module m2 interface [b : bool] body [b = (from m1 take f  from m1 take a)]
+if from m2 take b then (from m1 take id 1) else 2`))
+	if len(inf.Errors) != 0 {
+		t.Fatalf("Expected no type errors but got %v", inf.Errors)
+	}
+	if inf.Type.String() != "int" {
+		t.Errorf("Expected program to have type int but was %s", inf.Type)
+	}
+}
+
+func TestCheckModuleErrors(t *testing.T) {
+	checkProgramErrors(t, "module m interface [a : bool] body [a = 1] 0",
+		"1:21: module m declares a : bool, but its body defines it as int")
+	checkProgramErrors(t, "module m interface [a : int] body [b = 1] 0",
+		"1:21: module m does not define a declared in its interface")
+	checkProgramErrors(t, "module m interface [a : int] body [a = 1 b = 2] from m take b",
+		"1:61: module m does not export b")
+	checkProgramErrors(t, "from m take a", "1:6: unknown module m")
+	checkProgramErrors(t, "module m interface [] body [] module m interface [] body [] 0",
+		"1:38: module m is already defined")
+}
+
+func TestCheckClassesUnsupported(t *testing.T) {
+	input := "class c extends object method m () 1\nsend new c() m()"
+	checkUnsupported(t, InferProgram(parseProgram(t, input)), input,
+		"1:1: the type checker does not support class",
+		"2:1: the type checker does not support send", "2:6: the type checker does not support new")
+}
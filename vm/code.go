@@ -0,0 +1,119 @@
+package vm
+
+import (
+	"let_lang_proj_michael_andrepont/ast"
+	"fmt"
+	"strings"
+)
+
+type Op byte
+
+//A and B are the operands of an instruction, the comment says what each op uses them for.
+const (
+	OpConst       Op = iota //push Consts[A], numbers the way the runtime represents them
+	OpLoad                  //push the value at lexical address A
+	OpUnbound               //fail, Names[A] is not bound
+	OpSet                   //assign the top of the stack to lexical address A, push the set's value
+	OpRequireRefs           //fail unless the runtime uses implicit refs, set checks this first
+	OpBind                  //pop A values and bind them to NameLists[B] in front of the env
+	OpUnbind                //drop the first A bindings of the env
+	OpLetrec                //bind the procs Letrecs[A] in front of the env
+	OpClosure               //push a proc of Functions[A] closing over the env
+	OpCheckProc             //fail unless the top of the stack is a proc, before its operands run
+	OpCall                  //call the proc under the top A values with them as its arguments
+	OpReturn                //return the top of the stack to the caller
+	OpSub                   //pop two numbers and push the first minus the second
+	OpZero                  //pop a number and push whether it is zero
+	OpPrim                  //pop B values and push the primitive Names[A] applied to them
+	OpJump                  //continue at A
+	OpJumpIfFalse           //pop a bool and continue at A if it is false
+	OpPop                   //drop the top of the stack
+	OpRaise                 //pop a value and raise it
+	OpTry                   //install a handler at A for raises until OpEndTry
+	OpEndTry                //remove the handler installed by the last OpTry
+	OpLetcc                 //push the continuation that resumes at A, named Names[B]
+	OpThrow                 //pop a continuation and a value, and throw the value to it
+	OpTake                  //push Names[B] taken from module Names[A]
+	OpNew                   //pop B values and push a new object of class Names[A] initialized with them
+	OpSend                  //call method Names[A] of the object under the top B values with them
+	OpSuper                 //call method Names[A] of self's host's superclass with the top B values
+	OpSelf                  //push self
+	OpMissing               //fail, an expression of the AST was missing when it was compiled
+)
+
+var opNames = map[Op]string{
+	OpConst: "const", OpLoad: "load-local", OpUnbound: "unbound", OpSet: "set", OpRequireRefs: "require-refs",
+	OpBind: "bind", OpUnbind: "unbind", OpLetrec: "letrec", OpClosure: "closure", OpCheckProc: "check-proc",
+	OpCall: "call", OpReturn: "return", OpSub: "sub", OpZero: "zero?", OpPrim: "prim",
+	OpJump: "jump", OpJumpIfFalse: "jump-if-false", OpPop: "pop", OpRaise: "raise", OpTry: "try",
+	OpEndTry: "end-try", OpLetcc: "letcc", OpThrow: "throw", OpTake: "take", OpNew: "new",
+	OpSend: "send", OpSuper: "super", OpSelf: "self", OpMissing: "missing",
+}
+
+func (op Op) String() string { return opNames[op] }
+
+type Instruction struct {
+	Op Op
+	A  int
+	B  int
+}
+
+//Function is the code of a proc, or of the program body, a module definition or a method.
+type Function struct {
+	Name   string
+	Params []string
+	Code   []Instruction
+}
+
+//Chunk is a compiled program, instructions refer to its tables by index.
+type Chunk struct {
+	Consts    []ast.ExpVal
+	Names     []string
+	NameLists [][]string
+	Functions []*Function
+	Letrecs   [][]int //The Functions bound by each OpLetrec, in order.
+	bodies    []*procBody
+}
+
+//Disassemble returns a listing of every function in the chunk.
+func (c *Chunk) Disassemble() string {
+	var str strings.Builder
+	for i, fn := range c.Functions {
+		fmt.Fprintf(&str, "fn %d %s (%s):\n", i, fn.Name, strings.Join(fn.Params, ", "))
+		for pc, ins := range fn.Code {
+			line := fmt.Sprintf("%4d  %-14s%s", pc, ins.Op, c.operands(ins))
+			fmt.Fprintln(&str, strings.TrimRight(line, " "))
+		}
+	}
+	return str.String()
+}
+
+func (c *Chunk) operands(ins Instruction) string {
+	switch ins.Op {
+	case OpConst:
+		return fmt.Sprintf("%d\t; %s", ins.A, c.Consts[ins.A])
+	case OpLoad, OpSet, OpUnbind, OpCall, OpJump, OpJumpIfFalse, OpTry:
+		return fmt.Sprint(ins.A)
+	case OpUnbound:
+		return fmt.Sprintf("%d\t; %s", ins.A, c.Names[ins.A])
+	case OpBind:
+		return fmt.Sprintf("%d\t; %s", ins.A, strings.Join(c.NameLists[ins.B], " "))
+	case OpLetrec:
+		fns := make([]string, len(c.Letrecs[ins.A]))
+		for i, fn := range c.Letrecs[ins.A] {
+			fns[i] = fmt.Sprintf("fn %d", fn)
+		}
+		return fmt.Sprintf("%d\t; %s", ins.A, strings.Join(fns, ", "))
+	case OpClosure:
+		return fmt.Sprintf("%d\t; %s", ins.A, c.Functions[ins.A].Name)
+	case OpPrim:
+		return fmt.Sprintf("%d\t; %s", ins.B, c.Names[ins.A])
+	case OpLetcc:
+		return fmt.Sprintf("%d\t; %s", ins.A, c.Names[ins.B])
+	case OpTake:
+		return fmt.Sprintf("\t; %s.%s", c.Names[ins.A], c.Names[ins.B])
+	case OpNew, OpSend, OpSuper:
+		return fmt.Sprintf("%d\t; %s", ins.B, c.Names[ins.A])
+	}
+	return ""
+}
@@ -0,0 +1,318 @@
+package vm
+
+import (
+	"let_lang_proj_michael_andrepont/ast"
+	"fmt"
+)
+
+//Program is a compiled ast.Program.
+type Program struct {
+	Chunk *Chunk
+	//Classes are the program's class declarations with their method bodies compiled.
+	Classes []*ast.ClassDecl
+	Modules []*Module
+	Body    int //The function of the program's expression.
+}
+
+type Module struct {
+	Decl *ast.ModuleDefinition
+	Defs []int //The function of each definition's value, in order.
+}
+
+//scope holds the names of the bindings the env will have at runtime, in the same order, so a
+//variable is loaded by its position.
+type scope []string
+
+func (s scope) extend(names ...string) scope {
+	return append(append(make(scope, 0, len(names)+len(s)), names...), s...)
+}
+
+func (s scope) address(name string) (int, bool) {
+	for i, boundName := range s {
+		if boundName == name {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+type compiler struct {
+	chunk *Chunk
+	fn    *Function
+	names map[string]int
+	//classFields holds the fields of each class compiled so far, inherited ones first.
+	classFields map[string][]string
+	err         error
+}
+
+func newCompiler() *compiler {
+	return &compiler{chunk: &Chunk{}, names: map[string]int{}, classFields: map[string][]string{}}
+}
+
+//CompileProgram compiles the classes, modules and expression of a program.
+func CompileProgram(program *ast.Program) (*Program, error) {
+	c := newCompiler()
+	compiled := &Program{Chunk: c.chunk}
+	for _, class := range program.Classes {
+		compiled.Classes = append(compiled.Classes, c.class(class))
+	}
+	for _, module := range program.Modules {
+		compiledModule := &Module{Decl: module}
+		var defs scope
+		for _, binding := range module.Body {
+			name := module.Name.Value + "." + binding.Name.Value
+			compiledModule.Defs = append(compiledModule.Defs, c.function(name, nil, binding.Value, defs))
+			defs = defs.extend(binding.Name.Value)
+		}
+		compiled.Modules = append(compiled.Modules, compiledModule)
+	}
+	compiled.Body = c.function("program", nil, program.Body, scope{})
+	return compiled, c.err
+}
+
+//Compile compiles expr to run in env, the names of env's bindings are the scope of expr.
+func Compile(expr ast.Expression, env ast.BindingList) (*Chunk, error) {
+	c := newCompiler()
+	s := make(scope, len(env))
+	for i, b := range env {
+		s[i] = b.VarName
+	}
+	c.function("program", nil, expr, s)
+	return c.chunk, c.err
+}
+
+func (c *compiler) emit(op Op, a int, b int) int {
+	c.fn.Code = append(c.fn.Code, Instruction{Op: op, A: a, B: b})
+	return len(c.fn.Code) - 1
+}
+
+//patch points the jump at pc to the next instruction emitted.
+func (c *compiler) patch(pc int) {
+	c.fn.Code[pc].A = len(c.fn.Code)
+}
+
+func (c *compiler) name(name string) int {
+	if i, ok := c.names[name]; ok {
+		return i
+	}
+	c.chunk.Names = append(c.chunk.Names, name)
+	c.names[name] = len(c.chunk.Names) - 1
+	return c.names[name]
+}
+
+func (c *compiler) constant(val ast.ExpVal) int {
+	c.chunk.Consts = append(c.chunk.Consts, val)
+	return len(c.chunk.Consts) - 1
+}
+
+//function compiles body as a new function whose params are bound in front of s.
+func (c *compiler) function(name string, params []string, body ast.Expression, s scope) int {
+	fn := &Function{Name: name, Params: params}
+	index := len(c.chunk.Functions)
+	c.chunk.Functions = append(c.chunk.Functions, fn)
+	c.chunk.bodies = append(c.chunk.bodies, &procBody{chunk: c.chunk, fn: index})
+	enclosing := c.fn
+	c.fn = fn
+	c.compile(body, s.extend(params...))
+	c.emit(OpReturn, 0, 0)
+	c.fn = enclosing
+	return index
+}
+
+//class compiles the methods of decl. A method's env is built by the runtime, self and the
+//host's superclass come after the params, then the fields with the last declared first.
+func (c *compiler) class(decl *ast.ClassDecl) *ast.ClassDecl {
+	fields := append([]string{}, c.classFields[decl.Super.Value]...)
+	for _, field := range decl.Fields {
+		fields = append(fields, field.Value)
+	}
+	c.classFields[decl.Name.Value] = fields
+	methodScope := scope{"%self", "%super"}
+	for i := len(fields) - 1; i >= 0; i-- {
+		methodScope = append(methodScope, fields[i])
+	}
+
+	compiled := &ast.ClassDecl{Token: decl.Token, Name: decl.Name, Super: decl.Super, Fields: decl.Fields}
+	for _, method := range decl.Methods {
+		fn := c.function(decl.Name.Value+"."+method.Name.Value, identNames(method.Params), method.Body, methodScope)
+		compiled.Methods = append(compiled.Methods, &ast.MethodDecl{
+			Token:  method.Token,
+			Name:   method.Name,
+			Params: method.Params,
+			Body:   c.chunk.bodies[fn],
+		})
+	}
+	return compiled
+}
+
+func (c *compiler) compileAll(exprs []ast.Expression, s scope) {
+	for _, expr := range exprs {
+		c.compile(expr, s)
+	}
+}
+
+//compile emits code that leaves the value of expr on the stack.
+func (c *compiler) compile(expr ast.Expression, s scope) {
+	switch e := expr.(type) {
+	case nil:
+		c.emit(OpMissing, 0, 0)
+	case *ast.IntLiteral:
+		c.emit(OpConst, c.constant(e.Num()), 0)
+	case *ast.FloatLiteral:
+		c.emit(OpConst, c.constant(ast.FloatVal{Value: e.Value}), 0)
+	case *ast.StringLiteral:
+		c.emit(OpConst, c.constant(ast.StrVal{Value: e.Value}), 0)
+	case *ast.BoolLiteral:
+		c.emit(OpConst, c.constant(ast.BoolVal{Value: e.Value}), 0)
+	case *ast.EmptyListLiteral:
+		c.emit(OpConst, c.constant(ast.EmptyListVal{}), 0)
+	case *ast.Identifier:
+		c.load(e.Value, s)
+	case *ast.LetExpression:
+		c.compile(e.Value, s)
+		c.emit(OpBind, 1, c.nameList(e.Name.Value))
+		c.compile(e.In, s.extend(e.Name.Value))
+		c.emit(OpUnbind, 1, 0)
+	case *ast.MultiLetExpression:
+		var names []string
+		for _, binding := range e.Bindings {
+			c.compile(binding.Value, s)
+			names = append(names, binding.Name.Value)
+		}
+		c.emit(OpBind, len(names), c.nameList(names...))
+		c.compile(e.In, s.extend(names...))
+		c.emit(OpUnbind, len(names), 0)
+	case *ast.LetStarExpression:
+		inner := s
+		for _, binding := range e.Bindings {
+			c.compile(binding.Value, inner)
+			c.emit(OpBind, 1, c.nameList(binding.Name.Value))
+			inner = inner.extend(binding.Name.Value)
+		}
+		c.compile(e.In, inner)
+		c.emit(OpUnbind, len(e.Bindings), 0)
+	case *ast.MinusExpression:
+		c.compile(e.Arg1, s)
+		c.compile(e.Arg2, s)
+		c.emit(OpSub, 0, 0)
+	case *ast.IsZeroExpression:
+		c.compile(e.Arg1, s)
+		c.emit(OpZero, 0, 0)
+	case *ast.PrimAppExpression:
+		c.compileAll(e.Args, s)
+		c.emit(OpPrim, c.name(e.Name), len(e.Args))
+	case *ast.IfThenElseExpression:
+		c.compile(e.Value, s)
+		toElse := c.emit(OpJumpIfFalse, 0, 0)
+		c.compile(e.TrueBranch, s)
+		toEnd := c.emit(OpJump, 0, 0)
+		c.patch(toElse)
+		c.compile(e.FalseBranch, s)
+		c.patch(toEnd)
+	case *ast.ProcExpression:
+		c.emit(OpClosure, c.function("proc", identNames(e.Params), e.Body, s), 0)
+	case *ast.CallExpression:
+		c.compile(e.Operator, s)
+		c.emit(OpCheckProc, 0, 0)
+		c.compileAll(e.Operands, s)
+		c.emit(OpCall, len(e.Operands), 0)
+	case *ast.LetrecExpression:
+		var names []string
+		for _, proc := range e.Procs {
+			names = append(names, proc.Name.Value)
+		}
+		recScope := s.extend(names...)
+		var fns []int
+		for _, proc := range e.Procs {
+			fns = append(fns, c.function(proc.Name.Value, identNames(proc.Params), proc.Body, recScope))
+		}
+		c.chunk.Letrecs = append(c.chunk.Letrecs, fns)
+		c.emit(OpLetrec, len(c.chunk.Letrecs)-1, 0)
+		c.compile(e.In, recScope)
+		c.emit(OpUnbind, len(fns), 0)
+	case *ast.BeginExpression:
+		if len(e.Exprs) == 0 {
+			c.emit(OpConst, c.constant(nil), 0)
+		}
+		for i, expr := range e.Exprs {
+			c.compile(expr, s)
+			if i < len(e.Exprs)-1 {
+				c.emit(OpPop, 0, 0)
+			}
+		}
+	case *ast.SetExpression:
+		c.emit(OpRequireRefs, 0, 0)
+		c.compile(e.Value, s)
+		if depth, ok := s.address(e.Name.Value); ok {
+			c.emit(OpSet, depth, 0)
+		} else {
+			c.emit(OpUnbound, c.name(e.Name.Value), 0)
+		}
+	case *ast.RaiseExpression:
+		c.compile(e.Value, s)
+		c.emit(OpRaise, 0, 0)
+	case *ast.TryExpression:
+		try := c.emit(OpTry, 0, 0)
+		c.compile(e.Body, s)
+		c.emit(OpEndTry, 0, 0)
+		toEnd := c.emit(OpJump, 0, 0)
+		//The handler starts with the raised value on the stack.
+		c.patch(try)
+		c.emit(OpBind, 1, c.nameList(e.Var.Value))
+		c.compile(e.Handler, s.extend(e.Var.Value))
+		c.emit(OpUnbind, 1, 0)
+		c.patch(toEnd)
+	case *ast.LetccExpression:
+		letcc := c.emit(OpLetcc, 0, c.name(e.Name.Value))
+		c.emit(OpBind, 1, c.nameList(e.Name.Value))
+		c.compile(e.Body, s.extend(e.Name.Value))
+		c.emit(OpUnbind, 1, 0)
+		c.patch(letcc)
+	case *ast.ThrowExpression:
+		c.compile(e.Value, s)
+		c.compile(e.Cont, s)
+		c.emit(OpThrow, 0, 0)
+	case *ast.QualifiedVarExpression:
+		c.emit(OpTake, c.name(e.Module.Value), c.name(e.Var.Value))
+	case *ast.NewObjectExpression:
+		c.compileAll(e.Args, s)
+		c.emit(OpNew, c.name(e.Class.Value), len(e.Args))
+	case *ast.MethodCallExpression:
+		c.compile(e.Object, s)
+		c.compileAll(e.Args, s)
+		c.emit(OpSend, c.name(e.Method.Value), len(e.Args))
+	case *ast.SuperCallExpression:
+		c.compileAll(e.Args, s)
+		c.emit(OpSuper, c.name(e.Method.Value), len(e.Args))
+	case *ast.SelfExpression:
+		c.emit(OpSelf, 0, 0)
+	default:
+		if c.err == nil {
+			tok := expr.GetToken()
+			c.err = fmt.Errorf("%d:%d: the VM can not compile %T", tok.Line, tok.Column, expr)
+		}
+	}
+}
+
+//load emits a load of name, or a failure when it is unbound so the error happens when it runs
+//like it does with the Eval methods.
+func (c *compiler) load(name string, s scope) {
+	if depth, ok := s.address(name); ok {
+		c.emit(OpLoad, depth, 0)
+	} else {
+		c.emit(OpUnbound, c.name(name), 0)
+	}
+}
+
+func (c *compiler) nameList(names ...string) int {
+	c.chunk.NameLists = append(c.chunk.NameLists, names)
+	return len(c.chunk.NameLists) - 1
+}
+
+func identNames(idents []*ast.Identifier) []string {
+	names := make([]string, len(idents))
+	for i, ident := range idents {
+		names[i] = ident.Value
+	}
+	return names
+}
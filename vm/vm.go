@@ -0,0 +1,374 @@
+package vm
+
+import (
+	"let_lang_proj_michael_andrepont/ast"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+//The VM runs compiled code with an explicit stack of frames and values, the envs are the same
+//BindingLists the Eval methods use but variables are loaded by their lexical address. Procs
+//are applied with the runtime's BindArgs and primitives with ApplyPrimitive, so the scheduler
+//counts the same steps as it does for the Eval methods.
+
+//procBody is the Body of a proc made by the VM. Applying the proc outside the VM, like a
+//spawned thread does, runs it on a new machine.
+type procBody struct {
+	ast.BaseExpression
+	chunk *Chunk
+	fn    int
+}
+
+func (b *procBody) Eval(env ast.BindingList, rt *ast.Runtime) (ast.ExpVal, error) {
+	return newMachine(rt, b.chunk, b.fn, env).run()
+}
+func (b *procBody) Print(indentLevel int) {
+	fmt.Printf("%s<fn %d %s>\n", strings.Repeat("\t", indentLevel), b.fn, b.chunk.Functions[b.fn].Name)
+}
+
+type frame struct {
+	fn  *Function
+	pc  int
+	env ast.BindingList
+	//result replaces the value the function returns when set, new returns its object instead
+	//of the value of initialize.
+	result ast.ExpVal
+}
+
+//handler is installed by try, a raise goes back to the frame and stack height it was installed at.
+type handler struct {
+	frames int
+	stack  int
+	pc     int
+	env    ast.BindingList
+}
+
+type machine struct {
+	rt       *ast.Runtime
+	chunk    *Chunk
+	frames   []frame
+	stack    []ast.ExpVal
+	handlers []handler
+}
+
+//snapshot is the Frame of a ContVal made by the VM, a copy of the machine to resume.
+type snapshot struct {
+	frames   []frame
+	stack    []ast.ExpVal
+	handlers []handler
+}
+
+func newMachine(rt *ast.Runtime, chunk *Chunk, fn int, env ast.BindingList) *machine {
+	return &machine{rt: rt, chunk: chunk, frames: []frame{{fn: chunk.Functions[fn], env: env}}}
+}
+
+func (m *machine) push(val ast.ExpVal) {
+	m.stack = append(m.stack, val)
+}
+
+func (m *machine) pop() ast.ExpVal {
+	val := m.stack[len(m.stack)-1]
+	m.stack = m.stack[:len(m.stack)-1]
+	return val
+}
+
+//popN pops the top n values, in the order they were pushed.
+func (m *machine) popN(n int) []ast.ExpVal {
+	vals := append([]ast.ExpVal{}, m.stack[len(m.stack)-n:]...)
+	m.stack = m.stack[:len(m.stack)-n]
+	return vals
+}
+
+func (m *machine) run() (ast.ExpVal, error) {
+	for {
+		f := &m.frames[len(m.frames)-1]
+		ins := f.fn.Code[f.pc]
+		f.pc++
+		var err error
+		switch ins.Op {
+		case OpConst:
+			var val ast.ExpVal
+			if val, err = m.rt.Number(m.chunk.Consts[ins.A]); err == nil {
+				m.push(val)
+			}
+		case OpLoad:
+			var val ast.ExpVal
+			if val, err = m.rt.LookupAddress(ins.A, f.env); err == nil {
+				m.push(val)
+			}
+		case OpUnbound:
+			err = errors.New(fmt.Sprintf("Could not find variable name: %s in env of: %#v", m.chunk.Names[ins.A], f.env))
+		case OpSet:
+			var val ast.ExpVal
+			if val, err = m.rt.SetAddress(ins.A, m.pop(), f.env); err == nil {
+				m.push(val)
+			}
+		case OpRequireRefs:
+			if !m.rt.ImplicitRefs {
+				err = ast.ErrSetWithoutImplicitRefs
+			}
+		case OpBind:
+			names := m.chunk.NameLists[ins.B]
+			vals := m.popN(ins.A)
+			newEnv := make(ast.BindingList, 0, len(vals)+len(f.env))
+			for i, val := range vals {
+				newEnv = append(newEnv, m.rt.NewBinding(names[i], val))
+			}
+			f.env = append(newEnv, f.env...)
+		case OpUnbind:
+			f.env = f.env[ins.A:]
+		case OpLetrec:
+			fns := m.chunk.Letrecs[ins.A]
+			newEnv := make(ast.BindingList, 0, len(fns)+len(f.env))
+			for i, fn := range fns {
+				newEnv = append(newEnv, ast.Binding{
+					VarName: m.chunk.Functions[fn].Name,
+					Rec:     &ast.RecProc{Params: m.chunk.Functions[fn].Params, Body: m.chunk.bodies[fn], Index: i},
+				})
+			}
+			f.env = append(newEnv, f.env...)
+		case OpClosure:
+			m.push(&ast.ProcVal{Params: m.chunk.Functions[ins.A].Params, Body: m.chunk.bodies[ins.A], Env: f.env})
+		case OpCheckProc:
+			if operator := m.stack[len(m.stack)-1]; !isProc(operator) {
+				err = &ast.TypeError{Operation: "call", Expected: "a procedure", Got: operator}
+			}
+		case OpCall:
+			args := m.popN(ins.A)
+			err = m.call(m.pop().(*ast.ProcVal), args, nil)
+		case OpReturn:
+			val := m.pop()
+			if f.result != nil {
+				val = f.result
+			}
+			m.frames = m.frames[:len(m.frames)-1]
+			if len(m.frames) == 0 {
+				return val, nil
+			}
+			m.push(val)
+		case OpSub:
+			err = m.primitive("minus", m.popN(2))
+		case OpZero:
+			err = m.primitive("zero?", m.popN(1))
+		case OpPrim:
+			err = m.primitive(m.chunk.Names[ins.A], m.popN(ins.B))
+		case OpJump:
+			f.pc = ins.A
+		case OpJumpIfFalse:
+			val := m.pop()
+			predicate, ok := val.(ast.BoolVal)
+			if !ok {
+				err = &ast.TypeError{Operation: "if", Expected: "a bool", Got: val}
+			} else if !predicate.Value {
+				f.pc = ins.A
+			}
+		case OpPop:
+			m.pop()
+		case OpRaise:
+			err = m.raise(m.pop())
+		case OpTry:
+			m.handlers = append(m.handlers, handler{frames: len(m.frames), stack: len(m.stack), pc: ins.A, env: f.env})
+		case OpEndTry:
+			m.handlers = m.handlers[:len(m.handlers)-1]
+		case OpLetcc:
+			m.push(&ast.ContVal{Name: m.chunk.Names[ins.B], Frame: m.capture(ins.A)})
+		case OpThrow:
+			err = m.throw(m.pop(), m.pop())
+		case OpTake:
+			var val ast.ExpVal
+			if val, err = m.rt.LookupQualified(m.chunk.Names[ins.A], m.chunk.Names[ins.B]); err == nil {
+				m.push(val)
+			}
+		case OpNew:
+			err = m.newObject(m.chunk.Names[ins.A], m.popN(ins.B))
+		case OpSend:
+			args := m.popN(ins.B)
+			var proc *ast.ProcVal
+			if proc, err = m.rt.MethodProc(m.pop(), m.chunk.Names[ins.A]); err == nil {
+				err = m.call(proc, args, nil)
+			}
+		case OpSuper:
+			args := m.popN(ins.B)
+			var proc *ast.ProcVal
+			if proc, err = m.rt.SuperMethodProc(f.env, m.chunk.Names[ins.A]); err == nil {
+				err = m.call(proc, args, nil)
+			}
+		case OpSelf:
+			var self *ast.ObjectVal
+			if self, err = m.rt.Self(f.env); err == nil {
+				m.push(self)
+			}
+		case OpMissing:
+			err = fmt.Errorf("Missing expression at %d in %s", f.pc-1, f.fn.Name)
+		default:
+			err = fmt.Errorf("Unknown instruction %d at %d in %s", ins.Op, f.pc-1, f.fn.Name)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+func isProc(val ast.ExpVal) bool {
+	_, ok := val.(*ast.ProcVal)
+	return ok
+}
+
+func (m *machine) primitive(name string, args []ast.ExpVal) error {
+	val, err := m.rt.ApplyPrimitive(name, args)
+	if err != nil {
+		return err
+	}
+	m.push(val)
+	return nil
+}
+
+//call pushes a frame for proc, or applies it right away if it was not compiled into this chunk.
+func (m *machine) call(proc *ast.ProcVal, args []ast.ExpVal, result ast.ExpVal) error {
+	env, err := m.rt.BindArgs(proc, args)
+	if err != nil {
+		return err
+	}
+	if body, ok := proc.Body.(*procBody); ok && body.chunk == m.chunk {
+		m.frames = append(m.frames, frame{fn: m.chunk.Functions[body.fn], env: env, result: result})
+		return nil
+	}
+	val, err := proc.Body.Eval(env, m.rt)
+	if exception, ok := err.(*ast.RaisedException); ok {
+		return m.raise(exception.Value)
+	} else if err != nil {
+		return err
+	}
+	if result != nil {
+		val = result
+	}
+	m.push(val)
+	return nil
+}
+
+func (m *machine) newObject(className string, args []ast.ExpVal) error {
+	obj, err := m.rt.NewObject(className)
+	if err != nil {
+		return err
+	}
+	initialize, err := m.rt.Initializer(obj, len(args))
+	if err != nil {
+		return err
+	}
+	if initialize == nil {
+		m.push(obj)
+		return nil
+	}
+	return m.call(initialize, args, obj)
+}
+
+//raise goes back to the handler of the closest try with val on the stack.
+func (m *machine) raise(val ast.ExpVal) error {
+	if len(m.handlers) == 0 {
+		return &ast.RaisedException{Value: val}
+	}
+	h := m.handlers[len(m.handlers)-1]
+	m.handlers = m.handlers[:len(m.handlers)-1]
+	m.frames = m.frames[:h.frames]
+	f := &m.frames[len(m.frames)-1]
+	f.pc, f.env = h.pc, h.env
+	m.stack = m.stack[:h.stack]
+	m.push(val)
+	return nil
+}
+
+//capture copies the machine, resuming it continues in the current frame at pc.
+func (m *machine) capture(pc int) *snapshot {
+	frames := append([]frame{}, m.frames...)
+	frames[len(frames)-1].pc = pc
+	return &snapshot{
+		frames:   frames,
+		stack:    append([]ast.ExpVal{}, m.stack...),
+		handlers: append([]handler{}, m.handlers...),
+	}
+}
+
+//throw abandons the machine's state and resumes the continuation k with val. The snapshot is
+//copied again so k can be thrown to more than once.
+func (m *machine) throw(k ast.ExpVal, val ast.ExpVal) error {
+	contVal, ok := k.(*ast.ContVal)
+	if !ok {
+		return &ast.TypeError{Operation: "throw", Expected: "a continuation", Got: k}
+	}
+	snap, ok := contVal.Frame.(*snapshot)
+	if !ok {
+		return fmt.Errorf("Cannot throw to %s, it was not captured by the VM", contVal)
+	}
+	m.frames = append([]frame{}, snap.frames...)
+	m.stack = append([]ast.ExpVal{}, snap.stack...)
+	m.handlers = append([]handler{}, snap.handlers...)
+	m.push(val)
+	return nil
+}
+
+//Eval compiles expr and runs it in env.
+func Eval(expr ast.Expression, env ast.BindingList, rt *ast.Runtime) (ast.ExpVal, error) {
+	chunk, err := Compile(expr, env)
+	if err != nil {
+		return nil, err
+	}
+	return newMachine(rt, chunk, 0, env).run()
+}
+
+//EvalProgram compiles a program and runs it on the VM.
+func EvalProgram(rootNode ast.Node, rt *ast.Runtime) (ast.ExpVal, error) {
+	program, ok := rootNode.(*ast.Program)
+	if !ok {
+		expr, ok := rootNode.(ast.Expression)
+		if !ok {
+			return nil, errors.New(fmt.Sprintf("Could not evaluate %T, No eval function exist for that node.", rootNode))
+		}
+		program = &ast.Program{Body: expr}
+	}
+	compiled, err := CompileProgram(program)
+	if err != nil {
+		return nil, err
+	}
+	result, err := Run(compiled, rt)
+	if err != nil {
+		rt.Scheduler.Shutdown()
+		return nil, err
+	}
+	if err := rt.Scheduler.RunRemaining(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+//Run defines the classes and modules of a compiled program and runs its expression, it
+//leaves any threads the program spawned to the caller. Procs made by the VM run themselves
+//when a spawned thread applies them, so rt must not have an Apply hook from another evaluator.
+func Run(program *Program, rt *ast.Runtime) (ast.ExpVal, error) {
+	if rt.Apply != nil {
+		return nil, errors.New("The VM can not run on a runtime whose Apply hook is set by another evaluator")
+	}
+	for _, class := range program.Classes {
+		if err := rt.DefineClass(class); err != nil {
+			return nil, err
+		}
+	}
+	for _, module := range program.Modules {
+		env := ast.BindingList{}
+		for i, fn := range module.Defs {
+			val, err := newMachine(rt, program.Chunk, fn, env).run()
+			if err != nil {
+				return nil, err
+			}
+			env = append(ast.BindingList{rt.NewBinding(module.Decl.Body[i].Name.Value, val)}, env...)
+		}
+		exports, err := module.Decl.Exports(env)
+		if err != nil {
+			return nil, err
+		}
+		if err := rt.DefineModule(module.Decl.Name.Value, exports); err != nil {
+			return nil, err
+		}
+	}
+	return newMachine(rt, program.Chunk, program.Body, ast.BindingList{}).run()
+}
@@ -0,0 +1,124 @@
+package vm
+
+import (
+	"let_lang_proj_michael_andrepont/ast"
+	"let_lang_proj_michael_andrepont/lexer"
+	"let_lang_proj_michael_andrepont/parser"
+	"let_lang_proj_michael_andrepont/token"
+	"strings"
+	"testing"
+)
+
+func parseProgram(t *testing.T, input string) *ast.Program {
+	lxr := lexer.New(input)
+	tokens := []token.Token{}
+	for tok := lxr.NextToken(); tok.Type != token.EOF; tok = lxr.NextToken() {
+		tokens = append(tokens, tok)
+	}
+	tokens = append(tokens, token.Token{Type: token.EOF, Literal: ""})
+	prs := parser.New(tokens)
+	program := prs.ParseProgram()
+	if len(prs.Errors()) != 0 {
+		t.Fatalf("Could not parse %q: %s", input, strings.Join(prs.Errors(), "; "))
+	}
+	return program
+}
+
+func checkVM(t *testing.T, input string, expected string) {
+	checkVMWith(t, ast.NewRuntime(), input, expected)
+}
+
+func checkVMWith(t *testing.T, rt *ast.Runtime, input string, expected string) {
+	result, err := EvalProgram(parseProgram(t, input), rt)
+	if err != nil {
+		t.Fatalf("Could not evaluate %q: %s", input, err)
+	}
+	if rt.Show(result) != expected {
+		t.Errorf("Expected %q to be %s but was %s", input, expected, rt.Show(result))
+	}
+}
+
+func checkVMError(t *testing.T, input string, expected string) {
+	_, err := EvalProgram(parseProgram(t, input), ast.NewRuntime())
+	if err == nil || !strings.Contains(err.Error(), expected) {
+		t.Errorf("Expected %q to fail with [%s] but got %v", input, expected, err)
+	}
+}
+
+func TestDisassemble(t *testing.T) {
+	compiled, err := CompileProgram(parseProgram(t, "let f = proc (x) if zero?(x) then 1 else minus(x, 1) in (f 3)"))
+	if err != nil {
+		t.Fatal(err.Error())
+	}
+	expected := `fn 0 program ():
+   0  closure       1	; proc
+   1  bind          1	; f
+   2  load-local    0
+   3  check-proc
+   4  const         2	; 3
+   5  call          1
+   6  unbind        1
+   7  return
+fn 1 proc (x):
+   0  load-local    0
+   1  zero?
+   2  jump-if-false 5
+   3  const         0	; 1
+   4  jump          8
+   5  load-local    0
+   6  const         1	; 1
+   7  sub
+   8  return
+`
+	if listing := compiled.Chunk.Disassemble(); listing != expected {
+		t.Errorf("Expected the listing to be:\n%s\nbut was:\n%s", expected, listing)
+	}
+}
+
+func TestVMPrograms(t *testing.T) {
+	checkVM(t, "let x = 3 y = 4 in minus(x, y)", "-1")
+	checkVM(t, "let* x = 3 y = minus(x, 1) in list(x, y)", "(3 2)")
+	checkVM(t, "let a = 1 in let f = proc (x) proc (y) list(a, x, y) in ((f 2) 3)", "(1 2 3)")
+	checkVM(t, `letrec even(n) = if zero?(n) then true else (odd minus(n, 1))
+	                   odd(n) = if zero?(n) then false else (even minus(n, 1))
+	            in list((even 10), (odd 7), (odd 4))`, "(true true false)")
+	checkVM(t, "let f = proc (x) raise minus(x, 1) in try (f 5) catch (e) list(e)", "(4)")
+	checkVM(t, "minus(1, letcc k in minus(10, throw 4 to k))", "-3")
+	checkVM(t, "module m interface [x : int] body [y = 2 x = minus(y, 5)] from m take x", "-3")
+}
+
+func TestVMClasses(t *testing.T) {
+	classes := `class c1 extends object
+	              field i
+	              method initialize(x) set i = x
+	              method get() i
+	            class c2 extends c1
+	              method get() minus(super get(), 1)
+	            send new c2(10) get()`
+	rt := ast.NewRuntime()
+	rt.ImplicitRefs = true
+	checkVMWith(t, rt, classes, "9")
+	checkVMError(t, classes, ast.ErrClassesWithoutImplicitRefs.Error())
+}
+
+func TestVMDeepRecursion(t *testing.T) {
+	//The VM keeps its own stack of frames, so recursion is not limited by the Go stack.
+	checkVM(t, "letrec count(n) = if zero?(n) then 0 else minus((count minus(n, 1)), -1) in (count 100000)", "100000")
+}
+
+func TestVMErrors(t *testing.T) {
+	checkVMError(t, "minus(x, 1)", "Could not find variable name: x")
+	checkVMError(t, "(3 4)", "call expects a procedure")
+	checkVMError(t, "if 1 then 2 else 3", "if expects a bool")
+	checkVMError(t, "let f = proc (x) x in (f 1 2)", "expects 1 argument(s), got 2")
+	checkVMError(t, "set x = 1", ast.ErrSetWithoutImplicitRefs.Error())
+}
+
+func TestVMRuntimeWithApplyHook(t *testing.T) {
+	rt := ast.NewRuntime()
+	rt.Apply = func(proc *ast.ProcVal, args []ast.ExpVal) (ast.ExpVal, error) { return nil, nil }
+	_, err := EvalProgram(parseProgram(t, "1"), rt)
+	if err == nil || !strings.Contains(err.Error(), "Apply hook") {
+		t.Errorf("Expected the VM to refuse a runtime with an Apply hook but got %v", err)
+	}
+}